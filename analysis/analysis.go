@@ -0,0 +1,267 @@
+// Package analysis reviews games of engine.Chess: every position is
+// searched, and each move is graded by how much it lost against the best
+// move
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"chess-go/engine"
+)
+
+// MaxCentipawns caps the scores, a mate is worth the cap
+const MaxCentipawns = 1000
+
+// ErrThresholds is returned for thresholds of the grades that are not
+// positive and increasing
+var ErrThresholds = errors.New("analysis: the thresholds of the grades must be positive and increasing")
+
+// DefaultLimits bounds the search of each position
+var DefaultLimits = engine.SearchLimits{Depth: 4, MoveTime: time.Second}
+
+// Eval is the evaluation of a position from the point of view of white
+type Eval struct {
+	// Centipawns is the score when there is no mate
+	Centipawns int
+	// Mate is the number of moves to mate, negative when black mates
+	Mate int
+}
+
+// For returns the evaluation from the point of view of a color
+func (e Eval) For(color engine.Color) Eval {
+	if color == engine.Black {
+		return Eval{Centipawns: -e.Centipawns, Mate: -e.Mate}
+	}
+	return e
+}
+
+// Capped returns the score in centipawns between -MaxCentipawns and
+// MaxCentipawns, mates at the caps
+func (e Eval) Capped() int {
+	switch {
+	case e.Mate > 0:
+		return MaxCentipawns
+	case e.Mate < 0:
+		return -MaxCentipawns
+	case e.Centipawns > MaxCentipawns:
+		return MaxCentipawns
+	case e.Centipawns < -MaxCentipawns:
+		return -MaxCentipawns
+	}
+	return e.Centipawns
+}
+
+// WinChance returns the chance from 0 to 1 to win the game, given by a
+// logistic curve of the capped score
+func (e Eval) WinChance() float64 {
+	return 1 / (1 + math.Exp(-0.00368208*float64(e.Capped())))
+}
+
+// String writes the evaluation like the eval command of PGN comments: in
+// pawns, "0.35", or as a mate, "#-3"
+func (e Eval) String() string {
+	if e.Mate != 0 {
+		return "#" + strconv.Itoa(e.Mate)
+	}
+	return strconv.FormatFloat(float64(e.Centipawns)/100, 'f', 2, 64)
+}
+
+// Grade is the quality of a move
+type Grade int
+
+const (
+	Best Grade = iota
+	Good
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+// String returns the name of the grade
+func (g Grade) String() string {
+	switch g {
+	case Best:
+		return "Best"
+	case Good:
+		return "Good"
+	case Inaccuracy:
+		return "Inaccuracy"
+	case Mistake:
+		return "Mistake"
+	case Blunder:
+		return "Blunder"
+	}
+	return "Grade(" + strconv.Itoa(int(g)) + ")"
+}
+
+// NAG returns the Numeric Annotation Glyph of the grade: "?!" for an
+// inaccuracy, "?" for a mistake, "??" for a blunder and 0 otherwise
+func (g Grade) NAG() int {
+	switch g {
+	case Inaccuracy:
+		return 6
+	case Mistake:
+		return 2
+	case Blunder:
+		return 4
+	}
+	return 0
+}
+
+// Grader grades a move that was not the best one by the evaluations before
+// and after it, from the point of view of the player of the move
+type Grader func(before, after Eval) Grade
+
+// CentipawnLoss grades by the loss of capped centipawns
+func CentipawnLoss(inaccuracy, mistake, blunder int) Grader {
+	return func(before, after Eval) Grade {
+		return grade(float64(before.Capped()-after.Capped()), float64(inaccuracy), float64(mistake), float64(blunder))
+	}
+}
+
+// WinChanceLoss grades by the loss of chance to win, from 0 to 1
+func WinChanceLoss(inaccuracy, mistake, blunder float64) Grader {
+	return func(before, after Eval) Grade {
+		return grade(before.WinChance()-after.WinChance(), inaccuracy, mistake, blunder)
+	}
+}
+
+// DefaultGrader grades by the loss of chance to win: 5% is an inaccuracy,
+// 10% a mistake and 15% a blunder
+var DefaultGrader = WinChanceLoss(0.05, 0.10, 0.15)
+
+// NewGrader returns the grader by the loss of centipawns or of chance to
+// win with the least losses of an inaccuracy, a mistake and a blunder, or
+// ErrThresholds when they are not positive and increasing
+func NewGrader(centipawns bool, inaccuracy, mistake, blunder float64) (Grader, error) {
+	if centipawns {
+		inaccuracy, mistake, blunder = math.Round(inaccuracy), math.Round(mistake), math.Round(blunder)
+	}
+	if inaccuracy <= 0 || mistake <= inaccuracy || blunder <= mistake || !centipawns && blunder > 1 {
+		return nil, ErrThresholds
+	}
+
+	if centipawns {
+		return CentipawnLoss(int(inaccuracy), int(mistake), int(blunder)), nil
+	}
+	return WinChanceLoss(inaccuracy, mistake, blunder), nil
+}
+
+// grade returns the grade of a loss
+func grade(loss, inaccuracy, mistake, blunder float64) Grade {
+	switch {
+	case loss >= blunder:
+		return Blunder
+	case loss >= mistake:
+		return Mistake
+	case loss >= inaccuracy:
+		return Inaccuracy
+	}
+	return Good
+}
+
+// Options are the settings of an analysis
+type Options struct {
+	// Limits bounds the search of each position, DefaultLimits when zero
+	Limits engine.SearchLimits
+	// Grader grades the moves, DefaultGrader when nil
+	Grader Grader
+}
+
+// Move is an analyzed move of a game
+type Move struct {
+	SAN   string
+	Color engine.Color
+	// Best is the best move of the search in SAN
+	Best string
+	// Before and After are the evaluations of the positions before and after
+	// the move, a checkmate on the board is worth MaxCentipawns
+	Before Eval
+	After  Eval
+	// Loss is the loss of capped centipawns of the player of the move, never
+	// negative
+	Loss  int
+	Grade Grade
+}
+
+// Analyze searches every position of a game and grades its moves. report is
+// called with each move once it is graded.
+func Analyze(ctx context.Context, chess *engine.Chess, options Options, report func(Move)) ([]Move, error) {
+	if options.Limits == (engine.SearchLimits{}) {
+		options.Limits = DefaultLimits
+	}
+	if options.Grader == nil {
+		options.Grader = DefaultGrader
+	}
+
+	position, err := engine.NewChessGameWithFen(chess.StartingFEN())
+	if err != nil {
+		return nil, err
+	}
+
+	history := chess.History()
+	moves := make([]Move, 0, len(history))
+	before, best := evaluate(ctx, position, options.Limits)
+	for i, played := range history {
+		move := Move{Color: position.Turn(), Before: before}
+		if san, err := position.SAN(best); err == nil {
+			move.Best = san
+		}
+
+		result, err := position.Move(played)
+		if err != nil {
+			return nil, fmt.Errorf("analysis: ply %d: %w", i+1, err)
+		}
+		move.SAN = result.SAN
+
+		var next engine.Move
+		move.After, next = evaluate(ctx, position, options.Limits)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		mover, after := move.Before.For(move.Color), move.After.For(move.Color)
+		if loss := mover.Capped() - after.Capped(); loss > 0 {
+			move.Loss = loss
+		}
+		if played.From == best.From && played.To == best.To && played.Promotion == best.Promotion {
+			move.Grade = Best
+		} else {
+			move.Grade = options.Grader(mover, after)
+		}
+
+		moves = append(moves, move)
+		if report != nil {
+			report(move)
+		}
+		before, best = move.After, next
+	}
+	return moves, nil
+}
+
+// evaluate searches a position and returns its evaluation and its best
+// move. A finished game is not searched: a checkmate is worth
+// MaxCentipawns and a draw nothing.
+func evaluate(ctx context.Context, position *engine.Chess, limits engine.SearchLimits) (Eval, engine.Move) {
+	if position.IsGameOver() {
+		switch position.Outcome() {
+		case engine.WhiteWon:
+			return Eval{Centipawns: MaxCentipawns}, engine.Move{}
+		case engine.BlackWon:
+			return Eval{Centipawns: -MaxCentipawns}, engine.Move{}
+		}
+		return Eval{}, engine.Move{}
+	}
+
+	result := position.Search(ctx, limits, nil)
+	eval := Eval{Centipawns: result.Info.Score, Mate: result.Info.Mate}
+	if eval.Mate != 0 {
+		eval.Centipawns = 0
+	}
+	return eval.For(position.Turn()), result.BestMove
+}
@@ -0,0 +1,191 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+func TestAnalysis_Eval(t *testing.T) {
+	inputs := []Eval{{Centipawns: 35}, {Centipawns: -1520}, {Mate: 3}, {Mate: -2}, {}}
+
+	expectedOutputs := []struct {
+		String string
+		Black  Eval
+		Capped int
+	}{
+		{"0.35", Eval{Centipawns: -35}, 35},
+		{"-15.20", Eval{Centipawns: 1520}, -1000},
+		{"#3", Eval{Mate: -3}, 1000},
+		{"#-2", Eval{Mate: 2}, -1000},
+		{"0.00", Eval{}, 0},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		if input.String() != expected.String || input.For(engine.Black) != expected.Black || input.Capped() != expected.Capped {
+			t.Errorf("FAILED: %+v\n\tgot:     %s %+v %d\n\texpected:%+v", input, input.String(), input.For(engine.Black), input.Capped(), expected)
+		}
+	}
+
+	if chance := (Eval{}).WinChance(); chance != 0.5 {
+		t.Errorf("FAILED: win chance of 0.00\n\tgot:     %f\n\texpected:%f", chance, 0.5)
+	}
+}
+func TestAnalysis_Grader(t *testing.T) {
+	inputs := []struct {
+		Before, After Eval
+	}{
+		{Eval{Centipawns: 20}, Eval{Centipawns: 0}},
+		{Eval{Centipawns: 20}, Eval{Centipawns: -40}},
+		{Eval{Centipawns: 20}, Eval{Centipawns: -120}},
+		{Eval{Centipawns: 20}, Eval{Mate: -4}},
+		// Far ahead, a rook less still wins
+		{Eval{Mate: 5}, Eval{Centipawns: 700}},
+	}
+
+	expectedOutputs := [][2]Grade{
+		{Good, Good},
+		{Inaccuracy, Inaccuracy},
+		{Mistake, Mistake},
+		{Blunder, Blunder},
+		{Blunder, Good},
+	}
+
+	centipawns := CentipawnLoss(50, 100, 300)
+	for i, input := range inputs {
+		output := [2]Grade{centipawns(input.Before, input.After), DefaultGrader(input.Before, input.After)}
+		if output != expectedOutputs[i] {
+			t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%v", input, output, expectedOutputs[i])
+		}
+	}
+}
+func TestAnalysis_NewGrader(t *testing.T) {
+	inputs := []struct {
+		centipawns                   bool
+		inaccuracy, mistake, blunder float64
+	}{
+		{true, 50, 100, 300},
+		{false, 0.05, 0.10, 0.15},
+		{true, 0, 0, 0},
+		{true, 0.05, 0.10, 0.15},
+		{true, 50, 300, 100},
+		{false, -0.05, 0.10, 0.15},
+		{false, 5, 10, 15},
+	}
+
+	expectedOutputs := []struct {
+		grade Grade
+		err   error
+	}{
+		{Mistake, nil},
+		{Mistake, nil},
+		{Good, ErrThresholds},
+		{Good, ErrThresholds},
+		{Good, ErrThresholds},
+		{Good, ErrThresholds},
+		{Good, ErrThresholds},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		grader, err := NewGrader(input.centipawns, input.inaccuracy, input.mistake, input.blunder)
+		if !errors.Is(err, expected.err) {
+			t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%v", input, err, expected.err)
+		}
+		if err != nil {
+			continue
+		}
+
+		// A loss of 110 centipawns from an even position
+		if output := grader(Eval{Centipawns: 20}, Eval{Centipawns: -90}); output != expected.grade {
+			t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%v", input, output, expected.grade)
+		}
+	}
+}
+func TestAnalysis_Annotate(t *testing.T) {
+	games, err := pgn.ReadAll(strings.NewReader("1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 {[%eval 0.10] hoping for Qxf7+} 4. Qxf7# 1-0\n"))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	var reported int
+	moves, err := AnnotateGame(context.Background(), games[0], Options{Limits: engine.SearchLimits{Depth: 2}}, func(Move) { reported++ })
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	if reported != len(moves) {
+		t.Errorf("FAILED: reported\n\tgot:     %d\n\texpected:%d", reported, len(moves))
+	}
+
+	expectedMoves := []Move{
+		{SAN: "Nf6", Color: engine.Black, Best: "g6", Before: Eval{Centipawns: -5}, After: Eval{Mate: 1}, Loss: 1005, Grade: Blunder},
+		{SAN: "Qxf7#", Color: engine.White, Best: "Qxf7#", Before: Eval{Mate: 1}, After: Eval{Centipawns: MaxCentipawns}, Grade: Best},
+	}
+	for i, expected := range expectedMoves {
+		if output := moves[len(moves)-2+i]; output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", expected.SAN, output, expected)
+		}
+	}
+
+	expected := "1. e4 {[%eval 0.40]} 1... e5 {[%eval 0.00]} 2. Qh5 {[%eval 0.00]} 2... Nc6\n" +
+		"{[%eval -0.40]} 3. Bc4 {[%eval -0.05]} 3... Nf6 $4 {[%eval #1] Blunder. g6 was\n" +
+		"best. hoping for Qxf7+} 4. Qxf7# 1-0\n"
+	if _, output, _ := strings.Cut(games[0].String(), "\n\n"); output != expected+"\n" {
+		t.Errorf("FAILED\n\tgot:\n%s\n\texpected:\n%s", output, expected)
+	}
+
+	if err := Annotate(games[0], moves[1:]); err == nil {
+		t.Errorf("FAILED: moves missing\n\tgot:     %v\n\texpected:%s", err, "an error")
+	}
+}
+func TestAnalysis_Stats(t *testing.T) {
+	moves := []Move{
+		{Color: engine.White, Before: Eval{Centipawns: 30}, After: Eval{Centipawns: 40}, Grade: Best},
+		{Color: engine.Black, Before: Eval{Centipawns: 40}, After: Eval{Centipawns: 90}, Loss: 50, Grade: Inaccuracy},
+		{Color: engine.White, Before: Eval{Centipawns: 90}, After: Eval{Centipawns: -210}, Loss: 300, Grade: Blunder},
+		{Color: engine.Black, Before: Eval{Centipawns: -210}, After: Eval{Centipawns: -200}, Loss: 10, Grade: Good},
+	}
+
+	report := NewReport()
+	report.Record("Alice", "Bob", moves)
+	report.Record("Bob", "Carol", moves[:2])
+
+	inputs := []string{"Alice", "Bob", "Carol", "Dave"}
+
+	expectedOutputs := []struct {
+		Games, Moves int
+		ACPL         float64
+		Accuracy     string
+		Grades       [Blunder + 1]int
+	}{
+		{1, 2, 150, "64.6", [Blunder + 1]int{1, 0, 0, 0, 1}},
+		{2, 3, 20, "92.7", [Blunder + 1]int{1, 1, 1, 0, 0}},
+		{1, 1, 50, "81.5", [Blunder + 1]int{0, 0, 1, 0, 0}},
+		{0, 0, 0, "0.0", [Blunder + 1]int{}},
+	}
+
+	for i, input := range inputs {
+		stats := report.Player(input)
+		expected := expectedOutputs[i]
+		if stats.Games != expected.Games || stats.Moves != expected.Moves || stats.ACPL() != expected.ACPL ||
+			fmt.Sprintf("%.1f", stats.Accuracy()) != expected.Accuracy || stats.Grades != expected.Grades {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %.0f %.1f\n\texpected:%+v", input, stats, stats.ACPL(), stats.Accuracy(), expected)
+		}
+	}
+
+	var table strings.Builder
+	report.WriteTable(&table)
+	expectedTable := "#  Name   Games  Moves  Accuracy  ACPL  Best  Good  Inaccuracies  Mistakes  Blunders\n" +
+		"1  Bob    2      3      92.7      20    1     1     1             0         0\n" +
+		"2  Carol  1      1      81.5      50    0     0     1             0         0\n" +
+		"3  Alice  1      2      64.6      150   1     0     0             0         1\n"
+	if table.String() != expectedTable {
+		t.Errorf("FAILED\n\tgot:\n%s\n\texpected:\n%s", table.String(), expectedTable)
+	}
+}
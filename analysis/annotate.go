@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"chess-go/pgn"
+)
+
+// evalCommand matches the eval command of a comment, "[%eval 0.35]"
+var evalCommand = regexp.MustCompile(`\s*\[%eval [^\]]*\]\s*`)
+
+// Annotate writes the analysis of a game into its PGN moves: the evaluation
+// after each move in an eval command, and the NAG of the inaccuracies,
+// mistakes and blunders with the best move in their comment. Previous eval
+// commands are replaced, other comments are kept.
+func Annotate(game *pgn.Game, moves []Move) error {
+	if len(moves) != len(game.Moves) {
+		return fmt.Errorf("analysis: %d moves analyzed for a game of %d moves", len(moves), len(game.Moves))
+	}
+
+	for i, move := range moves {
+		annotated := &game.Moves[i]
+		comment := strings.TrimSpace(evalCommand.ReplaceAllString(annotated.Comment, " "))
+
+		var words []string
+		// A checkmate has nothing left to evaluate
+		if !strings.HasSuffix(move.SAN, "#") {
+			words = append(words, "[%eval "+move.After.String()+"]")
+		}
+		if nag := move.Grade.NAG(); nag != 0 {
+			if !hasNAG(annotated.NAGs, nag) {
+				annotated.NAGs = append(annotated.NAGs, nag)
+			}
+			if move.Best != "" {
+				words = append(words, fmt.Sprintf("%s. %s was best.", move.Grade, move.Best))
+			}
+		}
+		if comment != "" {
+			words = append(words, comment)
+		}
+		annotated.Comment = strings.Join(words, " ")
+	}
+	return nil
+}
+
+// AnnotateGame analyzes a game of a PGN file and annotates it
+func AnnotateGame(ctx context.Context, game *pgn.Game, options Options, report func(Move)) ([]Move, error) {
+	chess, err := game.Replay()
+	if err != nil {
+		return nil, err
+	}
+	moves, err := Analyze(ctx, chess, options, report)
+	if err != nil {
+		return nil, err
+	}
+	return moves, Annotate(game, moves)
+}
+
+// hasNAG reports whether a NAG is in a list
+func hasNAG(nags []int, nag int) bool {
+	for _, n := range nags {
+		if n == nag {
+			return true
+		}
+	}
+	return false
+}
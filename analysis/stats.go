@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// Stats are the statistics of the analyzed moves of a player
+type Stats struct {
+	Name  string
+	Games int
+	Moves int
+	// Loss is the sum of the capped centipawn losses of the moves
+	Loss int
+	// TotalAccuracy is the sum of the accuracies of the moves
+	TotalAccuracy float64
+	// Grades counts the moves of each grade
+	Grades [Blunder + 1]int
+}
+
+// ACPL returns the average centipawn loss of the moves
+func (s Stats) ACPL() float64 {
+	if s.Moves == 0 {
+		return 0
+	}
+	return float64(s.Loss) / float64(s.Moves)
+}
+
+// Accuracy returns the average accuracy of the moves from 0 to 100
+func (s Stats) Accuracy() float64 {
+	if s.Moves == 0 {
+		return 0
+	}
+	return s.TotalAccuracy / float64(s.Moves)
+}
+
+// Add counts a move of the player
+func (s *Stats) Add(move Move) {
+	s.Moves++
+	s.Loss += move.Loss
+	s.TotalAccuracy += MoveAccuracy(move.Before.For(move.Color), move.After.For(move.Color))
+	if move.Grade >= Best && move.Grade <= Blunder {
+		s.Grades[move.Grade]++
+	}
+}
+
+// Merge adds the statistics of other games of the player
+func (s *Stats) Merge(other Stats) {
+	s.Games += other.Games
+	s.Moves += other.Moves
+	s.Loss += other.Loss
+	s.TotalAccuracy += other.TotalAccuracy
+	for grade, count := range other.Grades {
+		s.Grades[grade] += count
+	}
+}
+
+// MoveAccuracy returns the accuracy of a move from 0 to 100, by the loss of
+// chance to win between the evaluations before and after it, from the point
+// of view of the player of the move. A move that loses nothing is 100.
+func MoveAccuracy(before, after Eval) float64 {
+	loss := 100 * (before.WinChance() - after.WinChance())
+	if loss <= 0 {
+		return 100
+	}
+	accuracy := 103.1668*math.Exp(-0.04354*loss) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+// GameStats returns the statistics of the players of an analyzed game
+func GameStats(moves []Move) (white, black Stats) {
+	white.Games, black.Games = 1, 1
+	for _, move := range moves {
+		if move.Color == engine.White {
+			white.Add(move)
+		} else {
+			black.Add(move)
+		}
+	}
+	return white, black
+}
+
+// Report gathers the statistics of players over many analyzed games
+type Report struct {
+	players map[string]*Stats
+}
+
+// NewReport returns a report without games
+func NewReport() *Report {
+	return &Report{players: map[string]*Stats{}}
+}
+
+// Record adds an analyzed game between two players
+func (r *Report) Record(white, black string, moves []Move) {
+	whiteStats, blackStats := GameStats(moves)
+	r.player(white).Merge(whiteStats)
+	r.player(black).Merge(blackStats)
+}
+
+// RecordGame adds an analyzed PGN game, its players are named by its White
+// and Black tags
+func (r *Report) RecordGame(game *pgn.Game, moves []Move) {
+	r.Record(game.Tag("White"), game.Tag("Black"), moves)
+}
+
+// player returns the statistics of a player, adding them when they are new
+func (r *Report) player(name string) *Stats {
+	stats, ok := r.players[name]
+	if !ok {
+		stats = &Stats{Name: name}
+		r.players[name] = stats
+	}
+	return stats
+}
+
+// Player returns the statistics of a player by name
+func (r *Report) Player(name string) Stats {
+	if stats, ok := r.players[name]; ok {
+		return *stats
+	}
+	return Stats{Name: name}
+}
+
+// Players returns the statistics of the players by accuracy, best first
+func (r *Report) Players() []Stats {
+	var players []Stats
+	for _, stats := range r.players {
+		players = append(players, *stats)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Accuracy() != players[j].Accuracy() {
+			return players[i].Accuracy() > players[j].Accuracy()
+		}
+		return players[i].Name < players[j].Name
+	})
+	return players
+}
+
+// WriteTable writes the statistics of the players as a table
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tName\tGames\tMoves\tAccuracy\tACPL\tBest\tGood\tInaccuracies\tMistakes\tBlunders")
+
+	for i, player := range r.Players() {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%.1f\t%.0f\t%d\t%d\t%d\t%d\t%d\n", i+1, player.Name,
+			player.Games, player.Moves, player.Accuracy(), player.ACPL(),
+			player.Grades[Best], player.Grades[Good], player.Grades[Inaccuracy], player.Grades[Mistake], player.Grades[Blunder])
+	}
+
+	return tw.Flush()
+}
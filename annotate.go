@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"chess-go/analysis"
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// runAnnotate analyzes the games of PGN databases, writes them annotated to
+// the standard output and returns the exit code
+func runAnnotate(args []string) int {
+	flags := flag.NewFlagSet("annotate", flag.ExitOnError)
+	analysisOptions := analysisFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go annotate [flags] GAMES.pgn... > ANNOTATED.pgn")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	options, err := analysisOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		games, err := pgn.ReadAll(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			return 1
+		}
+
+		for i, game := range games {
+			// Games that do not replay are written as they were
+			if _, err := analysis.AnnotateGame(context.Background(), game, options, nil); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+			}
+			if _, err := game.WriteTo(out); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			// Games come out one by one as they are analyzed
+			out.Flush()
+		}
+	}
+	return 0
+}
+
+// analysisFlags adds the flags of the search and the grades of an analysis
+// to a flag set, the options are returned once it is parsed. The thresholds
+// that are not set are the defaults of the loss graded.
+func analysisFlags(flags *flag.FlagSet) func() (analysis.Options, error) {
+	depth := flags.Int("depth", 0, "depth each position is searched to")
+	nodes := flags.Int("nodes", 0, "nodes searched in each position")
+	moveTime := flags.Duration("movetime", 0, "time each position is searched")
+	inaccuracy := flags.Float64("inaccuracy", 0.05, "loss of chance to win of an inaccuracy, or of centipawns with -centipawns where it defaults to 50")
+	mistake := flags.Float64("mistake", 0.10, "loss of chance to win of a mistake, or of centipawns with -centipawns where it defaults to 100")
+	blunder := flags.Float64("blunder", 0.15, "loss of chance to win of a blunder, or of centipawns with -centipawns where it defaults to 300")
+	centipawns := flags.Bool("centipawns", false, "grade the moves by the loss of centipawns instead of the loss of chance to win")
+
+	return func() (analysis.Options, error) {
+		thresholds := map[string]*float64{"inaccuracy": inaccuracy, "mistake": mistake, "blunder": blunder}
+		if *centipawns {
+			set := map[string]bool{}
+			flags.Visit(func(f *flag.Flag) {
+				set[f.Name] = true
+			})
+			for name, value := range map[string]float64{"inaccuracy": 50, "mistake": 100, "blunder": 300} {
+				if !set[name] {
+					*thresholds[name] = value
+				}
+			}
+		}
+
+		grader, err := analysis.NewGrader(*centipawns, *inaccuracy, *mistake, *blunder)
+		if err != nil {
+			return analysis.Options{}, err
+		}
+		return analysis.Options{
+			Limits: engine.SearchLimits{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime},
+			Grader: grader,
+		}, nil
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"chess-go/analysis"
+)
+
+func TestMain_AnalysisFlags(t *testing.T) {
+	inputs := [][]string{
+		{},
+		{"-centipawns"},
+		{"-centipawns", "-mistake", "150"},
+		{"-centipawns", "-inaccuracy", "0"},
+		{"-centipawns", "-mistake", "400"},
+		{"-mistake", "0.2", "-blunder", "0.1"},
+	}
+
+	// The grades of a loss of 110 centipawns from an even position
+	expectedOutputs := []string{"Mistake", "Mistake", "Inaccuracy", "error", "error", "error"}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		analysisOptions := analysisFlags(flags)
+		if err := flags.Parse(input); err != nil {
+			t.Fatalf("FAILED: %v\n\t%s", input, err.Error())
+		}
+
+		output := "error"
+		options, err := analysisOptions()
+		if err == nil {
+			output = options.Grader(analysis.Eval{Centipawns: 20}, analysis.Eval{Centipawns: -90}).String()
+		}
+		if output != expected {
+			t.Errorf("FAILED: %v\n\tgot:     %s %v\n\texpected:%s", input, output, err, expected)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package clock is a chess clock for the time controls of over-the-board
+// play: sudden death, Fischer increments, Bronstein and simple delays, and
+// controls of several stages
+package clock
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+var (
+	// ErrFlagFell is returned when pressing the clock after the time of the
+	// side to move ran out
+	ErrFlagFell = errors.New("clock: flag fell")
+	// ErrNotRunning is returned when pressing a clock that is not started or
+	// is paused
+	ErrNotRunning = errors.New("clock: not running")
+)
+
+// Source tells the time, it is replaced in tests to control the clock
+type Source interface {
+	Now() time.Time
+}
+
+// systemSource is the time of the system
+type systemSource struct{}
+
+func (systemSource) Now() time.Time {
+	return time.Now()
+}
+
+// System is the time of the system
+var System Source = systemSource{}
+
+// Manual is a time source that only moves when told to
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual returns a manual time source set to a time
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time of the source
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.now
+}
+
+// Advance moves the time of the source forward
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+}
+
+// side is the clock of a player
+type side struct {
+	remaining time.Duration
+	moves     int
+	// stage is the index of the stage in the control, and stageMoves the
+	// moves played in it
+	stage      int
+	stageMoves int
+}
+
+// Clock is the clock of the two players of a game
+type Clock struct {
+	mu      sync.Mutex
+	control Control
+	source  Source
+	sides   map[engine.Color]*side
+
+	turn    engine.Color
+	running bool
+	// since is when the clock of the side to move last started, used is the
+	// time it ran on the current move before it was paused
+	since time.Time
+	used  time.Duration
+	// flagged is the first color whose time ran out
+	flagged engine.Color
+}
+
+// New returns a stopped clock with the time of the first stage of a control
+// for each player, source is System when nil
+func New(control Control, source Source) *Clock {
+	if source == nil {
+		source = System
+	}
+
+	c := &Clock{
+		control: control,
+		source:  source,
+		sides:   map[engine.Color]*side{},
+		turn:    engine.White,
+		flagged: engine.NoColor,
+	}
+	for _, color := range []engine.Color{engine.White, engine.Black} {
+		c.sides[color] = &side{}
+		if len(control) > 0 {
+			c.sides[color].remaining = control[0].Time
+		}
+	}
+	return c
+}
+
+// Start starts the clock of the side to move
+func (c *Clock) Start(turn engine.Color) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.turn = turn
+	c.running = true
+	c.since = c.source.Now()
+	c.used = 0
+}
+
+// Restore sets the time left to a player and the number of moves they
+// completed, to go on with a game whose clock was lost
+func (c *Clock) Restore(color engine.Color, remaining time.Duration, moves int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.sides[color]
+	s.remaining = remaining
+	s.moves = moves
+	s.stage, s.stageMoves = 0, 0
+	for i := 0; i < moves; i++ {
+		s.stageMoves++
+		if stage := c.stage(s); stage.Moves > 0 && s.stageMoves == stage.Moves {
+			if s.stage < len(c.control)-1 {
+				s.stage++
+			}
+			s.stageMoves = 0
+		}
+	}
+}
+
+// Press ends the move of the side to move and starts the clock of the other
+// side. The time given back by the stage is added, and the time of the next
+// stage once its moves are played.
+func (c *Clock) Press() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return ErrNotRunning
+	}
+	if c.checkFlag() {
+		return ErrFlagFell
+	}
+
+	now := c.source.Now()
+	elapsed := c.used + now.Sub(c.since)
+	s := c.sides[c.turn]
+	stage := c.stage(s)
+
+	s.remaining -= charged(stage, elapsed)
+	switch {
+	case stage.Mode == Fischer:
+		s.remaining += stage.Increment
+	case stage.Mode == Bronstein && elapsed < stage.Increment:
+		s.remaining += elapsed
+	case stage.Mode == Bronstein:
+		s.remaining += stage.Increment
+	}
+
+	s.moves++
+	s.stageMoves++
+	if stage.Moves > 0 && s.stageMoves == stage.Moves {
+		if s.stage < len(c.control)-1 {
+			s.stage++
+		}
+		s.stageMoves = 0
+		s.remaining += c.stage(s).Time
+	}
+
+	c.turn = c.turn.Other()
+	c.since = now
+	c.used = 0
+	return nil
+}
+
+// Pause stops the clock of the side to move until Resume
+func (c *Clock) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+	c.checkFlag()
+	c.used += c.source.Now().Sub(c.since)
+	c.running = false
+}
+
+// Resume starts again the clock of the side to move after Pause
+func (c *Clock) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return
+	}
+	c.running = true
+	c.since = c.source.Now()
+}
+
+// Running reports whether the clock of the side to move is running
+func (c *Clock) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.running
+}
+
+// Turn returns the color whose clock runs or would run
+func (c *Clock) Turn() engine.Color {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.turn
+}
+
+// Remaining returns the time left to a player, without the delay of the
+// current move, and zero once their flag fell
+func (c *Clock) Remaining(color engine.Color) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.sides[color]
+	remaining := s.remaining
+	if color == c.turn {
+		remaining -= charged(c.stage(s), c.elapsed())
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Delay returns the delay left on the current move of a simple delay stage,
+// during which the clock of the side to move does not count down
+func (c *Clock) Delay() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stage := c.stage(c.sides[c.turn])
+	if stage.Mode != SimpleDelay || c.elapsed() >= stage.Increment {
+		return 0
+	}
+	return stage.Increment - c.elapsed()
+}
+
+// Moves returns the number of moves a player completed on the clock
+func (c *Clock) Moves(color engine.Color) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sides[color].moves
+}
+
+// Stage returns the stage of the time control a player is in
+func (c *Clock) Stage(color engine.Color) Stage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stage(c.sides[color])
+}
+
+// Flagged returns the color whose time ran out, NoColor while both have time
+func (c *Clock) Flagged() engine.Color {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkFlag()
+	return c.flagged
+}
+
+// Check ends a game in progress on time when the flag of a player fell, and
+// reports whether it did. The game is drawn when the opponent can not
+// checkmate.
+func (c *Clock) Check(chess *engine.Chess) bool {
+	flagged := c.Flagged()
+	if flagged == engine.NoColor || chess.IsGameOver() {
+		return false
+	}
+	return chess.Timeout(flagged) == nil
+}
+
+// checkFlag records the flag of the side to move if their time ran out and
+// reports whether a flag fell
+func (c *Clock) checkFlag() bool {
+	if c.flagged != engine.NoColor {
+		return true
+	}
+
+	s := c.sides[c.turn]
+	if s.remaining-charged(c.stage(s), c.elapsed()) <= 0 && len(c.control) > 0 {
+		c.flagged = c.turn
+		return true
+	}
+	return false
+}
+
+// elapsed returns the time the side to move used on the current move
+func (c *Clock) elapsed() time.Duration {
+	if !c.running {
+		return c.used
+	}
+	return c.used + c.source.Now().Sub(c.since)
+}
+
+// charged returns the time taken from the clock for a move, the time after
+// the delay for a simple delay
+func charged(stage Stage, elapsed time.Duration) time.Duration {
+	if stage.Mode == SimpleDelay {
+		if elapsed < stage.Increment {
+			return 0
+		}
+		return elapsed - stage.Increment
+	}
+	return elapsed
+}
+
+// stage returns the stage of the time control of a player
+func (c *Clock) stage(s *side) Stage {
+	if len(c.control) == 0 {
+		return Stage{}
+	}
+	return c.control[s.stage]
+}
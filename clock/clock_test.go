@@ -0,0 +1,161 @@
+package clock
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+)
+
+func TestClock_ParseControl(t *testing.T) {
+	inputs := []string{"5", "3+2", "25d5", "15b10", "40/90+30,30+30", "40/120", "0:30+0.5", "40/90,30", "x", "40/90,30,30", "0"}
+
+	expectedOutputs := []Control{
+		{{Time: 5 * time.Minute}},
+		{{Time: 3 * time.Minute, Increment: 2 * time.Second}},
+		{{Time: 25 * time.Minute, Mode: SimpleDelay, Increment: 5 * time.Second}},
+		{{Time: 15 * time.Minute, Mode: Bronstein, Increment: 10 * time.Second}},
+		{{Moves: 40, Time: 90 * time.Minute, Increment: 30 * time.Second}, {Time: 30 * time.Minute, Increment: 30 * time.Second}},
+		{{Moves: 40, Time: 120 * time.Minute}},
+		{{Time: 30 * time.Second, Increment: 500 * time.Millisecond}},
+		{{Moves: 40, Time: 90 * time.Minute}, {Time: 30 * time.Minute}},
+		nil,
+		nil,
+		nil,
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output, err := ParseControl(input)
+		if (err != nil) != (expected == nil) || !reflect.DeepEqual(output, expected) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %v\n\texpected:%+v", input, output, err, expected)
+		}
+		if expected != nil && output.String() != input {
+			t.Errorf("FAILED: %s\n\tgot:     %s\n\texpected:%s", input, output.String(), input)
+		}
+	}
+
+	control, _ := ParseControl("40/90+30,30+30")
+	if output, expected := control.PGN(), "40/5400+30:1800+30"; output != expected {
+		t.Errorf("FAILED\n\tgot:     %s\n\texpected:%s", output, expected)
+	}
+}
+
+// play presses the clock after each time spent on a move, alternately by
+// white and black, and returns the remaining time of both players
+func play(t *testing.T, control string, moves []time.Duration) [2]time.Duration {
+	parsed, err := ParseControl(control)
+	if err != nil {
+		t.Fatalf("FAILED: %s\n\t%s", control, err.Error())
+	}
+
+	source := NewManual(time.Unix(0, 0))
+	clock := New(parsed, source)
+	clock.Start(engine.White)
+	for _, move := range moves {
+		source.Advance(move)
+		if err := clock.Press(); err != nil {
+			t.Fatalf("FAILED: %s %v\n\t%s", control, moves, err.Error())
+		}
+	}
+
+	return [2]time.Duration{clock.Remaining(engine.White), clock.Remaining(engine.Black)}
+}
+func TestClock_Modes(t *testing.T) {
+	s := time.Second
+	inputs := []struct {
+		control string
+		moves   []time.Duration
+	}{
+		{"5", []time.Duration{10 * s, 20 * s, 30 * s}},
+		{"3+2", []time.Duration{10 * s, 20 * s, 30 * s}},
+		{"3b5", []time.Duration{3 * s, 20 * s, 30 * s}},
+		{"3d5", []time.Duration{3 * s, 20 * s, 30 * s}},
+		{"2/1,1+10", []time.Duration{10 * s, 10 * s, 10 * s, 10 * s, 10 * s}},
+		{"1/1", []time.Duration{30 * s, 30 * s, 30 * s}},
+	}
+
+	expectedOutputs := [][2]time.Duration{
+		{260 * s, 280 * s},
+		{144 * s, 162 * s},
+		{155 * s, 165 * s},
+		{155 * s, 165 * s},
+		{100 * s, 100 * s},
+		{120 * s, 90 * s},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := play(t, input.control, input.moves)
+		if output != expected {
+			t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%v", input, output, expected)
+		}
+	}
+}
+func TestClock_Restore(t *testing.T) {
+	control, _ := ParseControl("2/1,1+10")
+	source := NewManual(time.Unix(0, 0))
+	clock := New(control, source)
+	clock.Restore(engine.White, 45*time.Second, 2)
+	clock.Restore(engine.Black, 30*time.Second, 1)
+
+	clock.Start(engine.Black)
+	source.Advance(10 * time.Second)
+	clock.Press()
+
+	expected := [2]time.Duration{45 * time.Second, 80 * time.Second}
+	output := [2]time.Duration{clock.Remaining(engine.White), clock.Remaining(engine.Black)}
+	if output != expected || clock.Stage(engine.White).Increment != 10*time.Second {
+		t.Errorf("FAILED\n\tgot:     %v %+v\n\texpected:%v", output, clock.Stage(engine.White), expected)
+	}
+}
+func TestClock_Flag(t *testing.T) {
+	control, _ := ParseControl("1d5")
+	source := NewManual(time.Unix(0, 0))
+	clock := New(control, source)
+
+	if err := clock.Press(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", err, ErrNotRunning)
+	}
+
+	clock.Start(engine.White)
+	source.Advance(3 * time.Second)
+	if output := clock.Delay(); output != 2*time.Second {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", output, 2*time.Second)
+	}
+
+	// A paused clock does not run
+	clock.Pause()
+	source.Advance(time.Hour)
+	clock.Resume()
+	source.Advance(time.Minute)
+	if output := clock.Remaining(engine.White); output != 2*time.Second {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", output, 2*time.Second)
+	}
+	if output := clock.Flagged(); output != engine.NoColor {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", output, engine.NoColor)
+	}
+
+	source.Advance(2 * time.Second)
+	if output := clock.Flagged(); output != engine.White {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", output, engine.White)
+	}
+	if err := clock.Press(); !errors.Is(err, ErrFlagFell) {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", err, ErrFlagFell)
+	}
+
+	inputs := []string{
+		"4k3/8/8/8/8/8/8/4K2R w K - 0 1",
+		"4k2r/8/8/8/8/8/8/4K3 w k - 0 1",
+	}
+	expectedOutputs := []engine.Method{engine.TimeoutVsInsufficientMaterial, engine.Timeout}
+
+	for i, input := range inputs {
+		chess, _ := engine.NewChessGameWithFen(input)
+		if !clock.Check(chess) || chess.Method() != expectedOutputs[i] {
+			t.Errorf("FAILED: %s\n\tgot:     %s %s\n\texpected:%s", input, chess.Outcome(), chess.Method(), expectedOutputs[i])
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is how a stage gives time back for each move
+type Mode uint8
+
+const (
+	// Fischer adds the increment after each move
+	Fischer Mode = iota
+	// Bronstein gives back the time used on each move, up to the delay
+	Bronstein
+	// SimpleDelay starts counting down each move after the delay, the US
+	// delay
+	SimpleDelay
+)
+
+// String returns the symbol of the mode in time controls
+func (m Mode) String() string {
+	switch m {
+	case Bronstein:
+		return "b"
+	case SimpleDelay:
+		return "d"
+	}
+	return "+"
+}
+
+// Stage is a period of a time control
+type Stage struct {
+	// Moves is the number of moves of the stage, 0 for the rest of the game
+	Moves int
+	// Time is given when the stage starts
+	Time time.Duration
+	Mode Mode
+	// Increment is the increment of Fischer or the delay of the other modes,
+	// sudden death when zero
+	Increment time.Duration
+}
+
+// String returns the stage as "moves/minutes+seconds"
+func (s Stage) String() string {
+	str := formatMinutes(s.Time)
+	if s.Moves > 0 {
+		str = strconv.Itoa(s.Moves) + "/" + str
+	}
+	if s.Increment > 0 {
+		str += s.Mode.String() + strconv.FormatFloat(s.Increment.Seconds(), 'f', -1, 64)
+	}
+	return str
+}
+
+// Control is a time control made of stages. When the last stage has a number
+// of moves it repeats.
+type Control []Stage
+
+// ParseControl parses a time control of stages separated by commas. A stage
+// is "[moves/]minutes[+seconds]", where the minutes may be "minutes:seconds"
+// and the increment may be "dseconds" for a simple delay or "bseconds" for a
+// Bronstein delay: "5+3", "25d5", "40/90+30,30+30".
+func ParseControl(s string) (Control, error) {
+	var control Control
+	invalid := fmt.Errorf("clock: invalid time control %q", s)
+
+	stages := strings.Split(s, ",")
+	for i, text := range stages {
+		var stage Stage
+		text = strings.TrimSpace(text)
+
+		if moves, rest, ok := strings.Cut(text, "/"); ok {
+			n, err := strconv.Atoi(moves)
+			if err != nil || n <= 0 {
+				return nil, invalid
+			}
+			stage.Moves = n
+			text = rest
+		}
+
+		// The increment starts at the symbol of its mode
+		base := text
+		if j := strings.IndexAny(text, "+db"); j >= 0 {
+			base = text[:j]
+			switch text[j] {
+			case 'b':
+				stage.Mode = Bronstein
+			case 'd':
+				stage.Mode = SimpleDelay
+			}
+			increment, err := strconv.ParseFloat(text[j+1:], 64)
+			if err != nil || increment < 0 {
+				return nil, invalid
+			}
+			stage.Increment = time.Duration(increment * float64(time.Second))
+		}
+
+		var err error
+		if stage.Time, err = parseMinutes(base); err != nil {
+			return nil, invalid
+		}
+		if stage.Time <= 0 && i == 0 {
+			return nil, invalid
+		}
+		// Only the last stage may last for the rest of the game
+		if stage.Moves == 0 && i < len(stages)-1 {
+			return nil, invalid
+		}
+
+		control = append(control, stage)
+	}
+
+	return control, nil
+}
+
+// String returns the time control as parsed by ParseControl
+func (c Control) String() string {
+	var stages []string
+	for _, stage := range c {
+		stages = append(stages, stage.String())
+	}
+	return strings.Join(stages, ",")
+}
+
+// PGN returns the time control as the value of a PGN TimeControl tag, in
+// seconds with the stages separated by colons. Delays can not be written in
+// PGN and are left out.
+func (c Control) PGN() string {
+	if len(c) == 0 {
+		return "-"
+	}
+
+	var stages []string
+	for _, stage := range c {
+		str := strconv.FormatFloat(stage.Time.Seconds(), 'f', -1, 64)
+		if stage.Moves > 0 {
+			str = strconv.Itoa(stage.Moves) + "/" + str
+		}
+		if stage.Mode == Fischer && stage.Increment > 0 {
+			str += "+" + strconv.FormatFloat(stage.Increment.Seconds(), 'f', -1, 64)
+		}
+		stages = append(stages, str)
+	}
+	return strings.Join(stages, ":")
+}
+
+// parseMinutes parses "minutes" or "minutes:seconds", the minutes may have a
+// fraction
+func parseMinutes(s string) (time.Duration, error) {
+	minutes, seconds, ok := strings.Cut(s, ":")
+	m, err := strconv.ParseFloat(minutes, 64)
+	if err != nil || m < 0 {
+		return 0, fmt.Errorf("clock: invalid minutes %q", s)
+	}
+	d := time.Duration(m * float64(time.Minute))
+
+	if ok {
+		sec, err := strconv.Atoi(seconds)
+		if err != nil || sec < 0 || sec >= 60 {
+			return 0, fmt.Errorf("clock: invalid seconds %q", s)
+		}
+		d += time.Duration(sec) * time.Second
+	}
+	return d, nil
+}
+
+// formatMinutes writes a time as "minutes" or "minutes:seconds"
+func formatMinutes(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	seconds := int((d % time.Minute) / time.Second)
+	if seconds == 0 {
+		return strconv.Itoa(minutes)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"chess-go/eco"
+	"chess-go/pgn"
+)
+
+// runECO tags the games of PGN databases with their openings, writes them
+// to the standard output and returns the exit code
+func runECO(args []string) int {
+	flags := flag.NewFlagSet("eco", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go eco GAMES.pgn... > TAGGED.pgn")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		games, err := pgn.ReadAll(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			return 1
+		}
+
+		for i, game := range games {
+			// Games that do not replay are written untagged
+			if _, _, err := eco.Tag(game); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+			}
+			if _, err := game.WriteTo(out); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
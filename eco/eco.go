@@ -0,0 +1,168 @@
+// Package eco classifies the openings of games with the codes of the
+// Encyclopaedia of Chess Openings. Games are matched by position, so that
+// transpositions find their opening whatever the order of their moves.
+package eco
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// tableText is the table of openings: a header line, then the ECO code, the
+// name and the moves of each opening separated by tabs
+//
+//go:embed openings.tsv
+var tableText string
+
+// Opening is an opening of the table
+type Opening struct {
+	// ECO is the code of the opening, from A00 to E99
+	ECO string
+	// Name is the name of the opening, "Sicilian Defense"
+	Name string
+	// Variation is the variation of the opening, "Najdorf Variation,
+	// English Attack", or "" for the opening itself
+	Variation string
+	// Moves are the moves of the opening in SAN
+	Moves []string
+}
+
+// String returns the full name of the opening, with its variation after a
+// colon
+func (o Opening) String() string {
+	if o.Variation == "" {
+		return o.Name
+	}
+	return o.Name + ": " + o.Variation
+}
+
+var (
+	loadOnce sync.Once
+	// positions are the openings by position key, the first opening of the
+	// table wins when two lines reach the same position
+	positions map[string]Opening
+)
+
+// table returns the openings by position key, loading them the first time
+func table() map[string]Opening {
+	loadOnce.Do(func() {
+		var err error
+		if positions, err = load(tableText); err != nil {
+			panic(err)
+		}
+	})
+	return positions
+}
+
+// load plays the moves of each opening of a table and indexes the openings
+// by the position they reach
+func load(text string) (map[string]Opening, error) {
+	openings := map[string]Opening{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 0; scanner.Scan(); line++ {
+		if line == 0 || scanner.Text() == "" {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("eco: line %d: expected 3 fields, got %d", line+1, len(fields))
+		}
+
+		opening := Opening{ECO: fields[0]}
+		opening.Name, opening.Variation, _ = strings.Cut(fields[1], ": ")
+
+		chess := engine.NewGameChess()
+		for _, token := range strings.Fields(fields[2]) {
+			// Move numbers
+			if strings.HasSuffix(token, ".") {
+				continue
+			}
+			played, err := chess.MovePGN(token)
+			if err != nil {
+				return nil, fmt.Errorf("eco: line %d: %w", line+1, err)
+			}
+			opening.Moves = append(opening.Moves, played.SAN)
+		}
+
+		key := positionKey(chess)
+		if _, ok := openings[key]; !ok {
+			openings[key] = opening
+		}
+	}
+	return openings, scanner.Err()
+}
+
+// positionKey returns the placement, the side to move and the castling
+// rights of a position. The en passant square is left out: it is set after
+// every double pawn push, so transpositions would not meet otherwise.
+func positionKey(chess *engine.Chess) string {
+	fields := strings.Fields(chess.GetFEN())
+	return strings.Join(fields[:3], " ")
+}
+
+// Lookup returns the opening of the current position of a game, if the table
+// has it
+func Lookup(chess *engine.Chess) (Opening, bool) {
+	opening, ok := table()[positionKey(chess)]
+	return opening, ok
+}
+
+// Classify returns the opening of a game: the opening of the last position
+// of the game found in the table
+func Classify(chess *engine.Chess) (Opening, bool) {
+	position, err := engine.NewChessGameWithFen(chess.StartingFEN())
+	if err != nil {
+		return Opening{}, false
+	}
+
+	openings := table()
+	opening, found := openings[positionKey(position)]
+	for _, move := range chess.History() {
+		if _, err := position.Move(move); err != nil {
+			break
+		}
+		if o, ok := openings[positionKey(position)]; ok {
+			opening, found = o, true
+		}
+	}
+	return opening, found
+}
+
+// Tag classifies a game of a PGN file and sets its ECO, Opening and
+// Variation tags. The tags are left alone when its opening is not found.
+func Tag(game *pgn.Game) (Opening, bool, error) {
+	chess, err := game.Replay()
+	if err != nil {
+		return Opening{}, false, err
+	}
+
+	opening, ok := Classify(chess)
+	if !ok {
+		return Opening{}, false, nil
+	}
+	game.SetTag("ECO", opening.ECO)
+	game.SetTag("Opening", opening.Name)
+	if opening.Variation != "" {
+		game.SetTag("Variation", opening.Variation)
+	} else {
+		removeTag(game, "Variation")
+	}
+	return opening, true, nil
+}
+
+// removeTag removes a tag from a game
+func removeTag(game *pgn.Game, name string) {
+	tags := game.Tags[:0]
+	for _, tag := range game.Tags {
+		if tag.Name != name {
+			tags = append(tags, tag)
+		}
+	}
+	game.Tags = tags
+}
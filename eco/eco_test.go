@@ -0,0 +1,128 @@
+package eco
+
+import (
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+func TestECO_Table(t *testing.T) {
+	positions, err := load(tableText)
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	// Every line of the table reaches a position of its own
+	lines := strings.Count(strings.TrimSpace(tableText), "\n")
+	if len(positions) != lines {
+		t.Errorf("FAILED: positions\n\tgot:     %d\n\texpected:%d", len(positions), lines)
+	}
+
+	_, err = load("eco\tname\tpgn\nB20\tSicilian Defense\t1. e4 c6 2. Ke3\n")
+	if err == nil || !strings.HasPrefix(err.Error(), "eco: line 2:") {
+		t.Errorf("FAILED: illegal move\n\tgot:     %v\n\texpected:%s", err, "eco: line 2: ...")
+	}
+}
+func TestECO_Classify(t *testing.T) {
+	inputs := []string{
+		"e4 c5 Nf3 d6 d4 cxd4 Nxd4 Nf6 Nc3 a6 Be3 e5 Nb3 Be6 f3 Be7 Qd2",
+		// The London System reached from the Zukertort Opening
+		"Nf3 d5 d4 Nf6 Bf4 e6 e3",
+		// The Queen's Gambit Declined reached from the English Opening
+		"c4 e6 Nc3 d5 d4",
+		"e4 e5 Nf3 Nc6 Bc4 Bc5",
+		"d4 Nf6 c4 g6 Nc3 Bg7 e4 d6 Nf3 O-O Be2 e5 O-O Nc6 d5 Ne7",
+		"h4 h5",
+		"",
+	}
+
+	expectedOutputs := []string{
+		"B90 Sicilian Defense: Najdorf Variation, English Attack",
+		"D02 Queen's Pawn Game: London System",
+		"D31 Queen's Gambit Declined: Queen's Knight Variation",
+		"C50 Italian Game: Giuoco Piano",
+		"E97 King's Indian Defense: Orthodox Variation, Aronin-Taimanov Defense",
+		"A00 Kadas Opening",
+		"not found",
+	}
+
+	for i, input := range inputs {
+		chess := engine.NewGameChess()
+		for _, san := range strings.Fields(input) {
+			if _, err := chess.MovePGN(san); err != nil {
+				t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+			}
+		}
+
+		output := "not found"
+		if opening, ok := Classify(chess); ok {
+			output = opening.ECO + " " + opening.String()
+		}
+		if output != expectedOutputs[i] {
+			t.Errorf("FAILED: %s\n\tgot:     %s\n\texpected:%s", input, output, expectedOutputs[i])
+		}
+	}
+}
+func TestECO_Lookup(t *testing.T) {
+	inputs := []string{
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w Kkq - 0 2",
+		"4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+	}
+
+	expectedOutputs := []string{"B20", "B20", "", ""}
+
+	for i, input := range inputs {
+		chess, err := engine.NewChessGameWithFen(input)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+		opening, _ := Lookup(chess)
+		if opening.ECO != expectedOutputs[i] {
+			t.Errorf("FAILED: %s\n\tgot:     %s\n\texpected:%s", input, opening.ECO, expectedOutputs[i])
+		}
+	}
+}
+func TestECO_Tag(t *testing.T) {
+	games, err := pgn.ReadAll(strings.NewReader(`[Event "?"]
+[Variation "Old"]
+
+1. e4 c5 2. Nf3 Nc6 3. Bb5 g6 *
+
+[Event "?"]
+[Variation "Old"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Bxc6 dxc6 *
+
+[Event "?"]
+
+1. a4 a5 *
+`))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	expectedOutputs := []string{
+		`[ECO "B31"] [Opening "Sicilian Defense"] [Variation "Rossolimo Variation"]`,
+		`[ECO "C68"] [Opening "Ruy Lopez"] [Variation "Exchange Variation"]`,
+		`[ECO "A00"] [Opening "Ware Opening"]`,
+	}
+
+	for i, game := range games {
+		if _, _, err := Tag(game); err != nil {
+			t.Fatalf("FAILED: game %d\n\t%s", i+1, err.Error())
+		}
+		var tags []string
+		for _, name := range []string{"ECO", "Opening", "Variation"} {
+			if value := game.Tag(name); value != "" {
+				tags = append(tags, "["+name+` "`+value+`"]`)
+			}
+		}
+		if output := strings.Join(tags, " "); output != expectedOutputs[i] {
+			t.Errorf("FAILED: game %d\n\tgot:     %s\n\texpected:%s", i+1, output, expectedOutputs[i])
+		}
+	}
+}
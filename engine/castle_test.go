@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCastle_Legality(t *testing.T) {
+	inputs := []struct {
+		fen  string
+		move string
+	}{
+		// Free to castle on both sides
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O"},
+		// The rook may pass through an attacked square
+		{"1r2k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "O-O-O"},
+		// The king is in check
+		{"4k3/8/8/8/8/8/4r3/R3K2R w KQ - 0 1", "O-O"},
+		// The king passes through check
+		{"4k3/8/8/8/8/8/5r2/R3K2R w KQ - 0 1", "O-O"},
+		{"4k3/8/8/8/8/8/3r4/R3K2R w KQ - 0 1", "O-O-O"},
+		// The king lands in check
+		{"4k3/8/8/8/8/8/6r1/R3K2R w KQ - 0 1", "O-O"},
+		{"4k3/8/8/8/8/8/2r5/R3K2R w KQ - 0 1", "O-O-O"},
+		// The squares between king and rook are not empty
+		{"4k3/8/8/8/8/8/8/R3K1NR w KQ - 0 1", "O-O"},
+		{"4k3/8/8/8/8/8/8/RN2K2R w KQ - 0 1", "O-O-O"},
+		{"rn2k3/8/8/8/8/8/8/4K3 b q - 0 1", "O-O-O"},
+		// The rook is not on its corner
+		{"4k3/8/8/8/8/8/P7/4K3 w KQ - 0 1", "O-O"},
+		{"4k3/8/8/8/8/8/P7/4K3 w KQ - 0 1", "O-O-O"},
+		{"4k3/8/8/8/8/8/8/r3K2b w KQ - 0 1", "O-O-O"},
+		// The king is not on its home square
+		{"4k3/8/8/8/8/8/8/R4K1R w KQ - 0 1", "O-O"},
+		// No castle availability
+		{"4k3/8/8/8/8/8/8/R3K2R w Q - 0 1", "O-O"},
+	}
+
+	expectedOutputs := []error{
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+		ErrIllegalMove,
+	}
+
+	for i, input := range inputs {
+		chess, err := NewChessGameWithFen(input.fen)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input.fen, err.Error())
+		}
+		expected := expectedOutputs[i]
+		_, output := chess.MovePGN(input.move)
+
+		if !errors.Is(output, expected) {
+			t.Errorf("FAILED: %s %s\n\tgot:     %+v\n\texpected:%+v", input.fen, input.move, output, expected)
+		}
+	}
+}
+func TestCastle_RookMoves(t *testing.T) {
+	inputs := []string{
+		"O-O",
+		"O-O-O",
+	}
+
+	expectedOutputs := []string{
+		"r3k2r/8/8/8/8/8/8/R4RK1 b kq - 1 1",
+		"r3k2r/8/8/8/8/8/8/2KR3R b kq - 1 1",
+	}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+		expected := expectedOutputs[i]
+
+		result, err := chess.MovePGN(input)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+
+		if !result.Move.IsCastle() || result.SAN != input {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, result, "castle")
+		}
+
+		output := chess.GetFEN()
+		if output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}
+func TestCastle_Availability(t *testing.T) {
+	inputs := []struct {
+		fen   string
+		moves []string
+	}{
+		// Moving the king loses both sides
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"Kd1"}},
+		// Moving a rook loses its side
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"Rb1"}},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"Rg1"}},
+		// Capturing a rook on its home square loses its side
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"Rxa8"}},
+		{"r3k2r/8/8/8/8/8/6B1/R3K2R w KQkq - 0 1", []string{"Bxa8"}},
+		// Rooks away from the corners do not affect castling
+		{"r3k2r/8/8/8/8/R7/8/4K2R w Kkq - 0 1", []string{"Ra4"}},
+		// Castling loses both sides
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", []string{"O-O-O"}},
+	}
+
+	expectedOutputs := []CastleAvailability{
+		{WhiteKing: false, WhiteQueen: false, BlackKing: true, BlackQueen: true},
+		{WhiteKing: true, WhiteQueen: false, BlackKing: true, BlackQueen: true},
+		{WhiteKing: false, WhiteQueen: true, BlackKing: true, BlackQueen: true},
+		{WhiteKing: true, WhiteQueen: false, BlackKing: true, BlackQueen: false},
+		{WhiteKing: true, WhiteQueen: true, BlackKing: true, BlackQueen: false},
+		{WhiteKing: true, WhiteQueen: false, BlackKing: true, BlackQueen: true},
+		{WhiteKing: true, WhiteQueen: true, BlackKing: false, BlackQueen: false},
+	}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen(input.fen)
+		expected := expectedOutputs[i]
+
+		for _, move := range input.moves {
+			if _, err := chess.MovePGN(move); err != nil {
+				t.Fatalf("FAILED: %s %s\n\t%s", input.fen, move, err.Error())
+			}
+		}
+
+		output := chess.Castling()
+		if output != expected {
+			t.Errorf("FAILED: %s %v\n\tgot:     %+v\n\texpected:%+v", input.fen, input.moves, output, expected)
+		}
+	}
+}
+func TestCastle_GetFEN(t *testing.T) {
+	chess, _ := NewChessGameWithFen("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+
+	expected := "4k3/8/8/8/8/8/8/4K3 w - - 0 1"
+	output := chess.GetFEN()
+
+	if output != expected {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+	}
+}
@@ -2,7 +2,6 @@ package engine
 
 import (
 	"fmt"
-	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -14,59 +13,91 @@ const (
 	maxStep    = 7
 )
 
-// TODO: PGN notation tracker
-// TODO: undo and redo
+// TODO: redo
 
 func NewGameChess() *Chess {
 	chess, _ := NewChessGameWithFen(DefaultFen)
 	return chess
 }
 
-func NewChessGameWithFen(fen string) (*Chess, error) {
+// NewChessGameWithFen starts a game from a FEN, the position is only checked
+// for problems that Validate reports in the Strict mode
+func NewChessGameWithFen(fen string, mode ...ValidationMode) (*Chess, error) {
 	var chess Chess
 	err := chess.decodeFen(fen)
 	if err != nil {
 		return nil, err
 	}
+	if len(mode) > 0 && mode[0] == Strict {
+		if err := chess.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	chess.updateOutcome()
 	return &chess, nil
 }
 
-// MovePGN TODO: fix logic if two possible
-// MovePGN TODO: fix the filter of inputted PGN (kinda done)
-// MovePGN moves a piece like Move with Portable Game Notation (PGN)
-func (c *Chess) MovePGN(pgn string) (int, error) {
+// MovePGN moves a piece like Move with the Standard Algebraic Notation of a
+// Portable Game Notation (PGN) move
+func (c *Chess) MovePGN(pgn string) (*MoveResult, error) {
+	input := pgn
+	if c.IsGameOver() {
+		return nil, &MoveError{Move: input, Err: ErrGameOver, err: "the game is over"}
+	}
+
+	// Remove check, mate and annotation suffixes
+	pgn = strings.TrimRight(pgn, "+#!?")
+
 	// If move is castle
 	kingRow := 8 - determineKingRow(c.turn)
-	kingCoords := fmt.Sprintf("e%d", kingRow)
-	if strings.Contains(pgn, "O-O") {
-		var castleTo string
+	kingCoords := translateCBtoCoords(fmt.Sprintf("e%d", kingRow))
+	if strings.Contains(pgn, "O-O") || strings.Contains(pgn, "0-0") {
+		castleTo := NoSquare
 		// King Side Castle
-		if pgn == "O-O" {
-			castleTo = fmt.Sprintf("g%d", kingRow)
+		if pgn == "O-O" || pgn == "0-0" {
+			castleTo = translateCBtoCoords(fmt.Sprintf("g%d", kingRow))
 
 			// Queen Side Castle
-		} else if pgn == "O-O-O" {
-			castleTo = fmt.Sprintf("c%d", kingRow)
+		} else if pgn == "O-O-O" || pgn == "0-0-0" {
+			castleTo = translateCBtoCoords(fmt.Sprintf("c%d", kingRow))
+		}
+		if castleTo == NoSquare || determinePieceWithCoords(kingCoords, c.boardTable) != determineColorPiece(c.turn, 'k') {
+			return nil, &MoveError{Move: input, Err: ErrIllegalMove, err: "can not castle"}
 		}
-		return c.Move(kingCoords, castleTo)
+		return c.move(kingCoords, castleTo, NoPieceType, input)
+	}
+
+	// Determine the promotion
+	promotion := NoPieceType
+	promotionRE, _ := regexp.Compile("=?[QRBN]$")
+	if promotionRE.MatchString(pgn) {
+		promotion = PieceType(unicode.ToLower(rune(pgn[len(pgn)-1])))
+		pgn = promotionRE.ReplaceAllString(pgn, "")
 	}
 
 	// Compile the regexp
-	toRE, _ := regexp.Compile("[a-h]\\d")
-	pieceRE, _ := regexp.Compile("[RNBQK]")
+	toRE, _ := regexp.Compile("[a-h][1-8]")
+	pieceRE, _ := regexp.Compile("^[RNBQK]")
 	idRE, _ := regexp.Compile("[RNBQK][a-h1-8]|^[a-h]")
 	id2RE, _ := regexp.CompilePOSIX("[a-h1-8]")
 
 	// Find errors
 	if !toRE.MatchString(pgn) {
-		return -1, &MoveError{err: "invalid move coordinate"}
+		return nil, &MoveError{Move: input, Err: ErrIllegalMove, err: "invalid move coordinate"}
 	}
 
-	// Determine to coordinate
-	to := toRE.FindString(pgn)
-	pgn = toRE.ReplaceAllString(pgn, "")
+	// Determine to coordinate, the last square in the move
+	squares := toRE.FindAllString(pgn, -1)
+	to := squares[len(squares)-1]
 	toCoords := translateCBtoCoords(to)
 
+	// A fully disambiguated move names the from square too
+	fromSquare := NoSquare
+	if len(squares) > 1 {
+		fromSquare = translateCBtoCoords(squares[0])
+	}
+	pgn = strings.Replace(pgn, to, "", 1)
+
 	// Determine the piece
 	piece := pieceRE.FindString(pgn)
 
@@ -77,110 +108,172 @@ func (c *Chess) MovePGN(pgn string) (int, error) {
 	// Determine if attack
 	isAttack := strings.Contains(pgn, "x")
 
-	// Determine the fromCoords
-	fromCoords := &Coords{}
+	// Determine the candidate pieces that can move to the square
+	var candidates []Square
 	switch piece {
 	case "R", "N", "B", "Q", "K":
-		possibleMoves := c.calculateMoves(rune(piece[0]), toCoords, clearBoard, maxStep)
-
-		piecePGN := func() bool {
-			for _, move := range possibleMoves {
-				if determinePieceWithCoords(move, c.boardTable) == determineColorPiece(c.turn, rune(piece[0])) {
-					if id != "" {
-						if (unicode.IsDigit(rune(id[0])) && move.row != translateCBtoCoords("a"+id).row) ||
-							(unicode.IsLetter(rune(id[0])) && move.col != translateCBtoCoords(id+"1").col) {
-							continue
-						}
-					}
-					fromCoords = move
-					return true
+		colorPiece := determineColorPiece(c.turn, rune(piece[0]))
+		possibleMoves := c.calculateMoves(colorPiece, toCoords, clearBoard, maxStep)
+
+		for _, move := range possibleMoves {
+			if determinePieceWithCoords(move, c.boardTable) != colorPiece {
+				continue
+			}
+			if fromSquare != NoSquare && move != fromSquare {
+				continue
+			}
+			if fromSquare == NoSquare && id != "" {
+				if (unicode.IsDigit(rune(id[0])) && move.Rank() != int(id[0]-'1')) ||
+					(unicode.IsLetter(rune(id[0])) && move.File() != int(id[0]-'a')) {
+					continue
 				}
 			}
-			return false
-		}
 
-		if !piecePGN() {
-			return -1, &MoveError{err: "Invalid pgn"}
+			validMoves := c.calculateValidMoves(move)
+			if checkIfMovesContains(&validMoves, toCoords) {
+				candidates = append(candidates, move)
+			}
 		}
 
 	case "":
-		var pawnCoords Coords
+		pawnCol := toCoords.col()
 		if isAttack && id != "" {
-			pawnCoords.col = translateCBtoCoords(id + "1").col // TODO: optimize this
-		} else {
-			pawnCoords.col = toCoords.col
+			pawnCol = int(id[0] - 'a')
 		}
 
-		pawnPGN := func() bool {
-			for y, row := range c.boardTable {
-				if row[pawnCoords.col] == determineColorPiece(c.turn, 'p') {
-					pawnCoords.row = y
-					validMoves := c.calculateValidMoves(&pawnCoords)
-					if checkIfMovesContains(&validMoves, toCoords) {
-						fromCoords = &pawnCoords
-						return true
-					}
+		for y, row := range c.boardTable {
+			if row[pawnCol] == determineColorPiece(c.turn, 'p') {
+				pawnCoords := squareAt(y, pawnCol)
+				validMoves := c.calculateValidMoves(pawnCoords)
+				if checkIfMovesContains(&validMoves, toCoords) {
+					candidates = append(candidates, pawnCoords)
 				}
 			}
-			return false
 		}
+	}
 
-		if !pawnPGN() {
-			return -1, &MoveError{err: "Invalid pawn move"}
-		}
+	if len(candidates) == 0 {
+		return nil, &MoveError{Move: input, Err: ErrIllegalMove, err: "no piece can make this move"}
+	}
+	if len(candidates) > 1 {
+		return nil, &MoveError{Move: input, Err: ErrAmbiguousMove, err: "more than one piece can make this move"}
 	}
 
-	return c.move(fromCoords, toCoords)
+	return c.move(candidates[0], toCoords, promotion, input)
 }
 
-// Move moves a piece and returns statusCode (int) and error
-//
-// -1 = error
-//
-// 0 = normal
-//
-// 1 = check
-//
-// 2 = checkmate
-func (c *Chess) Move(from, to string) (int, error) {
-	if from == to {
-		return -1, &MoveError{err: "no move happened"}
+// Move moves a piece and returns the result of the move
+func (c *Chess) Move(m Move) (*MoveResult, error) {
+	move, err := c.checkMove(m)
+	if err != nil {
+		return nil, err
 	}
 
-	fromCoords := translateCBtoCoords(from)
-	toCoords := translateCBtoCoords(to)
+	return c.play(move), nil
+}
+
+// checkMove returns a move of the side to move described from the board, or
+// the error of Move when it is not legal
+func (c *Chess) checkMove(m Move) (Move, error) {
+	input := m.String()
+	if c.IsGameOver() {
+		return Move{}, &MoveError{Move: input, Err: ErrGameOver, err: "the game is over"}
+	}
+
+	if m.From == m.To {
+		return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: "no move happened"}
+	}
 
-	return c.move(fromCoords, toCoords)
+	if checkIfCoordsIsOutOfBounds(m.From) || checkIfCoordsIsOutOfBounds(m.To) {
+		return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: "square is not on the board"}
+	}
+
+	promotion := m.Promotion
+	if promotion == 0 {
+		promotion = NoPieceType
+	}
+
+	return c.legalMove(m.From, m.To, promotion, input)
 }
 
-// PrintBoard TODO: Improve this
-// PrintBoard prints the board
+// MoveUCI moves a piece like Move with a move in UCI notation ("e2e4")
+func (c *Chess) MoveUCI(uci string) (*MoveResult, error) {
+	m, err := ParseMove(uci)
+	if err != nil {
+		return nil, &MoveError{Move: uci, Err: ErrIllegalMove, err: err.Error()}
+	}
+
+	return c.Move(m)
+}
+
+// PrintBoard prints the board to the standard output
+//
+// Deprecated: the renderers of package render draw the board to any writer
 func (c *Chess) PrintBoard() {
 	for i, row := range c.boardTable {
 		for _, content := range row {
-			print(string(content), " ")
+			fmt.Print(string(content), " ")
 		}
-		println(8 - i)
+		fmt.Println(8 - i)
 	}
 
-	println("a b c d e f g h")
+	fmt.Println("a b c d e f g h")
 }
 
-// CalculateValidMoves calculates the valid paths in a given cb notation
-func (c *Chess) CalculateValidMoves(cb string) []string {
-	coords := translateCBtoCoords(cb)
+// CalculateValidMoves calculates the valid destinations of the piece on a square
+func (c *Chess) CalculateValidMoves(square Square) []Square {
+	if checkIfCoordsIsOutOfBounds(square) {
+		return nil
+	}
+
+	return c.calculateValidMoves(square)
+}
+
+// Turn returns the color to move
+func (c *Chess) Turn() Color {
+	return c.turn
+}
 
-	var validMoves []string
+// PieceAt returns the piece on a square, NoPiece if it is empty
+func (c *Chess) PieceAt(square Square) Piece {
+	return determinePieceWithCoords(square, c.boardTable)
+}
 
-	for _, move := range c.calculateValidMoves(coords) {
-		validMoves = append(validMoves, translateCoordsToCB(move))
+// KingSquare returns the square of the king of a color, NoSquare if it has
+// none
+func (c *Chess) KingSquare(color Color) Square {
+	king := NewPiece(color, King)
+	for square := A1; square <= H8; square++ {
+		if c.PieceAt(square) == king {
+			return square
+		}
 	}
+	return NoSquare
+}
 
-	return validMoves
+// Board returns a copy of the board, indexed by row (rank 8 first) then column
+func (c *Chess) Board() Board {
+	return c.boardTable
 }
 
-func (c *Chess) Turn() rune {
-	return c.turn
+// Castling returns the castle availability of both sides
+func (c *Chess) Castling() CastleAvailability {
+	return c.castle
+}
+
+// EnPassant returns the en passant square, NoSquare if there is none
+func (c *Chess) EnPassant() Square {
+	return c.pawnPassant
+}
+
+// HalfMoves returns the number of half moves since the last capture or pawn move
+func (c *Chess) HalfMoves() int {
+	return c.halfmoves
+}
+
+// FullMoves returns the number of the current move, starting at 1
+func (c *Chess) FullMoves() int {
+	return c.fullmoves
 }
 
 // GetFEN returns the FEN string of the current chess game
@@ -191,7 +284,7 @@ func (c *Chess) GetFEN() string {
 	for i, row := range c.boardTable {
 		var spaceCount int
 		for _, content := range row {
-			if content == '-' {
+			if content == NoPiece {
 				spaceCount++
 				continue
 			}
@@ -214,7 +307,7 @@ func (c *Chess) GetFEN() string {
 	}
 
 	// Turn
-	fen += " " + string(c.turn) + " "
+	fen += " " + c.turn.String() + " "
 
 	// Castle
 	if c.castle.WhiteKing {
@@ -229,9 +322,12 @@ func (c *Chess) GetFEN() string {
 	if c.castle.BlackQueen {
 		fen += "q"
 	}
+	if c.castle == (CastleAvailability{}) {
+		fen += "-"
+	}
 
 	// Pawn Passant
-	fen += " " + c.pawnPassant
+	fen += " " + c.pawnPassant.String()
 
 	// Half Moves
 	fen += " " + strconv.Itoa(c.halfmoves)
@@ -264,7 +360,7 @@ func (c *Chess) decodeFen(fen string) error {
 			if column < 1 || column > 8 {
 				column = 1
 			} else {
-				columnContent = '-'
+				columnContent = rune(NoPiece)
 			}
 
 			for i := 0; i < column; i++ {
@@ -273,7 +369,7 @@ func (c *Chess) decodeFen(fen string) error {
 					break
 				}
 
-				c.boardTable[row][col] = columnContent
+				c.boardTable[row][col] = Piece(columnContent)
 			}
 
 			if column > 1 {
@@ -288,13 +384,17 @@ func (c *Chess) decodeFen(fen string) error {
 	}
 
 	// Turn
-	if len(splitFen[1]) != 1 {
+	if splitFen[1] != "w" && splitFen[1] != "b" {
 		return &FENError{err: "invalid turn parameter"}
 	}
-	c.turn = []rune(splitFen[1])[0]
+	c.turn = Color([]rune(splitFen[1])[0])
 
 	// Castle
 	for _, castleAble := range splitFen[2] {
+		if !strings.ContainsRune("KQkq-", castleAble) {
+			return &FENError{err: "invalid castle parameter"}
+		}
+
 		if castleAble == 'K' {
 			c.castle.WhiteKing = true
 		} else if castleAble == 'Q' {
@@ -307,9 +407,8 @@ func (c *Chess) decodeFen(fen string) error {
 	}
 
 	// Pawn Passant
-	c.pawnPassant = splitFen[3]
-	if translateCBtoCoords(c.pawnPassant) == nil && c.pawnPassant != "-" {
-		c.pawnPassant = "-"
+	c.pawnPassant = translateCBtoCoords(splitFen[3])
+	if c.pawnPassant == NoSquare && splitFen[3] != "-" {
 		return &FENError{err: "invalid pawn passant"}
 	}
 
@@ -328,107 +427,109 @@ func (c *Chess) decodeFen(fen string) error {
 	return nil
 }
 
-func (c *Chess) move(fromCoords *Coords, toCoords *Coords) (int, error) {
+func (c *Chess) move(fromCoords Square, toCoords Square, promotion PieceType, input string) (*MoveResult, error) {
+	move, err := c.legalMove(fromCoords, toCoords, promotion, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.play(move), nil
+}
+
+// legalMove returns the move of the piece on a square to another, or an
+// error naming the input when it is not a legal move of the side to move
+func (c *Chess) legalMove(fromCoords Square, toCoords Square, promotion PieceType, input string) (Move, error) {
 	piece := determinePieceWithCoords(fromCoords, c.boardTable)
 
 	color := determineColor(piece)
-	enemy := determineEnemy(color)
 
 	from := translateCoordsToCB(fromCoords)
 	to := translateCoordsToCB(toCoords)
 
 	// Check if current turn
 	if color != c.turn {
-		return -1, &MoveError{err: "Not current turn"}
+		if color == NoColor {
+			return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: "no piece on " + from}
+		}
+		return Move{}, &MoveError{Move: input, Err: ErrNotYourTurn, err: "Not current turn"}
 	}
 
 	// Check if valid move
 	validMoves := c.calculateValidMoves(fromCoords)
 	if !checkIfMovesContains(&validMoves, toCoords) {
-		return -1, &MoveError{err: fmt.Sprintf("not a valid move from %s to %s", from, to)}
+		return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: fmt.Sprintf("not a valid move from %s to %s", from, to)}
 	}
 
-	statusCode := 0
-
-	c.halfmoves++
-
-	// Check if capture then reset halfmoves
-	if determinePieceWithCoords(toCoords, c.boardTable) != '-' {
-		c.halfmoves = 0
+	// Check if the promotion fits the move
+	move := c.newMove(fromCoords, toCoords, promotion)
+	if promotion != NoPieceType && !move.IsPromotion() {
+		return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: "only a pawn reaching the last rank can promote"}
+	}
+	if move.IsPromotion() && (move.Promotion == Pawn || move.Promotion == King) {
+		return Move{}, &MoveError{Move: input, Err: ErrIllegalMove, err: "can not promote to " + move.Promotion.String()}
 	}
 
-	// Move the piece in board
-	movePiece(fromCoords, toCoords, &c.boardTable)
-
-	// Check if checked
-	if c.checkIfChecked(enemy, c.boardTable) {
-		statusCode = 1
+	return move, nil
+}
 
-		if c.checkIfMate(enemy) {
-			statusCode = 2
-			c.winner = c.turn
-		}
-	}
+// play plays a legal move, tracks it and returns its result
+func (c *Chess) play(move Move) *MoveResult {
+	san := c.sanOf(move)
+	c.movesTracker = append(c.movesTracker, trackedMove{
+		move:     move,
+		position: c.positionKey(),
+		previous: c.snapshot(),
+	})
 
-	// Post process
-	switch unicode.ToLower(piece) {
-	case 'p':
-		// Check if pawn moves 2 times
-		if math.Abs(float64(fromCoords.row-toCoords.row)) == 2 {
-			c.pawnPassant = from
-		}
-		c.halfmoves = 0
+	c.makeMove(move)
 
-	case 'r':
-		if color == 'w' {
-			if fromCoords.col == 7 {
-				c.castle.WhiteKing = false
-			} else if fromCoords.col == 0 {
-				c.castle.WhiteQueen = false
-			}
-		} else {
-			if fromCoords.col == 7 {
-				c.castle.BlackKing = false
-			} else if fromCoords.col == 0 {
-				c.castle.BlackQueen = false
-			}
-		}
+	result := &MoveResult{
+		Move:     move,
+		UCI:      move.String(),
+		Captured: move.Captured,
+	}
 
-	case 'k':
-		// Determine the row of the king based on color
-		kingRow := determineKingRow(color)
+	// Check if checked
+	if c.checkIfChecked(c.turn, c.boardTable) {
+		result.Check = true
+	}
 
-		// Move rook if king castled
-		if fromCoords.col-toCoords.col == 2 {
-			movePiece(&Coords{kingRow, 0}, &Coords{kingRow, 3}, &c.boardTable)
-		} else if fromCoords.col-toCoords.col == -2 {
-			movePiece(&Coords{kingRow, 7}, &Coords{kingRow, 5}, &c.boardTable)
-		}
+	c.updateOutcome()
 
-		// Make castle availability false if king moved
-		if color == 'w' {
-			c.castle.WhiteKing = false
-			c.castle.WhiteQueen = false
+	if result.Check {
+		if c.method == Checkmate {
+			san += "#"
 		} else {
-			c.castle.BlackKing = false
-			c.castle.BlackQueen = false
+			san += "+"
 		}
 	}
+	c.movesTracker[len(c.movesTracker)-1].san = san
 
-	// Increment fullmoves after the turn of black
-	if c.turn == 'b' {
-		c.fullmoves++
-	}
+	result.SAN = san
+	result.GameOver = c.IsGameOver()
+	result.Outcome = c.outcome
+	result.Method = c.method
 
-	// Switch the turn
-	c.turn = enemy
+	return result
+}
 
-	return statusCode, nil
+// revokeCastle makes castle availability false for the rook home square
+func (c *Chess) revokeCastle(square Square) {
+	switch square {
+	case H1:
+		c.castle.WhiteKing = false
+	case A1:
+		c.castle.WhiteQueen = false
+	case H8:
+		c.castle.BlackKing = false
+	case A8:
+		c.castle.BlackQueen = false
+	}
 }
 
-// calculateValidMoves calculates the valid paths in a given piece coordinate
-func (c *Chess) calculateValidMoves(coord *Coords) []*Coords {
-	var validMoves []*Coords
+// calculateValidMoves calculates the valid paths in a given piece square
+func (c *Chess) calculateValidMoves(coord Square) []Square {
+	var validMoves []Square
 
 	piece := determinePieceWithCoords(coord, c.boardTable)
 
@@ -446,12 +547,12 @@ func (c *Chess) calculateValidMoves(coord *Coords) []*Coords {
 }
 
 // calculateMoves TODO: optimize move calculations
-// calculateMoves calculates the paths in a given piece and coordinate
-func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep int) []*Coords {
-	var moves []*Coords
+// calculateMoves calculates the paths in a given piece and square
+func (c *Chess) calculateMoves(piece Piece, coord Square, board Board, maxStep int) []Square {
+	var moves []Square
 	color := determineColor(piece)
 
-	addMove := func(move *Coords) {
+	addMove := func(move Square) {
 		// Check if the move is out of bounds
 		if checkIfCoordsIsOutOfBounds(move) {
 			return
@@ -460,11 +561,11 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		moves = append(moves, move)
 	}
 
-	switch unicode.ToLower(piece) {
+	switch unicode.ToLower(rune(piece)) {
 	// Pawn
 	case 'p':
 		var startingRow, direction int
-		if color == 'w' {
+		if color == White {
 			startingRow = 6
 			direction = -1
 		} else {
@@ -473,12 +574,12 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		}
 
 		numOfMoves := 1
-		if coord.row == startingRow {
+		if coord.row() == startingRow {
 			numOfMoves = 2
 		}
 
 		for i := 0; i < numOfMoves; i++ {
-			newCoords := &Coords{coord.row + direction*(i+1), coord.col}
+			newCoords := offsetSquare(coord, direction*(i+1), 0)
 
 			if checkIfThereIsPieceInCoords(newCoords, board) {
 				break
@@ -488,19 +589,19 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		}
 
 		for _, sideDirection := range []int{1, -1} {
-			newCoords := &Coords{coord.row + direction, coord.col + sideDirection}
+			newCoords := offsetSquare(coord, direction, sideDirection)
+			if newCoords == NoSquare {
+				continue
+			}
 
 			if !checkIfThereIsPieceInCoords(newCoords, board) {
-				if c.pawnPassant == "-" {
+				// Only capture an empty square en passant, behind an enemy pawn
+				if c.pawnPassant == NoSquare || newCoords != c.pawnPassant {
 					continue
 				}
 
-				pawnPassantCoord := translateCBtoCoords(c.pawnPassant)
-				if checkIfAllyInCoords(pawnPassantCoord, color, board) {
-					continue
-				}
-
-				if !(coord.row == pawnPassantCoord.row && newCoords.col == pawnPassantCoord.col) {
+				victim := squareAt(coord.row(), newCoords.col())
+				if determinePieceWithCoords(victim, board) != determineColorPiece(determineEnemy(color), 'p') {
 					continue
 				}
 			}
@@ -515,7 +616,7 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 	case 'n':
 		for _, rowDirection := range []int{2, -2} {
 			for _, colDirection := range []int{1, -1} {
-				newCoords := &Coords{coord.row + rowDirection, coord.col + colDirection}
+				newCoords := offsetSquare(coord, rowDirection, colDirection)
 
 				if checkIfAllyInCoords(newCoords, color, board) {
 					continue
@@ -526,7 +627,7 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		}
 		for _, rowDirection := range []int{1, -1} {
 			for _, colDirection := range []int{2, -2} {
-				newCoords := &Coords{coord.row + rowDirection, coord.col + colDirection}
+				newCoords := offsetSquare(coord, rowDirection, colDirection)
 
 				if checkIfAllyInCoords(newCoords, color, board) {
 					continue
@@ -540,7 +641,10 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		for _, rowDirection := range []int{1, -1} {
 			for _, colDirection := range []int{1, -1} {
 				for i := 0; i < maxStep; i++ {
-					newCoords := &Coords{coord.row + rowDirection*(i+1), coord.col + colDirection*(i+1)}
+					newCoords := offsetSquare(coord, rowDirection*(i+1), colDirection*(i+1))
+					if newCoords == NoSquare {
+						break
+					}
 
 					if checkIfThereIsPieceInCoords(newCoords, board) {
 						if !checkIfAllyInCoords(newCoords, color, board) {
@@ -565,9 +669,9 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 						switcher1 = 0
 					}
 
-					newCoords := &Coords{
-						coord.row + direction*(i+1)*switcher,
-						coord.col + direction*(i+1)*switcher1,
+					newCoords := offsetSquare(coord, direction*(i+1)*switcher, direction*(i+1)*switcher1)
+					if newCoords == NoSquare {
+						break
 					}
 
 					if checkIfThereIsPieceInCoords(newCoords, board) {
@@ -599,48 +703,73 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 		blackRow := 0
 
 		// Define col
+		kingCol := 4
 		kingSideCol := 6
 		queenSideCol := 2
 
-		// Define coords of castle
-		whiteKing := &Coords{row: whiteRow, col: kingSideCol}
-		whiteQueen := &Coords{row: whiteRow, col: queenSideCol}
-		blackKing := &Coords{row: blackRow, col: kingSideCol}
-		blackQueen := &Coords{row: blackRow, col: queenSideCol}
+		// Castle only from the home square of the king
+		if (color == White && coord != squareAt(whiteRow, kingCol)) ||
+			(color == Black && coord != squareAt(blackRow, kingCol)) {
+			break
+		}
+
+		// Define squares of castle
+		whiteKing := squareAt(whiteRow, kingSideCol)
+		whiteQueen := squareAt(whiteRow, queenSideCol)
+		blackKing := squareAt(blackRow, kingSideCol)
+		blackQueen := squareAt(blackRow, queenSideCol)
+
+		// Define squares of side of castle, which the king passes through
+		whiteKing1 := squareAt(whiteRow, kingSideCol-1)
+		whiteQueen1 := squareAt(whiteRow, queenSideCol+1)
+		blackKing1 := squareAt(blackRow, kingSideCol-1)
+		blackQueen1 := squareAt(blackRow, queenSideCol+1)
+
+		// Define squares beside the rook in queen side, which only the rook passes through
+		whiteQueen2 := squareAt(whiteRow, queenSideCol-1)
+		blackQueen2 := squareAt(blackRow, queenSideCol-1)
 
-		// Define coords of side of castle
-		whiteKing1 := &Coords{row: whiteRow, col: kingSideCol - 1}
-		whiteQueen1 := &Coords{row: whiteRow, col: queenSideCol + 1}
-		blackKing1 := &Coords{row: blackRow, col: kingSideCol - 1}
-		blackQueen1 := &Coords{row: blackRow, col: queenSideCol + 1}
+		// Define squares of the rooks
+		whiteKingRook := squareAt(whiteRow, 7)
+		whiteQueenRook := squareAt(whiteRow, 0)
+		blackKingRook := squareAt(blackRow, 7)
+		blackQueenRook := squareAt(blackRow, 0)
 
-		if color == 'w' {
+		rook := determineColorPiece(color, 'r')
+
+		if color == White {
 			if c.castle.WhiteKing &&
-				!c.checkIfMoveIsCheck(coord, whiteKing1, board) &&
+				determinePieceWithCoords(whiteKingRook, board) == rook &&
 				!checkIfThereIsPieceInCoords(whiteKing, board) &&
-				!checkIfThereIsPieceInCoords(whiteKing1, board) {
+				!checkIfThereIsPieceInCoords(whiteKing1, board) &&
+				!c.checkIfMoveIsCheck(coord, whiteKing1, board) {
 
 				moves = append(moves, whiteKing)
 			}
 			if c.castle.WhiteQueen &&
-				!c.checkIfMoveIsCheck(coord, whiteQueen1, board) &&
+				determinePieceWithCoords(whiteQueenRook, board) == rook &&
 				!checkIfThereIsPieceInCoords(whiteQueen, board) &&
-				!checkIfThereIsPieceInCoords(whiteQueen1, board) {
+				!checkIfThereIsPieceInCoords(whiteQueen1, board) &&
+				!checkIfThereIsPieceInCoords(whiteQueen2, board) &&
+				!c.checkIfMoveIsCheck(coord, whiteQueen1, board) {
 
 				moves = append(moves, whiteQueen)
 			}
 		} else {
 			if c.castle.BlackKing &&
-				!c.checkIfMoveIsCheck(coord, blackKing1, board) &&
+				determinePieceWithCoords(blackKingRook, board) == rook &&
 				!checkIfThereIsPieceInCoords(blackKing, board) &&
-				!checkIfThereIsPieceInCoords(blackKing1, board) {
+				!checkIfThereIsPieceInCoords(blackKing1, board) &&
+				!c.checkIfMoveIsCheck(coord, blackKing1, board) {
 
 				moves = append(moves, blackKing)
 			}
 			if c.castle.BlackQueen &&
-				!c.checkIfMoveIsCheck(coord, blackQueen1, board) &&
+				determinePieceWithCoords(blackQueenRook, board) == rook &&
 				!checkIfThereIsPieceInCoords(blackQueen, board) &&
-				!checkIfThereIsPieceInCoords(blackQueen1, board) {
+				!checkIfThereIsPieceInCoords(blackQueen1, board) &&
+				!checkIfThereIsPieceInCoords(blackQueen2, board) &&
+				!c.checkIfMoveIsCheck(coord, blackQueen1, board) {
 
 				moves = append(moves, blackQueen)
 			}
@@ -651,11 +780,11 @@ func (c *Chess) calculateMoves(piece rune, coord *Coords, board Board, maxStep i
 }
 
 // checkIfMate checks if the king is mated
-func (c *Chess) checkIfMate(color rune) bool {
+func (c *Chess) checkIfMate(color Color) bool {
 	for y, row := range c.boardTable {
 		for x, piece := range row {
 			if determineColor(piece) == color {
-				if len(c.calculateValidMoves(&Coords{y, x})) > 0 {
+				if len(c.calculateValidMoves(squareAt(y, x))) > 0 {
 					return false
 				}
 			}
@@ -666,24 +795,34 @@ func (c *Chess) checkIfMate(color rune) bool {
 }
 
 // checkIfChecked checks if the king is checked
-func (c *Chess) checkIfChecked(color rune, board Board) bool {
+func (c *Chess) checkIfChecked(color Color, board Board) bool {
 	king := determineColorPiece(color, 'k')
 
-	var kingCoord Coords
+	kingCoord := NoSquare
 
 	for y, row := range board {
 		for x, piece := range row {
 			if piece == king {
-				kingCoord.col = x
-				kingCoord.row = y
+				kingCoord = squareAt(y, x)
 			}
 		}
 	}
 
+	if kingCoord == NoSquare {
+		return false
+	}
+
+	// The enemy king can not come next to the king either
+	for _, move := range c.calculateMoves(determineColorPiece(color, 'q'), kingCoord, board, 1) {
+		if determinePieceWithCoords(move, board) == determineColorPiece(determineEnemy(color), 'k') {
+			return true
+		}
+	}
+
 	for _, attackingPiece := range []rune{'p', 'n', 'b', 'r', 'q'} {
-		attackingPiece = determineColorPiece(color, attackingPiece)
-		enemyPiece := determineEnemyVersion(attackingPiece)
-		moves := c.calculateMoves(attackingPiece, &kingCoord, board, maxStep)
+		colorPiece := determineColorPiece(color, attackingPiece)
+		enemyPiece := determineEnemyVersion(colorPiece)
+		moves := c.calculateMoves(colorPiece, kingCoord, board, maxStep)
 
 		for _, move := range moves {
 			if determinePieceWithCoords(move, board) == enemyPiece {
@@ -696,8 +835,11 @@ func (c *Chess) checkIfChecked(color rune, board Board) bool {
 }
 
 // checkIfMoveIsCheck Check if the move leads to a check
-func (c *Chess) checkIfMoveIsCheck(from *Coords, to *Coords, board Board) bool {
+func (c *Chess) checkIfMoveIsCheck(from Square, to Square, board Board) bool {
 	color := determineColor(determinePieceWithCoords(from, board))
+	if passantVictim := determinePassantVictim(from, to, board); passantVictim != NoSquare {
+		board[passantVictim.row()][passantVictim.col()] = NoPiece
+	}
 	movePiece(from, to, &board)
 
 	if c.checkIfChecked(color, board) {
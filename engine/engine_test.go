@@ -1,8 +1,12 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -13,7 +17,7 @@ import (
 func TestEngine_Move(t *testing.T) {
 	chess := NewGameChess()
 
-	_, err := chess.Move("b1", "c3")
+	_, err := chess.Move(Move{From: B1, To: C3})
 	if err != nil {
 		panic(err)
 	}
@@ -36,11 +40,11 @@ func TestEngine_GetFen(t *testing.T) {
 	}
 }
 func TestEngine_determineColor(t *testing.T) {
-	inputs := []rune{
+	inputs := []Piece{
 		'b', 'r', 'R', 'K', '-',
 	}
 
-	expectedOutputs := []rune{
+	expectedOutputs := []Color{
 		'b', 'b', 'w', 'w', '-',
 	}
 
@@ -54,15 +58,15 @@ func TestEngine_determineColor(t *testing.T) {
 	}
 }
 func TestEngine_determinePieceWithCoords(t *testing.T) {
-	inputs := []*Coords{
-		{0, 2},
-		{0, 5},
-		{7, 0},
-		{7, 6},
-		//{10, 3},
+	inputs := []Square{
+		C8,
+		F8,
+		A1,
+		G1,
+		NoSquare,
 	}
 
-	expectedOutputs := []rune{
+	expectedOutputs := []Piece{
 		'b',
 		'b',
 		'R',
@@ -88,10 +92,10 @@ func TestEngine_translateCBtoCoords(t *testing.T) {
 		"-",
 	}
 
-	expectedOutputs := []*Coords{
-		{0, 0},
-		nil,
-		nil,
+	expectedOutputs := []Square{
+		A8,
+		NoSquare,
+		NoSquare,
 	}
 
 	for i, input := range inputs {
@@ -104,10 +108,10 @@ func TestEngine_translateCBtoCoords(t *testing.T) {
 	}
 }
 func TestEngine_translateCoordsToCB(t *testing.T) {
-	inputs := []*Coords{
-		{1, 1},
-		{0, 0},
-		{4, 5},
+	inputs := []Square{
+		B7,
+		A8,
+		F4,
 	}
 
 	expectedOutputs := []string{
@@ -150,7 +154,7 @@ func TestEngine_decodeFen(t *testing.T) {
 				BlackKing:  true,
 				BlackQueen: true,
 			},
-			pawnPassant: "-",
+			pawnPassant: NoSquare,
 			halfmoves:   0,
 			fullmoves:   1,
 		},
@@ -172,7 +176,7 @@ func TestEngine_decodeFen(t *testing.T) {
 				BlackKing:  false,
 				BlackQueen: true,
 			},
-			pawnPassant: "c6",
+			pawnPassant: C6,
 			halfmoves:   5,
 			fullmoves:   23,
 		},
@@ -190,6 +194,17 @@ func TestEngine_decodeFen(t *testing.T) {
 			t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", chess, expected)
 		}
 	}
+
+	// The turn is white or black even when loading leniently
+	for _, input := range []string{
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR x KQkq - 0 2",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR - KQkq - 0 2",
+	} {
+		var fenError *FENError
+		if _, err := NewChessGameWithFen(input); !errors.As(err, &fenError) {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%s", input, err, "invalid turn parameter")
+		}
+	}
 }
 func TestEngine_calculateValidMoves(t *testing.T) {
 	inputs := []string{
@@ -199,14 +214,14 @@ func TestEngine_calculateValidMoves(t *testing.T) {
 		"e3",
 	}
 
-	expectedOutputs := [][]*Coords{
-		{{3, 0}, {3, 1}},
-		{{5, 0}, {4, 0}},
-		{{2, 1}, {2, 2}, {2, 0}},
-		{{3, 5}, {3, 3}, {4, 6}, {4, 2}},
+	expectedOutputs := [][]Square{
+		{A5, B5},
+		{A3, A4},
+		{B6, C6, A6},
+		{F5, D5, G4, C4},
 	}
 
-	chess, _ := NewChessGameWithFen("rnbqkbnr/1p1ppppp/p7/1Pp5/4P3/4N3/PPPP1PPP/RNBQKBNR w Kq c5 5 23")
+	chess, _ := NewChessGameWithFen("rnbqkbnr/1p1ppppp/p7/1Pp5/4P3/4N3/PPPP1PPP/RNBQKBNR w Kq c6 5 23")
 	for i, input := range inputs {
 
 		expectedOutput := expectedOutputs[i]
@@ -259,3 +274,579 @@ func TestEngine_checkIfChecked(t *testing.T) {
 		}
 	}
 }
+func TestEngine_ParseMove(t *testing.T) {
+	inputs := []string{
+		"e2e4",
+		"e7e8q",
+		"e7e8k",
+		"i2e4",
+		"e2",
+	}
+
+	expectedOutputs := []string{
+		"e2e4",
+		"e7e8q",
+		"",
+		"",
+		"",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		move, err := ParseMove(input)
+
+		var output string
+		if err == nil {
+			output = move.String()
+		}
+
+		if output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}
+func TestEngine_Piece(t *testing.T) {
+	inputs := []string{
+		"K",
+		"n",
+		"x",
+	}
+
+	expectedOutputs := []Piece{
+		NewPiece(White, King),
+		NewPiece(Black, Knight),
+		NoPiece,
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output, _ := ParsePiece(input)
+
+		if output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+		if output.Color() != expected.Color() || output.Type() != expected.Type() {
+			t.Errorf("FAILED: %s\n\tgot:     %v %v\n\texpected:%v %v", input, output.Color(), output.Type(), expected.Color(), expected.Type())
+		}
+	}
+}
+func TestEngine_KingSquare(t *testing.T) {
+	inputs := []string{DefaultFen, "8/8/8/8/8/8/8/4K3 w - - 0 1"}
+
+	expectedOutputs := [][2]Square{{E1, E8}, {E1, NoSquare}}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		chess, _ := NewChessGameWithFen(input)
+
+		if output := [2]Square{chess.KingSquare(White), chess.KingSquare(Black)}; output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", input, output, expected)
+		}
+	}
+}
+func TestEngine_ColorName(t *testing.T) {
+	inputs := []Color{White, Black, NoColor}
+
+	expectedOutputs := []string{"white White", "black Black", "none None"}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := input.Name() + " " + input.Title()
+
+		if output != expected {
+			t.Errorf("FAILED: %v\n\tgot:     %s\n\texpected:%s", input, output, expected)
+		}
+	}
+}
+func TestEngine_MovePGN(t *testing.T) {
+	inputs := []string{
+		"f3", "e5", "g4", "Qh4",
+	}
+
+	expectedOutputs := []string{
+		"f3", "e5", "g4", "Qh4#",
+	}
+
+	chess := NewGameChess()
+	var result *MoveResult
+	for i, input := range inputs {
+		var err error
+		expected := expectedOutputs[i]
+		result, err = chess.MovePGN(input)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+
+		if result.SAN != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, result.SAN, expected)
+		}
+	}
+
+	if !result.Check || !result.GameOver || result.Outcome != BlackWon || result.Method != Checkmate {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", result, "checkmate won by black")
+	}
+
+	_, err := chess.MovePGN("e4")
+	if !errors.Is(err, ErrGameOver) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrGameOver)
+	}
+}
+func TestEngine_SAN(t *testing.T) {
+	inputs := []struct {
+		fen  string
+		move string
+	}{
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4"},
+		{"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 2 3", "f3f7"},
+		{"r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7"},
+		{"4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", "e1g1"},
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "a1d1"},
+		{"7k/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n"},
+		{"7k/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q"},
+	}
+
+	expectedOutputs := []string{"Qh4#", "Qxf7+", "Qxf7#", "O-O", "Rad1", "b8=N", "b8=Q+"}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		chess, err := NewChessGameWithFen(input.fen)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input.fen, err.Error())
+		}
+		move, _ := ParseMove(input.move)
+
+		output, err := chess.SAN(move)
+		if err != nil || output != expected {
+			t.Errorf("FAILED: %s %s\n\tgot:     %+v %v\n\texpected:%+v", input.fen, input.move, output, err, expected)
+		}
+		if fen := chess.GetFEN(); fen != input.fen || len(chess.History()) != 0 || chess.IsGameOver() {
+			t.Errorf("FAILED: %s %s\n\tgot:     %+v\n\texpected:%+v", input.fen, input.move, fen, "the game left as it was")
+		}
+	}
+
+	chess := NewGameChess()
+	if _, err := chess.SAN(Move{From: E2, To: E5}); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrIllegalMove)
+	}
+}
+func TestEngine_MoveErrors(t *testing.T) {
+	inputs := []string{
+		"Nd2",
+		"e5",
+		"Nc3",
+		"d8Q",
+	}
+
+	expectedOutputs := []error{
+		ErrAmbiguousMove,
+		ErrIllegalMove,
+		nil,
+		ErrIllegalMove,
+	}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen("4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1")
+		expected := expectedOutputs[i]
+		_, err := chess.MovePGN(input)
+
+		if !errors.Is(err, expected) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, err, expected)
+		}
+
+		var moveError *MoveError
+		if err != nil && !errors.As(err, &moveError) {
+			t.Errorf("FAILED: %s\n\tgot:     %T\n\texpected:%T", input, err, moveError)
+		}
+	}
+
+	chess := NewGameChess()
+	_, err := chess.Move(Move{From: E7, To: E5})
+	if !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrNotYourTurn)
+	}
+}
+
+// perft counts the leaf nodes of the legal move tree up to depth
+func perft(chess *Chess, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+
+	nodes := 0
+	for _, move := range chess.LegalMoves() {
+		saved := *chess
+		if _, err := chess.Move(move); err != nil {
+			panic(err)
+		}
+		nodes += perft(chess, depth-1)
+		*chess = saved
+	}
+
+	return nodes
+}
+func TestEngine_LegalMoves(t *testing.T) {
+	inputs := []string{
+		DefaultFen,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	}
+
+	expectedOutputs := []int{
+		400,
+		2039,
+		191,
+		264,
+	}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen(input)
+		expected := expectedOutputs[i]
+		output := perft(chess, 2)
+
+		if output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}
+func TestEngine_LegalMovesFilters(t *testing.T) {
+	// White can promote on b8 with or without capturing, and take en passant on d6
+	chess, _ := NewChessGameWithFen("rn2k3/1P6/8/2Pp4/8/8/8/4K2R w K d6 0 1")
+
+	inputs := [][]Move{
+		chess.LegalCaptures(),
+		chess.LegalChecks(),
+		chess.LegalMovesOf(Pawn),
+	}
+
+	expectedOutputs := [][]string{
+		{"b7a8q", "b7a8r", "b7a8b", "b7a8n", "c5d6"},
+		{"h1h8"},
+		{"b7a8q", "b7a8r", "b7a8b", "b7a8n", "c5c6", "c5d6"},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		var output []string
+		for _, move := range input {
+			output = append(output, move.String())
+		}
+
+		if !reflect.DeepEqual(output, expected) {
+			t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+		}
+	}
+
+	result, err := chess.Move(Move{From: C5, To: D6})
+	if err != nil || !result.Move.IsEnPassant() || result.Captured != BlackPawn || chess.PieceAt(D5) != NoPiece {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", result, err, "en passant capture of d5")
+	}
+}
+func TestEngine_Validate(t *testing.T) {
+	inputs := []string{
+		DefaultFen,
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",
+		"4k3/8/8/8/8/8/8/8 w - - 0 1",
+		"4k3/8/8/8/8/8/8/3KK3 w - - 0 1",
+		"P3k3/8/8/8/8/8/8/4K3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K2X w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w K - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w - e6 0 1",
+		"4k3/8/8/4p3/8/8/8/4K3 w - c6 0 1",
+		"4k3/8/8/8/8/8/8/r3K3 b - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3 w - - -1 0",
+	}
+
+	expectedOutputs := [][]string{
+		nil,
+		nil,
+		{FieldBoard},
+		{FieldBoard},
+		{FieldBoard},
+		{FieldBoard},
+		{FieldCastling},
+		{FieldEnPassant},
+		{FieldEnPassant},
+		{FieldTurn},
+		{FieldHalfmoves, FieldFullmoves},
+	}
+
+	for i, input := range inputs {
+		chess, err := NewChessGameWithFen(input)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+		expected := expectedOutputs[i]
+
+		var output []string
+		var validationErrors ValidationErrors
+		if errors.As(chess.Validate(), &validationErrors) {
+			for _, validationError := range validationErrors {
+				output = append(output, validationError.Field)
+			}
+		}
+
+		if !reflect.DeepEqual(output, expected) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, chess.Validate(), expected)
+		}
+
+		_, err = NewChessGameWithFen(input, Strict)
+		if (err == nil) != (expected == nil) {
+			t.Errorf("FAILED: %s strict\n\tgot:     %+v\n\texpected:%+v", input, err, expected)
+		}
+	}
+}
+func TestEngine_Undo(t *testing.T) {
+	chess := NewGameChess()
+
+	for _, move := range []string{"e4", "d5", "exd5", "c6"} {
+		if _, err := chess.MovePGN(move); err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", move, err.Error())
+		}
+	}
+
+	expected := []string{"e4", "d5", "exd5", "c6"}
+	if output := chess.HistorySAN(); !reflect.DeepEqual(output, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := chess.Undo(); err != nil {
+			t.Fatalf("FAILED: undo %d\n\t%s", i, err.Error())
+		}
+	}
+
+	if output := chess.GetFEN(); output != DefaultFen {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, DefaultFen)
+	}
+
+	if _, err := chess.Undo(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrNoHistory)
+	}
+}
+func TestEngine_Timeout(t *testing.T) {
+	inputs := []struct {
+		fen     string
+		flagged Color
+	}{
+		{"4k3/8/8/8/8/8/8/4K2R w K - 0 1", White},
+		{"4k3/8/8/8/8/8/8/4K2R b K - 0 1", Black},
+		{"4k3/8/8/8/8/8/P7/n3K3 w - - 0 1", White},
+		{"4k3/8/8/8/8/8/P7/2b1K3 w - - 0 1", White},
+		{"2b1k3/8/4b3/8/8/8/8/2B1K3 w - - 0 1", White},
+	}
+
+	expectedOutputs := []struct {
+		outcome Outcome
+		method  Method
+	}{
+		{Draw, TimeoutVsInsufficientMaterial},
+		{WhiteWon, Timeout},
+		{BlackWon, Timeout},
+		{BlackWon, Timeout},
+		{BlackWon, Timeout},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		chess, err := NewChessGameWithFen(input.fen)
+		if err != nil {
+			t.Fatalf("FAILED: %+v\n\t%s", input, err.Error())
+		}
+		if err := chess.Timeout(input.flagged); err != nil {
+			t.Fatalf("FAILED: %+v\n\t%s", input, err.Error())
+		}
+
+		if chess.Outcome() != expected.outcome || chess.Method() != expected.method {
+			t.Errorf("FAILED: %+v\n\tgot:     %s by %s\n\texpected:%s by %s", input, chess.Outcome(), chess.Method(), expected.outcome, expected.method)
+		}
+	}
+
+	chess, _ := NewChessGameWithFen("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+	if err := chess.Timeout(White); !errors.Is(err, ErrGameOver) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrGameOver)
+	}
+}
+func TestEngine_Repetition(t *testing.T) {
+	inputs := []string{
+		// The en passant square of the first move can not be used
+		"e4 Nf6 Nf3 Ng8 Ng1 Nf6 Nf3 Ng8 Ng1",
+		// The pawn on e5 can take on d6 the first time only
+		"e4 Nf6 e5 d5 Nf3 Nc6 Ng1 Nb8 Nf3 Nc6 Ng1 Nb8",
+		"e4 Nf6 e5 d5 Nf3 Nc6 Ng1 Nb8 Nf3 Nc6 Ng1 Nb8 Nf3",
+	}
+
+	expectedOutputs := []bool{true, false, true}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		chess := NewGameChess()
+		for _, move := range strings.Fields(input) {
+			if _, err := chess.MovePGN(move); err != nil {
+				t.Fatalf("FAILED: %s\n\t%s", move, err.Error())
+			}
+		}
+
+		if output := chess.CanClaimDraw(); output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", input, output, expected)
+		}
+	}
+}
+func TestEngine_ClaimDraw(t *testing.T) {
+	inputs := []string{
+		"Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8",
+		"Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1",
+		"Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8",
+	}
+
+	expectedOutputs := []struct {
+		err    error
+		method Method
+	}{
+		{nil, ThreefoldRepetition},
+		{ErrNoDrawClaim, NoMethod},
+		// The fifth repetition ends the game without a claim
+		{ErrGameOver, FivefoldRepetition},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		chess := NewGameChess()
+		for _, move := range strings.Fields(input) {
+			if _, err := chess.MovePGN(move); err != nil {
+				t.Fatalf("FAILED: %s\n\t%s", move, err.Error())
+			}
+		}
+
+		if err := chess.ClaimDraw(); !errors.Is(err, expected.err) || chess.Method() != expected.method {
+			t.Errorf("FAILED: %s\n\tgot:     %v %v\n\texpected:%v %v", input, err, chess.Method(), expected.err, expected.method)
+		}
+	}
+
+	inputFens := []string{
+		"4k3/8/8/8/8/8/8/R3K3 w - - 100 60",
+		"4k3/8/8/8/8/8/8/R3K3 w - - 99 60",
+	}
+	expectedMethods := []Method{FiftyMoveRule, NoMethod}
+	for i, fen := range inputFens {
+		chess, err := NewChessGameWithFen(fen)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", fen, err.Error())
+		}
+		chess.ClaimDraw()
+		if chess.Method() != expectedMethods[i] {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", fen, chess.Method(), expectedMethods[i])
+		}
+	}
+
+	chess, _ := NewChessGameWithFen("4k3/8/8/8/8/8/8/R3K3 w - - 149 90")
+	chess.MovePGN("Ra2")
+	if chess.Method() != SeventyFiveMoveRule {
+		t.Errorf("FAILED: Ra2\n\tgot:     %v\n\texpected:%v", chess.Method(), SeventyFiveMoveRule)
+	}
+}
+func TestEngine_End(t *testing.T) {
+	inputs := []func(*Chess) error{
+		func(c *Chess) error { return c.Resign(White) },
+		func(c *Chess) error { return c.Resign(Black) },
+		func(c *Chess) error { return c.AgreeDraw() },
+		func(c *Chess) error { return c.Abandon(Black) },
+	}
+
+	expectedOutputs := []struct {
+		outcome Outcome
+		method  Method
+	}{
+		{BlackWon, Resignation},
+		{WhiteWon, Resignation},
+		{Draw, DrawAgreement},
+		{WhiteWon, Abandonment},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		chess := NewGameChess()
+		if err := input(chess); err != nil {
+			t.Fatalf("FAILED: %d\n\t%s", i, err.Error())
+		}
+		if chess.Outcome() != expected.outcome || chess.Method() != expected.method {
+			t.Errorf("FAILED: %d\n\tgot:     %s by %s\n\texpected:%s by %s", i, chess.Outcome(), chess.Method(), expected.outcome, expected.method)
+		}
+		if err := input(chess); !errors.Is(err, ErrGameOver) {
+			t.Errorf("FAILED: %d\n\tgot:     %+v\n\texpected:%+v", i, err, ErrGameOver)
+		}
+	}
+}
+func TestEngine_Search(t *testing.T) {
+	inputs := []string{
+		"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1",
+		"r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 4 4",
+		"4k3/8/8/8/8/8/3q4/4K3 w - - 0 1",
+	}
+
+	expectedOutputs := []string{
+		"d1d8",
+		"f3f7",
+		"e1d2",
+	}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen(input)
+		expected := expectedOutputs[i]
+		output := chess.Search(context.Background(), SearchLimits{Depth: 3}, nil)
+
+		if output.BestMove.String() != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output.BestMove, expected)
+		}
+		if chess.GetFEN() != input {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, chess.GetFEN(), input)
+		}
+	}
+
+	// The quiescence search sees the mate of a check
+	chess, _ := NewChessGameWithFen(inputs[0])
+	if output := chess.Search(context.Background(), SearchLimits{Depth: 1}, nil); output.Info.Mate != 1 {
+		t.Errorf("FAILED: %s depth 1\n\tgot:     %+v\n\texpected:%+v", inputs[0], output.Info, "mate in 1")
+	}
+}
+func TestEngine_SearchRepetition(t *testing.T) {
+	chess, _ := NewChessGameWithFen("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1")
+	for _, move := range strings.Fields("Ke2 Kd7 Ke1 Ke8 Ke2 Kd7 Ke1") {
+		if _, err := chess.MovePGN(move); err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", move, err.Error())
+		}
+	}
+
+	// Black a queen down goes back to a position played before for a draw
+	output := chess.Search(context.Background(), SearchLimits{Depth: 2}, nil)
+	if output.BestMove.String() != "d7e8" || output.Info.Score != 0 {
+		t.Errorf("FAILED\n\tgot:     %v %d\n\texpected:%v %d", output.BestMove, output.Info.Score, "d7e8", 0)
+	}
+}
+func TestEngine_SearchStrength(t *testing.T) {
+	fen := "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1"
+	inputs := []Strength{
+		{Limits: SearchLimits{Depth: 2}, Noise: 100},
+		{Limits: SearchLimits{Depth: 3}},
+		{Blunder: 1},
+	}
+
+	// Noise does not hide a mate, a blunder is any legal move
+	expectedOutputs := []string{"d1d8", "d1d8", "d1f1"}
+
+	for i, input := range inputs {
+		chess, _ := NewChessGameWithFen(fen)
+		expected := expectedOutputs[i]
+		output := chess.SearchStrength(context.Background(), input, rand.New(rand.NewSource(1)), nil)
+
+		if output.BestMove.String() != expected {
+			t.Errorf("FAILED: %+v\n\tgot:     %+v\n\texpected:%+v", input, output.BestMove, expected)
+		}
+		if chess.GetFEN() != fen {
+			t.Errorf("FAILED: %+v\n\tgot:     %+v\n\texpected:%+v", input, chess.GetFEN(), fen)
+		}
+	}
+}
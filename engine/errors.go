@@ -1,5 +1,21 @@
 package engine
 
+import "errors"
+
+var (
+	// ErrIllegalMove is returned when a move breaks the rules of chess
+	ErrIllegalMove = errors.New("illegal move")
+	// ErrNotYourTurn is returned when moving a piece of the side not to move
+	ErrNotYourTurn = errors.New("not your turn")
+	// ErrAmbiguousMove is returned when a PGN move matches more than one piece
+	ErrAmbiguousMove = errors.New("ambiguous move")
+	// ErrGameOver is returned when moving after the game has ended
+	ErrGameOver = errors.New("game is over")
+	// ErrNoDrawClaim is returned when claiming a draw the position does not
+	// allow
+	ErrNoDrawClaim = errors.New("no draw to claim")
+)
+
 type FENError struct {
 	err string
 }
@@ -8,10 +24,31 @@ func (F *FENError) Error() string {
 	return "Invalid FEN: " + F.err
 }
 
+// MoveError describes a rejected move, Err is one of the sentinel errors
 type MoveError struct {
+	Move string
+	Err  error
+
 	err string
 }
 
 func (m *MoveError) Error() string {
-	return "Invalid Move: " + m.err
+	msg := "Invalid Move"
+	if m.Move != "" {
+		msg += " " + m.Move
+	}
+	return msg + ": " + m.err
+}
+
+func (m *MoveError) Unwrap() error {
+	return m.Err
+}
+
+type ParseError struct {
+	kind  string
+	input string
+}
+
+func (p *ParseError) Error() string {
+	return "Invalid " + p.kind + ": " + p.input
 }
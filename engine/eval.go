@@ -0,0 +1,152 @@
+package engine
+
+// pieceValues are the material values of the pieces in centipawns
+var pieceValues = map[PieceType]int{
+	Pawn:   100,
+	Knight: 320,
+	Bishop: 330,
+	Rook:   500,
+	Queen:  900,
+	King:   20000,
+}
+
+// pieceSquareTables are bonuses for white pieces on each square of the board,
+// indexed like Board with rank 8 first, black pieces use the mirrored square
+var pieceSquareTables = map[PieceType][8][8]int{
+	Pawn: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	Knight: {
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	},
+	Bishop: {
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	},
+	Rook: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	},
+	Queen: {
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	},
+	King: {
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	},
+}
+
+// kingEndgameTable replaces the king table once the queens are off the board
+// or little material is left, so the king walks to the center
+var kingEndgameTable = [8][8]int{
+	{-50, -40, -30, -20, -20, -30, -40, -50},
+	{-30, -20, -10, 0, 0, -10, -20, -30},
+	{-30, -10, 20, 30, 30, 20, -10, -30},
+	{-30, -10, 30, 40, 40, 30, -10, -30},
+	{-30, -10, 30, 40, 40, 30, -10, -30},
+	{-30, -10, 20, 30, 30, 20, -10, -30},
+	{-30, -30, 0, 0, 0, 0, -30, -30},
+	{-50, -30, -30, -30, -30, -30, -30, -50},
+}
+
+// PieceValue returns the material value of a piece type in centipawns
+func PieceValue(pieceType PieceType) int {
+	return pieceValues[pieceType]
+}
+
+// Evaluate returns a static evaluation of the position in centipawns, from
+// the point of view of the side to move
+func (c *Chess) Evaluate() int {
+	score := c.evaluateBoard()
+	if c.turn == Black {
+		return -score
+	}
+	return score
+}
+
+// evaluateBoard returns a static evaluation of the board in centipawns, from
+// the point of view of white
+func (c *Chess) evaluateBoard() int {
+	var score, material int
+	queens := 0
+
+	for _, row := range c.boardTable {
+		for _, piece := range row {
+			switch piece.Type() {
+			case Queen:
+				queens++
+				material += pieceValues[Queen]
+			case Knight, Bishop, Rook:
+				material += pieceValues[piece.Type()]
+			}
+		}
+	}
+	endgame := queens == 0 || material <= 2*pieceValues[Queen]
+
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			pieceType := piece.Type()
+			if pieceType == NoPieceType {
+				continue
+			}
+
+			tableRow := y
+			if determineColor(piece) == Black {
+				tableRow = 7 - y
+			}
+
+			table := pieceSquareTables[pieceType]
+			if pieceType == King && endgame {
+				table = kingEndgameTable
+			}
+
+			value := pieceValues[pieceType] + table[tableRow][x]
+			if determineColor(piece) == White {
+				score += value
+			} else {
+				score -= value
+			}
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,132 @@
+package engine
+
+import "errors"
+
+// ErrNoHistory is returned when undoing a move before any has been played
+var ErrNoHistory = errors.New("no move to undo")
+
+// snapshot is the state of the game that a move changes
+type snapshot struct {
+	boardTable  Board
+	turn        Color
+	castle      CastleAvailability
+	pawnPassant Square
+	halfmoves   int
+	fullmoves   int
+}
+
+// snapshot saves the state of the game before a move
+func (c *Chess) snapshot() snapshot {
+	return snapshot{
+		boardTable:  c.boardTable,
+		turn:        c.turn,
+		castle:      c.castle,
+		pawnPassant: c.pawnPassant,
+		halfmoves:   c.halfmoves,
+		fullmoves:   c.fullmoves,
+	}
+}
+
+// restore brings the game back to a saved state
+func (c *Chess) restore(s snapshot) {
+	c.boardTable = s.boardTable
+	c.turn = s.turn
+	c.castle = s.castle
+	c.pawnPassant = s.pawnPassant
+	c.halfmoves = s.halfmoves
+	c.fullmoves = s.fullmoves
+}
+
+// makeMove plays a legal move without checking it or tracking it
+func (c *Chess) makeMove(m Move) {
+	color := determineColor(m.Piece)
+
+	c.halfmoves++
+
+	// Check if capture then reset halfmoves
+	if m.Captured != NoPiece {
+		c.halfmoves = 0
+	}
+
+	// Move the piece in board, with the captured pawn, castled rook or promotion
+	applyMoveToBoard(m, &c.boardTable)
+	c.pawnPassant = NoSquare
+
+	// Make castle availability false if a rook left or was captured on its home square
+	c.revokeCastle(m.From)
+	c.revokeCastle(m.To)
+
+	// Post process
+	switch m.Piece.Type() {
+	case Pawn:
+		// Check if pawn moves 2 times, the square it skipped can be taken en passant
+		if m.From.row()-m.To.row() == 2 || m.From.row()-m.To.row() == -2 {
+			c.pawnPassant = squareAt((m.From.row()+m.To.row())/2, m.From.col())
+		}
+		c.halfmoves = 0
+
+	case King:
+		// Make castle availability false if king moved
+		if color == White {
+			c.castle.WhiteKing = false
+			c.castle.WhiteQueen = false
+		} else {
+			c.castle.BlackKing = false
+			c.castle.BlackQueen = false
+		}
+	}
+
+	// Increment fullmoves after the turn of black
+	if c.turn == Black {
+		c.fullmoves++
+	}
+
+	// Switch the turn
+	c.turn = determineEnemy(color)
+}
+
+// Undo takes back the last move played and returns it
+func (c *Chess) Undo() (Move, error) {
+	if len(c.movesTracker) == 0 {
+		return Move{}, ErrNoHistory
+	}
+
+	last := c.movesTracker[len(c.movesTracker)-1]
+	c.movesTracker = c.movesTracker[:len(c.movesTracker)-1]
+
+	c.restore(last.previous)
+	c.outcome = NoOutcome
+	c.method = NoMethod
+
+	return last.move, nil
+}
+
+// History returns the moves played since the game started
+func (c *Chess) History() []Move {
+	moves := make([]Move, 0, len(c.movesTracker))
+	for _, tracked := range c.movesTracker {
+		moves = append(moves, tracked.move)
+	}
+	return moves
+}
+
+// HistorySAN returns the moves played since the game started in Standard
+// Algebraic Notation
+func (c *Chess) HistorySAN() []string {
+	moves := make([]string, 0, len(c.movesTracker))
+	for _, tracked := range c.movesTracker {
+		moves = append(moves, tracked.san)
+	}
+	return moves
+}
+
+// StartingFEN returns the FEN of the position the game started from
+func (c *Chess) StartingFEN() string {
+	if len(c.movesTracker) == 0 {
+		return c.GetFEN()
+	}
+
+	start := *c
+	start.restore(c.movesTracker[0].previous)
+	return start.GetFEN()
+}
@@ -0,0 +1,157 @@
+package engine
+
+// promotionTypes are the piece types a pawn can promote to
+var promotionTypes = []PieceType{Queen, Rook, Bishop, Knight}
+
+// LegalMoves returns every legal move of the side to move, with a move for
+// each promotion choice
+func (c *Chess) LegalMoves() []Move {
+	var moves []Move
+
+	if c.IsGameOver() {
+		return moves
+	}
+
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			if determineColor(piece) != c.turn {
+				continue
+			}
+
+			moves = append(moves, c.legalMovesFrom(squareAt(y, x))...)
+		}
+	}
+
+	return moves
+}
+
+// LegalMovesFrom returns the legal moves of the piece on a square
+func (c *Chess) LegalMovesFrom(square Square) []Move {
+	if c.IsGameOver() || determineColor(c.PieceAt(square)) != c.turn {
+		return nil
+	}
+
+	return c.legalMovesFrom(square)
+}
+
+// LegalCaptures returns the legal moves that capture a piece
+func (c *Chess) LegalCaptures() []Move {
+	return filterMoves(c.LegalMoves(), Move.IsCapture)
+}
+
+// LegalChecks returns the legal moves that give check
+func (c *Chess) LegalChecks() []Move {
+	return filterMoves(c.LegalMoves(), c.givesCheck)
+}
+
+// LegalMovesOf returns the legal moves of the pieces of the given type
+func (c *Chess) LegalMovesOf(pieceType PieceType) []Move {
+	return filterMoves(c.LegalMoves(), func(m Move) bool {
+		return m.Piece.Type() == pieceType
+	})
+}
+
+// legalMovesFrom returns the legal moves of the piece on a square, without
+// checking whose turn it is
+func (c *Chess) legalMovesFrom(square Square) []Move {
+	var moves []Move
+
+	for _, to := range c.calculateValidMoves(square) {
+		move := c.newMove(square, to, NoPieceType)
+		if !move.IsPromotion() {
+			moves = append(moves, move)
+			continue
+		}
+
+		for _, promotion := range promotionTypes {
+			moves = append(moves, c.newMove(square, to, promotion))
+		}
+	}
+
+	return moves
+}
+
+// givesCheck checks if the move checks the enemy king
+func (c *Chess) givesCheck(m Move) bool {
+	board := c.boardTable
+	applyMoveToBoard(m, &board)
+
+	return c.checkIfChecked(determineEnemy(determineColor(m.Piece)), board)
+}
+
+// newMove describes the move of the piece on a square to another on the
+// current board, a pawn reaching the last rank promotes to a queen unless
+// another promotion is given
+func (c *Chess) newMove(from, to Square, promotion PieceType) Move {
+	piece := determinePieceWithCoords(from, c.boardTable)
+	move := Move{
+		From:      from,
+		To:        to,
+		Piece:     piece,
+		Captured:  determinePieceWithCoords(to, c.boardTable),
+		Promotion: NoPieceType,
+	}
+
+	switch piece.Type() {
+	case Pawn:
+		if passantVictim := determinePassantVictim(from, to, c.boardTable); passantVictim != NoSquare {
+			move.Captured = determinePieceWithCoords(passantVictim, c.boardTable)
+			move.Flags |= FlagEnPassant
+		}
+
+		if to.Rank() == 0 || to.Rank() == 7 {
+			move.Promotion = promotion
+			if promotion == NoPieceType || promotion == 0 {
+				move.Promotion = Queen
+			}
+			move.Flags |= FlagPromotion
+		}
+
+	case King:
+		if to.File()-from.File() == 2 {
+			move.Flags |= FlagKingSideCastle
+		} else if to.File()-from.File() == -2 {
+			move.Flags |= FlagQueenSideCastle
+		}
+	}
+
+	if move.Captured != NoPiece {
+		move.Flags |= FlagCapture
+	}
+
+	return move
+}
+
+// applyMoveToBoard moves the piece of a move in board, removing the pawn
+// captured en passant, moving the castled rook and promoting pawns
+func applyMoveToBoard(m Move, board *Board) {
+	if passantVictim := determinePassantVictim(m.From, m.To, *board); passantVictim != NoSquare {
+		board[passantVictim.row()][passantVictim.col()] = NoPiece
+	}
+
+	movePiece(m.From, m.To, board)
+
+	kingRow := m.From.row()
+	if m.Flags&FlagKingSideCastle != 0 {
+		movePiece(squareAt(kingRow, 7), squareAt(kingRow, 5), board)
+	} else if m.Flags&FlagQueenSideCastle != 0 {
+		movePiece(squareAt(kingRow, 0), squareAt(kingRow, 3), board)
+	}
+
+	if m.IsPromotion() {
+		board[m.To.row()][m.To.col()] = NewPiece(determineColor(m.Piece), m.Promotion)
+	}
+}
+
+// filterMoves returns the moves that satisfy keep
+func filterMoves(moves []Move, keep func(Move) bool) []Move {
+	var filtered []Move
+
+	for _, move := range moves {
+		if keep(move) {
+			filtered = append(filtered, move)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,386 @@
+package engine
+
+import "strings"
+
+// Outcome is the result of a game
+type Outcome uint8
+
+const (
+	NoOutcome Outcome = iota
+	WhiteWon
+	BlackWon
+	Draw
+)
+
+// String returns the outcome as written in PGN
+func (o Outcome) String() string {
+	switch o {
+	case WhiteWon:
+		return "1-0"
+	case BlackWon:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+// Winner returns the winning color, NoColor for draws and unfinished games
+func (o Outcome) Winner() Color {
+	switch o {
+	case WhiteWon:
+		return White
+	case BlackWon:
+		return Black
+	}
+	return NoColor
+}
+
+// WinFor returns the outcome of a game won by a color
+func WinFor(color Color) Outcome {
+	if color == White {
+		return WhiteWon
+	}
+	return BlackWon
+}
+
+// Method is the way a game ended
+type Method uint8
+
+const (
+	NoMethod Method = iota
+	Checkmate
+	Stalemate
+	InsufficientMaterial
+	FiftyMoveRule
+	ThreefoldRepetition
+	Timeout
+	TimeoutVsInsufficientMaterial
+	Resignation
+	DrawAgreement
+	Abandonment
+	FivefoldRepetition
+	SeventyFiveMoveRule
+)
+
+// String returns the name of the method
+func (m Method) String() string {
+	switch m {
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case FiftyMoveRule:
+		return "fifty move rule"
+	case ThreefoldRepetition:
+		return "threefold repetition"
+	case Timeout:
+		return "timeout"
+	case TimeoutVsInsufficientMaterial:
+		return "timeout vs insufficient material"
+	case Resignation:
+		return "resignation"
+	case DrawAgreement:
+		return "agreement"
+	case Abandonment:
+		return "abandonment"
+	case FivefoldRepetition:
+		return "fivefold repetition"
+	case SeventyFiveMoveRule:
+		return "seventy-five move rule"
+	}
+	return "none"
+}
+
+// MoveResult describes a move that has been played
+type MoveResult struct {
+	Move Move
+	SAN  string
+	UCI  string
+
+	Captured Piece
+	Check    bool
+
+	GameOver bool
+	Outcome  Outcome
+	Method   Method
+}
+
+// trackedMove is a played move with what is needed to look back at it
+type trackedMove struct {
+	move     Move
+	san      string
+	position string
+	previous snapshot
+}
+
+// Outcome returns the outcome of the game, NoOutcome while it is in progress
+func (c *Chess) Outcome() Outcome {
+	return c.outcome
+}
+
+// Method returns how the game ended, NoMethod while it is in progress
+func (c *Chess) Method() Method {
+	return c.method
+}
+
+// IsGameOver reports whether the game has ended
+func (c *Chess) IsGameOver() bool {
+	return c.outcome != NoOutcome
+}
+
+// InCheck reports whether the side to move is in check
+func (c *Chess) InCheck() bool {
+	return c.checkIfChecked(c.turn, c.boardTable)
+}
+
+// Timeout ends the game with the color running out of time. The opponent
+// wins unless they can not possibly checkmate, then the game is drawn.
+func (c *Chess) Timeout(color Color) error {
+	if c.canMate(color.Other()) {
+		return c.end(WinFor(color.Other()), Timeout)
+	}
+	return c.end(Draw, TimeoutVsInsufficientMaterial)
+}
+
+// Resign ends the game with the resignation of a color
+func (c *Chess) Resign(color Color) error {
+	return c.end(WinFor(color.Other()), Resignation)
+}
+
+// AgreeDraw ends the game with a draw agreed by the players
+func (c *Chess) AgreeDraw() error {
+	return c.end(Draw, DrawAgreement)
+}
+
+// CanClaimDraw reports whether a draw may be claimed, by the fifty move rule
+// or the threefold repetition
+func (c *Chess) CanClaimDraw() bool {
+	return c.claimableDraw() != NoMethod
+}
+
+// ClaimDraw ends the game with a draw by the fifty move rule or the
+// threefold repetition, ErrNoDrawClaim when the position allows neither
+func (c *Chess) ClaimDraw() error {
+	if c.IsGameOver() {
+		return ErrGameOver
+	}
+	method := c.claimableDraw()
+	if method == NoMethod {
+		return ErrNoDrawClaim
+	}
+	return c.end(Draw, method)
+}
+
+// claimableDraw returns the draw that may be claimed, NoMethod when none
+func (c *Chess) claimableDraw() Method {
+	switch {
+	case c.IsGameOver():
+		return NoMethod
+	case c.countRepetitions() >= 3:
+		return ThreefoldRepetition
+	case c.halfmoves >= 100:
+		return FiftyMoveRule
+	}
+	return NoMethod
+}
+
+// Abandon ends the game with a color leaving it
+func (c *Chess) Abandon(color Color) error {
+	return c.end(WinFor(color.Other()), Abandonment)
+}
+
+// end ends a game in progress
+func (c *Chess) end(outcome Outcome, method Method) error {
+	if c.IsGameOver() {
+		return ErrGameOver
+	}
+
+	c.outcome = outcome
+	c.method = method
+	return nil
+}
+
+// canMate reports whether a color has the material to checkmate with some
+// series of legal moves: anything but a lone minor piece or bishops on
+// squares of one color, which only mate with the help of enemy pieces
+func (c *Chess) canMate(color Color) bool {
+	var minors, bishops []Square
+	enemyPieces := map[PieceType]int{}
+
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			if piece == NoPiece || piece.Type() == King {
+				continue
+			}
+			if piece.Color() != color {
+				enemyPieces[piece.Type()]++
+				continue
+			}
+
+			switch piece.Type() {
+			case Pawn, Rook, Queen:
+				return true
+			case Knight:
+				minors = append(minors, squareAt(y, x))
+			case Bishop:
+				minors = append(minors, squareAt(y, x))
+				bishops = append(bishops, squareAt(y, x))
+			}
+		}
+	}
+
+	switch {
+	case len(minors) == 0:
+		return false
+	case len(minors) == 1:
+		// A lone knight or bishop mates a king hemmed in by its own pieces
+		return len(enemyPieces) > 0
+	case len(bishops) < len(minors):
+		return true
+	}
+
+	// Bishops of one color need an enemy piece that is not a bishop of the
+	// same color to block the king
+	for _, bishop := range bishops {
+		if squareColor(bishop) != squareColor(bishops[0]) {
+			return true
+		}
+	}
+	for pieceType := range enemyPieces {
+		if pieceType != Bishop {
+			return true
+		}
+	}
+	return c.hasBishopOn(color.Other(), 1-squareColor(bishops[0]))
+}
+
+// hasBishopOn reports whether a color has a bishop on squares of a color,
+// 0 for dark squares and 1 for light squares
+func (c *Chess) hasBishopOn(color Color, squares int) bool {
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			if piece.Type() == Bishop && piece.Color() == color && squareColor(squareAt(y, x)) == squares {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// squareColor returns 0 for dark squares and 1 for light squares
+func squareColor(square Square) int {
+	return (square.File() + square.Rank()) % 2
+}
+
+// updateOutcome ends the game if the side to move has no way to continue it.
+// The fifty move rule and the threefold repetition are claimed, the game only
+// ends on its own after seventy-five moves or the fifth repetition.
+func (c *Chess) updateOutcome() {
+	switch {
+	case c.checkIfMate(c.turn):
+		if c.checkIfChecked(c.turn, c.boardTable) {
+			c.outcome = WinFor(determineEnemy(c.turn))
+			c.method = Checkmate
+		} else {
+			c.outcome = Draw
+			c.method = Stalemate
+		}
+	case c.checkIfInsufficientMaterial():
+		c.outcome = Draw
+		c.method = InsufficientMaterial
+	case c.halfmoves >= 150:
+		c.outcome = Draw
+		c.method = SeventyFiveMoveRule
+	case c.countRepetitions() >= 5:
+		c.outcome = Draw
+		c.method = FivefoldRepetition
+	}
+}
+
+// checkIfInsufficientMaterial checks if neither side can possibly mate
+func (c *Chess) checkIfInsufficientMaterial() bool {
+	var minors []Square
+	var bishops []Square
+
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			switch piece.Type() {
+			case Pawn, Rook, Queen:
+				return false
+			case Knight:
+				minors = append(minors, squareAt(y, x))
+			case Bishop:
+				minors = append(minors, squareAt(y, x))
+				bishops = append(bishops, squareAt(y, x))
+			}
+		}
+	}
+
+	if len(minors) <= 1 {
+		return true
+	}
+
+	// Only bishops on squares of the same color remain
+	if len(bishops) != len(minors) {
+		return false
+	}
+	for _, bishop := range bishops {
+		if (bishop.File()+bishop.Rank())%2 != (bishops[0].File()+bishops[0].Rank())%2 {
+			return false
+		}
+	}
+	return true
+}
+
+// positionKey returns the FEN fields that identify a position for
+// repetitions, the en passant square only when a pawn can take on it
+func (c *Chess) positionKey() string {
+	fields := strings.Split(c.GetFEN(), " ")
+	if !c.canTakeEnPassant() {
+		fields[3] = "-"
+	}
+	return strings.Join(fields[:4], " ")
+}
+
+// canTakeEnPassant reports whether a pawn of the side to move can legally
+// take the pawn that just moved two squares
+func (c *Chess) canTakeEnPassant() bool {
+	if c.pawnPassant == NoSquare {
+		return false
+	}
+
+	// The pawns that can take stand beside the pawn that moved
+	rank := c.pawnPassant.Rank() - 1
+	if c.turn == Black {
+		rank = c.pawnPassant.Rank() + 1
+	}
+	pawn := NewPiece(c.turn, Pawn)
+	for _, file := range []int{c.pawnPassant.File() - 1, c.pawnPassant.File() + 1} {
+		if file < 0 || file > 7 || c.PieceAt(NewSquare(file, rank)) != pawn {
+			continue
+		}
+		validMoves := c.calculateValidMoves(NewSquare(file, rank))
+		if checkIfMovesContains(&validMoves, c.pawnPassant) {
+			return true
+		}
+	}
+	return false
+}
+
+// countRepetitions counts how many times the current position has occurred
+func (c *Chess) countRepetitions() int {
+	current := c.positionKey()
+	count := 1
+
+	// Positions before the last capture or pawn move can not repeat
+	for i := len(c.movesTracker) - 1; i >= 0 && i >= len(c.movesTracker)-c.halfmoves; i-- {
+		if c.movesTracker[i].position == current {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,104 @@
+package engine
+
+import "unicode"
+
+// sanOf returns the Standard Algebraic Notation of a valid move of the side
+// to move, without the check or checkmate suffix
+func (c *Chess) sanOf(m Move) string {
+	from, to := m.From, m.To
+	piece := m.Piece
+	pieceType := piece.Type()
+	isCapture := m.IsCapture()
+
+	// Castle
+	if pieceType == King && to.File()-from.File() == 2 {
+		return "O-O"
+	}
+	if pieceType == King && to.File()-from.File() == -2 {
+		return "O-O-O"
+	}
+
+	var san string
+	if pieceType == Pawn {
+		if isCapture {
+			san = string(rune('a' + from.File()))
+		}
+	} else {
+		san = string(unicode.ToUpper(rune(pieceType))) + c.disambiguate(piece, from, to)
+	}
+
+	if isCapture {
+		san += "x"
+	}
+	san += to.String()
+
+	if m.IsPromotion() {
+		san += "=" + string(unicode.ToUpper(rune(m.Promotion)))
+	}
+
+	return san
+}
+
+// disambiguate returns the file, rank or square needed to tell the moving
+// piece apart from the other pieces of the same kind that can reach the square
+func (c *Chess) disambiguate(piece Piece, from, to Square) string {
+	var sameFile, sameRank, ambiguous bool
+
+	for y, row := range c.boardTable {
+		for x, other := range row {
+			otherSquare := squareAt(y, x)
+			if other != piece || otherSquare == from {
+				continue
+			}
+
+			validMoves := c.calculateValidMoves(otherSquare)
+			if !checkIfMovesContains(&validMoves, to) {
+				continue
+			}
+
+			ambiguous = true
+			if otherSquare.File() == from.File() {
+				sameFile = true
+			}
+			if otherSquare.Rank() == from.Rank() {
+				sameRank = true
+			}
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(rune('a' + from.File()))
+	case !sameRank:
+		return string(rune('1' + from.Rank()))
+	}
+	return from.String()
+}
+
+// SAN returns the Standard Algebraic Notation of a legal move of the side to
+// move, with its check or checkmate suffix. The game is left as it is.
+func (c *Chess) SAN(m Move) (string, error) {
+	move, err := c.checkMove(m)
+	if err != nil {
+		return "", err
+	}
+
+	san := c.sanOf(move)
+	board := c.boardTable
+	applyMoveToBoard(move, &board)
+	enemy := determineEnemy(c.turn)
+	if !c.checkIfChecked(enemy, board) {
+		return san, nil
+	}
+
+	// The mate is looked for on a copy of the position after the move
+	after := *c
+	after.movesTracker = nil
+	after.makeMove(move)
+	if after.checkIfMate(enemy) {
+		return san + "#", nil
+	}
+	return san + "+", nil
+}
@@ -0,0 +1,344 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	// MateScore is the score of a checkmate, less the plies needed to deliver it
+	MateScore = 100000
+	// maxSearchDepth bounds iterative deepening when no depth limit is given
+	maxSearchDepth = 64
+	// defaultMovesToGo is how many moves the remaining time is split into
+	// when the time control has no move count
+	defaultMovesToGo = 30
+)
+
+// SearchLimits bounds a search, zero values mean no limit
+type SearchLimits struct {
+	Depth    int
+	Nodes    int
+	MoveTime time.Duration
+}
+
+// AllocateTime splits the remaining time of a player into the time for its
+// next move, movesToGo is 0 when the time control has no move count
+func AllocateTime(remaining, increment time.Duration, movesToGo int) time.Duration {
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+
+	moveTime := remaining/time.Duration(movesToGo) + increment*3/4
+	if moveTime > remaining/2 {
+		moveTime = remaining / 2
+	}
+	return moveTime
+}
+
+// SearchInfo reports a completed iteration of a search
+type SearchInfo struct {
+	Depth int
+	// Score is in centipawns from the point of view of the side to move
+	Score int
+	// Mate is the number of moves to mate, negative when being mated
+	Mate  int
+	Nodes int
+	Time  time.Duration
+	PV    []Move
+}
+
+// NPS returns the nodes searched per second
+func (i SearchInfo) NPS() int {
+	if i.Time <= 0 {
+		return 0
+	}
+	return int(float64(i.Nodes) / i.Time.Seconds())
+}
+
+// SearchResult is the outcome of a search
+type SearchResult struct {
+	BestMove Move
+	Info     SearchInfo
+}
+
+// searcher holds the state of a running search
+type searcher struct {
+	chess *Chess
+	ctx   context.Context
+
+	limits   SearchLimits
+	start    time.Time
+	deadline time.Time
+
+	nodes   int
+	stopped bool
+
+	// pv holds the best line found at each ply in the last iteration
+	pv [][]Move
+	// positions are the keys of the positions played before the one
+	// searched, in the game and in the search, to find repetitions
+	positions []string
+}
+
+// newSearcher returns a searcher of a copy of the game that knows the
+// positions played in it
+func newSearcher(c *Chess, ctx context.Context, limits SearchLimits) *searcher {
+	position := *c
+	position.movesTracker = nil
+
+	s := &searcher{
+		chess:  &position,
+		ctx:    ctx,
+		limits: limits,
+		start:  time.Now(),
+	}
+	if limits.MoveTime > 0 {
+		s.deadline = s.start.Add(limits.MoveTime)
+	}
+	for _, tracked := range c.movesTracker {
+		s.positions = append(s.positions, tracked.position)
+	}
+	return s
+}
+
+// repeated reports whether a position was played before since the last
+// capture or pawn move, the search takes a repetition for a draw
+func (s *searcher) repeated(key string) bool {
+	for i := len(s.positions) - 1; i >= 0 && i >= len(s.positions)-s.chess.halfmoves; i-- {
+		if s.positions[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Search looks for the best move of the side to move with an iterative
+// deepening alpha-beta search. report is called after each completed depth,
+// the search stops at the limits or when ctx is done, whichever comes first.
+// The game itself is left untouched.
+func (c *Chess) Search(ctx context.Context, limits SearchLimits, report func(SearchInfo)) SearchResult {
+	s := newSearcher(c, ctx, limits)
+
+	moves := c.LegalMoves()
+	if len(moves) == 0 {
+		return SearchResult{}
+	}
+
+	result := SearchResult{BestMove: moves[0]}
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 {
+		maxDepth = maxSearchDepth
+	}
+
+	var previousPV []Move
+	for depth := 1; depth <= maxDepth; depth++ {
+		s.pv = make([][]Move, depth+1)
+		score := s.negamax(depth, 0, -MateScore-1, MateScore+1, previousPV)
+
+		// A stopped iteration is incomplete, keep the last complete one
+		if s.stopped && depth > 1 {
+			break
+		}
+		if len(s.pv[0]) == 0 {
+			break
+		}
+
+		previousPV = append([]Move(nil), s.pv[0]...)
+		result.BestMove = previousPV[0]
+		result.Info = SearchInfo{
+			Depth: depth,
+			Score: score,
+			Mate:  mateIn(score),
+			Nodes: s.nodes,
+			Time:  time.Since(s.start),
+			PV:    previousPV,
+		}
+		if report != nil {
+			report(result.Info)
+		}
+
+		// Stop once a forced mate is found or there is a single legal move
+		if s.stopped || result.Info.Mate != 0 || len(moves) == 1 {
+			break
+		}
+	}
+
+	return result
+}
+
+// mateIn converts a score into moves to mate, or 0 if it is not a mate score
+func mateIn(score int) int {
+	if score > MateScore-maxSearchDepth*2 {
+		return (MateScore - score + 1) / 2
+	}
+	if score < -MateScore+maxSearchDepth*2 {
+		return -(MateScore + score + 1) / 2
+	}
+	return 0
+}
+
+// checkLimits stops the search once it has run out of time or nodes
+func (s *searcher) checkLimits() {
+	if s.stopped || s.nodes&1023 != 0 {
+		return
+	}
+
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.stopped = true
+	}
+	if s.limits.Nodes > 0 && s.nodes >= s.limits.Nodes {
+		s.stopped = true
+	}
+}
+
+// negamax searches the position to depth and returns its score for the side
+// to move, the best line is stored in s.pv[ply]
+func (s *searcher) negamax(depth, ply int, alpha, beta int, previousPV []Move) int {
+	s.nodes++
+	s.checkLimits()
+	if s.stopped && ply > 0 {
+		return 0
+	}
+
+	c := s.chess
+	s.pv[ply] = s.pv[ply][:0]
+
+	key := c.positionKey()
+	if ply > 0 && (c.halfmoves >= 100 || c.checkIfInsufficientMaterial() || s.repeated(key)) {
+		return 0
+	}
+
+	if depth == 0 {
+		return s.quiescence(ply, alpha, beta)
+	}
+
+	moves := c.LegalMoves()
+	if len(moves) == 0 {
+		if c.checkIfChecked(c.turn, c.boardTable) {
+			return -MateScore + ply
+		}
+		return 0
+	}
+
+	var pvMove *Move
+	if ply < len(previousPV) {
+		pvMove = &previousPV[ply]
+	}
+	orderMoves(moves, pvMove)
+
+	s.positions = append(s.positions, key)
+	defer func() { s.positions = s.positions[:len(s.positions)-1] }()
+
+	for i, move := range moves {
+		saved := c.snapshot()
+		c.makeMove(move)
+
+		// Only the first move continues the line of the last iteration
+		var childPV []Move
+		if i == 0 && pvMove != nil && move == *pvMove {
+			childPV = previousPV
+		}
+		score := -s.negamax(depth-1, ply+1, -beta, -alpha, childPV)
+		c.restore(saved)
+
+		if s.stopped {
+			if ply > 0 {
+				return 0
+			}
+			break
+		}
+
+		if score > alpha {
+			alpha = score
+			s.pv[ply] = append(append(s.pv[ply][:0], move), s.pv[ply+1]...)
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return alpha
+}
+
+// quiescence searches captures until the position is quiet, so the static
+// evaluation is not taken in the middle of an exchange. A side in check can
+// not stand pat, all its evasions are searched.
+func (s *searcher) quiescence(ply, alpha, beta int) int {
+	s.nodes++
+	s.checkLimits()
+	if s.stopped {
+		return 0
+	}
+
+	c := s.chess
+	inCheck := ply < maxSearchDepth*2 && c.checkIfChecked(c.turn, c.boardTable)
+
+	moves := c.LegalMoves()
+	if inCheck {
+		if len(moves) == 0 {
+			return -MateScore + ply
+		}
+	} else {
+		standPat := c.Evaluate()
+		if standPat >= beta {
+			return beta
+		}
+		if standPat > alpha {
+			alpha = standPat
+		}
+
+		moves = filterMoves(moves, func(m Move) bool {
+			return m.IsCapture() || (m.IsPromotion() && m.Promotion == Queen)
+		})
+	}
+	orderMoves(moves, nil)
+
+	for _, move := range moves {
+		saved := c.snapshot()
+		c.makeMove(move)
+		score := -s.quiescence(ply+1, -beta, -alpha)
+		c.restore(saved)
+
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+// orderMoves sorts moves so the most promising are searched first: the move
+// of the previous best line, then promotions and captures of valuable pieces
+// by cheap ones
+func orderMoves(moves []Move, pvMove *Move) {
+	priority := func(m Move) int {
+		if pvMove != nil && m == *pvMove {
+			return 1 << 20
+		}
+
+		score := 0
+		if m.IsPromotion() {
+			score += pieceValues[m.Promotion] * 10
+		}
+		if m.IsCapture() {
+			score += pieceValues[m.Captured.Type()]*10 - pieceValues[m.Piece.Type()]/10
+		}
+		return score
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return priority(moves[i]) > priority(moves[j])
+	})
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Strength weakens the search so that people can beat it
+type Strength struct {
+	Limits SearchLimits
+	// Noise is the largest error in centipawns added at random to the score
+	// of each move, the moves are then searched one by one to the depth of
+	// the limits, 2 when it has none
+	Noise int
+	// Blunder is the chance from 0 to 1 to play a random move instead
+	Blunder float64
+}
+
+// Levels are the strengths of the levels from the weakest, level 1, to the
+// strongest
+var Levels = []Strength{
+	{Limits: SearchLimits{Depth: 1}, Noise: 400, Blunder: 0.2},
+	{Limits: SearchLimits{Depth: 1}, Noise: 250, Blunder: 0.1},
+	{Limits: SearchLimits{Depth: 2}, Noise: 150, Blunder: 0.05},
+	{Limits: SearchLimits{Depth: 2}, Noise: 80, Blunder: 0.02},
+	{Limits: SearchLimits{Depth: 3}, Noise: 40},
+	{Limits: SearchLimits{Depth: 3}, Noise: 15},
+	{Limits: SearchLimits{Depth: 4}},
+	{Limits: SearchLimits{Depth: 5, MoveTime: 2 * time.Second}},
+	{Limits: SearchLimits{MoveTime: 5 * time.Second}},
+	{Limits: SearchLimits{MoveTime: 10 * time.Second}},
+}
+
+// Level returns the strength of a level from 1 to len(Levels)
+func Level(level int) (Strength, error) {
+	if level < 1 || level > len(Levels) {
+		return Strength{}, fmt.Errorf("the level must be between 1 and %d", len(Levels))
+	}
+	return Levels[level-1], nil
+}
+
+// SearchStrength looks for a move of the side to move like Search, but
+// plays as badly as the strength asks for. rnd makes the errors, report is
+// called with the score of the move played when the strength has noise.
+func (c *Chess) SearchStrength(ctx context.Context, strength Strength, rnd *rand.Rand, report func(SearchInfo)) SearchResult {
+	moves := c.LegalMoves()
+	if len(moves) == 0 {
+		return SearchResult{}
+	}
+
+	if strength.Blunder > 0 && rnd.Float64() < strength.Blunder {
+		move := moves[rnd.Intn(len(moves))]
+		return SearchResult{BestMove: move, Info: SearchInfo{PV: []Move{move}}}
+	}
+	if strength.Noise <= 0 {
+		return c.Search(ctx, strength.Limits, report)
+	}
+
+	s := newSearcher(c, ctx, strength.Limits)
+	position := s.chess
+	// The moves are searched from the next ply, after the root position
+	s.positions = append(s.positions, position.positionKey())
+	depth := strength.Limits.Depth
+	if depth <= 0 {
+		depth = 2
+	}
+
+	// Every move is searched with a full window to know its real score
+	var result SearchResult
+	best := 0
+	for i, move := range moves {
+		saved := position.snapshot()
+		position.makeMove(move)
+		s.pv = make([][]Move, depth+1)
+		score := -s.negamax(depth-1, 1, -MateScore-1, MateScore+1, nil)
+		position.restore(saved)
+		if s.stopped && i > 0 {
+			break
+		}
+
+		noisy := score + rnd.Intn(2*strength.Noise+1) - strength.Noise
+		if i == 0 || noisy > best {
+			best = noisy
+			result.BestMove = move
+			result.Info = SearchInfo{
+				Depth: depth,
+				Score: score,
+				Mate:  mateIn(score),
+				PV:    append([]Move{move}, s.pv[1]...),
+			}
+		}
+	}
+
+	result.Info.Nodes = s.nodes
+	result.Info.Time = time.Since(s.start)
+	if report != nil {
+		report(result.Info)
+	}
+	return result
+}
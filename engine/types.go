@@ -1,37 +1,373 @@
 package engine
 
-type Board [8][8]rune
+import (
+	"strings"
+	"unicode"
+)
 
-type CastleAvailability struct {
-	WhiteKing  bool
-	WhiteQueen bool
-	BlackKing  bool
-	BlackQueen bool
+// Color is the color of a side or of a piece
+type Color rune
+
+const (
+	White   Color = 'w'
+	Black   Color = 'b'
+	NoColor Color = '-'
+)
+
+// String returns the FEN letter of the color
+func (c Color) String() string {
+	return string(c)
+}
+
+// Name returns the name of the color, "white", "black" or "none"
+func (c Color) Name() string {
+	switch c {
+	case White:
+		return "white"
+	case Black:
+		return "black"
+	}
+	return "none"
+}
+
+// Title returns the name of the color capitalized, "White", "Black" or "None"
+func (c Color) Title() string {
+	name := c.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Other returns the opposing color
+func (c Color) Other() Color {
+	switch c {
+	case White:
+		return Black
+	case Black:
+		return White
+	}
+	return NoColor
+}
+
+// ParseColor parses the FEN letter of a color ("w" or "b")
+func ParseColor(s string) (Color, error) {
+	switch s {
+	case "w":
+		return White, nil
+	case "b":
+		return Black, nil
+	}
+	return NoColor, &ParseError{kind: "color", input: s}
+}
+
+// PieceType is the kind of piece regardless of its color
+type PieceType rune
+
+const (
+	NoPieceType PieceType = '-'
+	Pawn        PieceType = 'p'
+	Knight      PieceType = 'n'
+	Bishop      PieceType = 'b'
+	Rook        PieceType = 'r'
+	Queen       PieceType = 'q'
+	King        PieceType = 'k'
+)
+
+// PieceTypes lists every piece type from the least to the most valuable
+var PieceTypes = []PieceType{Pawn, Knight, Bishop, Rook, Queen, King}
+
+// String returns the lowercase letter of the piece type
+func (t PieceType) String() string {
+	return string(t)
+}
+
+// ParsePieceType parses a piece letter of either case into its type
+func ParsePieceType(s string) (PieceType, error) {
+	if len(s) == 1 {
+		t := PieceType(unicode.ToLower(rune(s[0])))
+		for _, pieceType := range PieceTypes {
+			if t == pieceType {
+				return t, nil
+			}
+		}
+	}
+	return NoPieceType, &ParseError{kind: "piece type", input: s}
+}
+
+// Piece is a colored piece written as its FEN letter, uppercase for white
+type Piece rune
+
+const (
+	NoPiece Piece = '-'
+
+	WhitePawn   Piece = 'P'
+	WhiteKnight Piece = 'N'
+	WhiteBishop Piece = 'B'
+	WhiteRook   Piece = 'R'
+	WhiteQueen  Piece = 'Q'
+	WhiteKing   Piece = 'K'
+
+	BlackPawn   Piece = 'p'
+	BlackKnight Piece = 'n'
+	BlackBishop Piece = 'b'
+	BlackRook   Piece = 'r'
+	BlackQueen  Piece = 'q'
+	BlackKing   Piece = 'k'
+)
+
+// NewPiece returns the piece of the given color and type
+func NewPiece(color Color, t PieceType) Piece {
+	if t == NoPieceType || color == NoColor {
+		return NoPiece
+	}
+	return determineColorPiece(color, rune(t))
+}
+
+// Color returns the color of the piece
+func (p Piece) Color() Color {
+	return determineColor(p)
+}
+
+// Type returns the type of the piece
+func (p Piece) Type() PieceType {
+	if p == NoPiece {
+		return NoPieceType
+	}
+	return PieceType(unicode.ToLower(rune(p)))
+}
+
+// String returns the FEN letter of the piece
+func (p Piece) String() string {
+	return string(p)
+}
+
+// ParsePiece parses the FEN letter of a piece
+func ParsePiece(s string) (Piece, error) {
+	t, err := ParsePieceType(s)
+	if err != nil {
+		return NoPiece, &ParseError{kind: "piece", input: s}
+	}
+	if unicode.IsUpper(rune(s[0])) {
+		return NewPiece(White, t), nil
+	}
+	return NewPiece(Black, t), nil
+}
+
+// Square is a square of the board, from A1 (0) to H8 (63)
+type Square int8
+
+const NoSquare Square = -1
+
+const (
+	A1 Square = iota
+	B1
+	C1
+	D1
+	E1
+	F1
+	G1
+	H1
+	A2
+	B2
+	C2
+	D2
+	E2
+	F2
+	G2
+	H2
+	A3
+	B3
+	C3
+	D3
+	E3
+	F3
+	G3
+	H3
+	A4
+	B4
+	C4
+	D4
+	E4
+	F4
+	G4
+	H4
+	A5
+	B5
+	C5
+	D5
+	E5
+	F5
+	G5
+	H5
+	A6
+	B6
+	C6
+	D6
+	E6
+	F6
+	G6
+	H6
+	A7
+	B7
+	C7
+	D7
+	E7
+	F7
+	G7
+	H7
+	A8
+	B8
+	C8
+	D8
+	E8
+	F8
+	G8
+	H8
+)
+
+// NewSquare returns the square on the zero based file (a = 0) and rank (1 = 0)
+func NewSquare(file, rank int) Square {
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return NoSquare
+	}
+	return Square(rank*8 + file)
+}
+
+// File returns the zero based file of the square (a = 0)
+func (s Square) File() int {
+	return int(s) % 8
+}
+
+// Rank returns the zero based rank of the square (1 = 0)
+func (s Square) Rank() int {
+	return int(s) / 8
+}
+
+// IsValid reports whether the square is on the board
+func (s Square) IsValid() bool {
+	return s >= A1 && s <= H8
+}
+
+// String returns the chessboard notation of the square, or "-" for NoSquare
+func (s Square) String() string {
+	if !s.IsValid() {
+		return "-"
+	}
+	return translateCoordsToCB(s)
 }
 
-type Coords struct {
-	row int
-	col int
+// ParseSquare parses a square in chessboard notation ("e4")
+func ParseSquare(s string) (Square, error) {
+	square := translateCBtoCoords(s)
+	if square == NoSquare {
+		return NoSquare, &ParseError{kind: "square", input: s}
+	}
+	return square, nil
 }
 
+// row returns the index of the square's rank in the Board, rank 8 being 0
+func (s Square) row() int {
+	return 7 - s.Rank()
+}
+
+// col returns the index of the square's file in the Board
+func (s Square) col() int {
+	return s.File()
+}
+
+// MoveFlag describes the special properties of a move
+type MoveFlag uint8
+
+const (
+	FlagCapture MoveFlag = 1 << iota
+	FlagEnPassant
+	FlagKingSideCastle
+	FlagQueenSideCastle
+	FlagPromotion
+)
+
+// Move is a move of a piece from one square to another
 type Move struct {
-	From *Coords
-	To   *Coords
+	From Square
+	To   Square
+
+	Piece     Piece
+	Captured  Piece
+	Promotion PieceType
 
-	Captured rune
+	Flags MoveFlag
 }
 
-type Turn [2]*Move
+// IsCapture reports whether the move captures a piece
+func (m Move) IsCapture() bool {
+	return m.Flags&FlagCapture != 0
+}
+
+// IsEnPassant reports whether the move is an en passant capture
+func (m Move) IsEnPassant() bool {
+	return m.Flags&FlagEnPassant != 0
+}
+
+// IsCastle reports whether the move is a castle on either side
+func (m Move) IsCastle() bool {
+	return m.Flags&(FlagKingSideCastle|FlagQueenSideCastle) != 0
+}
+
+// IsPromotion reports whether the move promotes a pawn
+func (m Move) IsPromotion() bool {
+	return m.Flags&FlagPromotion != 0
+}
+
+// String returns the move in UCI notation ("e2e4", "e7e8q")
+func (m Move) String() string {
+	s := m.From.String() + m.To.String()
+	if m.Promotion != NoPieceType && m.Promotion != 0 {
+		s += m.Promotion.String()
+	}
+	return s
+}
+
+// ParseMove parses a move in UCI notation, only From, To and Promotion are set
+func ParseMove(s string) (Move, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, &ParseError{kind: "move", input: s}
+	}
+
+	from := translateCBtoCoords(s[0:2])
+	to := translateCBtoCoords(s[2:4])
+	if from == NoSquare || to == NoSquare {
+		return Move{}, &ParseError{kind: "move", input: s}
+	}
+
+	move := Move{From: from, To: to, Promotion: NoPieceType}
+	if len(s) == 5 {
+		promotion, err := ParsePieceType(strings.ToLower(s[4:]))
+		if err != nil || promotion == Pawn || promotion == King {
+			return Move{}, &ParseError{kind: "move", input: s}
+		}
+		move.Promotion = promotion
+	}
+
+	return move, nil
+}
+
+type Board [8][8]Piece
+
+type CastleAvailability struct {
+	WhiteKing  bool
+	WhiteQueen bool
+	BlackKing  bool
+	BlackQueen bool
+}
 
 type Chess struct {
 	boardTable  Board
-	turn        rune
+	turn        Color
 	castle      CastleAvailability
-	pawnPassant string
+	pawnPassant Square
 	halfmoves   int
 	fullmoves   int
 
-	winner rune
+	outcome Outcome
+	method  Method
 
-	movesTracker []Turn
+	movesTracker []trackedMove
 }
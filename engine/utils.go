@@ -17,99 +17,131 @@ var (
 	}
 )
 
-// translateCBtoCoords translates chessboard notation to coordinates
-func translateCBtoCoords(cb string) *Coords {
+// translateCBtoCoords translates chessboard notation to a square
+func translateCBtoCoords(cb string) Square {
+	if len(cb) != 2 {
+		return NoSquare
+	}
+
 	column := rune(cb[0])
 	// Check if column is within range
-	if column > 104 || column < 97 {
-		return nil
+	if column > 'h' || column < 'a' {
+		return NoSquare
 	}
 
 	row := rune(cb[1])
 	// Check if row is within range
-	if row > 56 || row < 49 {
-		return nil
+	if row > '8' || row < '1' {
+		return NoSquare
 	}
 
-	return &Coords{int('8' - row), int(column - 'a')}
+	return NewSquare(int(column-'a'), int(row-'1'))
 }
 
 // determineColor returns the color of the entered piece
-func determineColor(piece rune) rune {
-	if piece == '-' {
-		return piece
+func determineColor(piece Piece) Color {
+	if piece == NoPiece {
+		return NoColor
 	}
 
-	if piece == unicode.ToUpper(piece) {
-		return 'w'
+	if unicode.IsUpper(rune(piece)) {
+		return White
 	} else {
-		return 'b'
+		return Black
 	}
 }
 
-// determinePieceWithCoords determines the piece on the board with the coordinates
-func determinePieceWithCoords(coord *Coords, board Board) rune {
-	if checkIfCoordsIsOutOfBounds(coord) {
-		return '-'
+// determinePieceWithCoords determines the piece on the board with the square
+func determinePieceWithCoords(square Square, board Board) Piece {
+	if checkIfCoordsIsOutOfBounds(square) {
+		return NoPiece
 	}
 
-	return board[coord.row][coord.col]
+	return board[square.row()][square.col()]
 }
 
 // checkIfCoordsIsOutOfBounds
-func checkIfCoordsIsOutOfBounds(coord *Coords) bool {
-	return coord.row > 7 || coord.row < 0 || coord.col > 7 || coord.col < 0
+func checkIfCoordsIsOutOfBounds(square Square) bool {
+	return !square.IsValid()
 }
 
 // checkIfThereIsPieceInCoords
-func checkIfThereIsPieceInCoords(coord *Coords, board Board) bool {
-	return determinePieceWithCoords(coord, board) != '-'
+func checkIfThereIsPieceInCoords(square Square, board Board) bool {
+	return determinePieceWithCoords(square, board) != NoPiece
 }
 
 // checkIfAllyInCoords
-func checkIfAllyInCoords(coord *Coords, color rune, board Board) bool {
-	colorInCoord := determineColor(determinePieceWithCoords(coord, board))
+func checkIfAllyInCoords(square Square, color Color, board Board) bool {
+	colorInCoord := determineColor(determinePieceWithCoords(square, board))
 
 	return colorInCoord == color
 }
 
-// determineColorPiece returns the enemy equivalent of piece
-func determineColorPiece(color, piece rune) rune {
-	if color == 'w' {
-		return unicode.ToUpper(piece)
+// determineColorPiece returns the piece letter in the given color
+func determineColorPiece(color Color, piece rune) Piece {
+	if color == White {
+		return Piece(unicode.ToUpper(piece))
 	} else {
-		return unicode.ToLower(piece)
+		return Piece(unicode.ToLower(piece))
 	}
 }
 
 // determineEnemyVersion returns the enemy version of the piece
-func determineEnemyVersion(piece rune) rune {
-	if unicode.IsUpper(piece) {
-		return unicode.ToLower(piece)
+func determineEnemyVersion(piece Piece) Piece {
+	if unicode.IsUpper(rune(piece)) {
+		return Piece(unicode.ToLower(rune(piece)))
 	} else {
-		return unicode.ToUpper(piece)
+		return Piece(unicode.ToUpper(rune(piece)))
 	}
 }
 
 // movePiece moves piece in board
-func movePiece(from *Coords, to *Coords, board *Board) {
-	board[to.row][to.col] = board[from.row][from.col]
-	board[from.row][from.col] = '-'
+func movePiece(from Square, to Square, board *Board) {
+	board[to.row()][to.col()] = board[from.row()][from.col()]
+	board[from.row()][from.col()] = NoPiece
+}
+
+// determinePassantVictim returns the square of the pawn captured en passant
+// by the move, or NoSquare if the move is not an en passant capture
+func determinePassantVictim(from Square, to Square, board Board) Square {
+	piece := determinePieceWithCoords(from, board)
+	if unicode.ToLower(rune(piece)) != 'p' || from.col() == to.col() || checkIfThereIsPieceInCoords(to, board) {
+		return NoSquare
+	}
+
+	return squareAt(from.row(), to.col())
+}
+
+// offsetSquare returns the square moved by the given rows and columns of the
+// board, or NoSquare if it falls outside the board
+func offsetSquare(square Square, rowOffset, colOffset int) Square {
+	row := square.row() + rowOffset
+	col := square.col() + colOffset
+	if row > 7 || row < 0 || col > 7 || col < 0 {
+		return NoSquare
+	}
+
+	return squareAt(row, col)
+}
+
+// squareAt returns the square in the given row and column of the board
+func squareAt(row, col int) Square {
+	return NewSquare(col, 7-row)
 }
 
-// translateCoordsToCB translates coordinates to chessboard notation
-func translateCoordsToCB(coord *Coords) string {
-	if coord.row < 0 || coord.row > 7 || coord.col < 0 || coord.col > 7 {
+// translateCoordsToCB translates a square to chessboard notation
+func translateCoordsToCB(square Square) string {
+	if checkIfCoordsIsOutOfBounds(square) {
 		return ""
 	}
 
-	return string('a'+rune(coord.col)) + string('8'-rune(coord.row))
+	return string('a'+rune(square.File())) + string('1'+rune(square.Rank()))
 }
 
 // checkIfMovesContains checks if moves contains move
-func checkIfMovesContains(moves *[]*Coords, move *Coords) bool {
+func checkIfMovesContains(moves *[]Square, move Square) bool {
 	for _, m := range *moves {
-		if *m == *move {
+		if m == move {
 			return true
 		}
 	}
@@ -118,17 +150,17 @@ func checkIfMovesContains(moves *[]*Coords, move *Coords) bool {
 }
 
 // determineEnemy determines the enemy color
-func determineEnemy(color rune) rune {
-	if color == 'w' {
-		return 'b'
+func determineEnemy(color Color) Color {
+	if color == White {
+		return Black
 	}
-	return 'w'
+	return White
 }
 
 // determineKingRow determines the row of the king
-func determineKingRow(color rune) int {
+func determineKingRow(color Color) int {
 	kingRow := 0
-	if color == 'w' {
+	if color == White {
 		kingRow = 7
 	}
 	return kingRow
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValidationMode tells NewChessGameWithFen how strictly to check a position
+type ValidationMode uint8
+
+const (
+	// Lenient accepts any FEN that can be decoded
+	Lenient ValidationMode = iota
+	// Strict also rejects positions that can not occur in a game
+	Strict
+)
+
+// Fields of a position reported by a ValidationError
+const (
+	FieldBoard     = "board"
+	FieldTurn      = "turn"
+	FieldCastling  = "castling"
+	FieldEnPassant = "en passant"
+	FieldHalfmoves = "halfmoves"
+	FieldFullmoves = "fullmoves"
+)
+
+// ValidationError describes a problem in a position, Square is NoSquare when
+// the problem is not about a single square
+type ValidationError struct {
+	Field  string
+	Square Square
+	Reason string
+}
+
+func (v *ValidationError) Error() string {
+	msg := "Invalid position: " + v.Field + ": " + v.Reason
+	if v.Square != NoSquare {
+		msg += " on " + v.Square.String()
+	}
+	return msg
+}
+
+// ValidationErrors is every problem found in a position
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	var msgs []string
+	for _, err := range v {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (v ValidationErrors) Unwrap() []error {
+	var errs []error
+	for _, err := range v {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ValidateFEN decodes a FEN and validates the position it describes
+func ValidateFEN(fen string) error {
+	chess, err := NewChessGameWithFen(fen)
+	if err != nil {
+		return err
+	}
+	return chess.Validate()
+}
+
+// Validate checks if the position can occur in a game and returns every
+// problem found as ValidationErrors, or nil if there is none
+func (c *Chess) Validate() error {
+	var errs ValidationErrors
+
+	report := func(field string, square Square, reason string) {
+		errs = append(errs, &ValidationError{Field: field, Square: square, Reason: reason})
+	}
+
+	// Board
+	kings := map[Color]int{}
+	pawns := map[Color]int{}
+	pieces := map[Color]int{}
+	for y, row := range c.boardTable {
+		for x, piece := range row {
+			square := squareAt(y, x)
+			if piece == NoPiece {
+				continue
+			}
+
+			if _, err := ParsePiece(piece.String()); err != nil {
+				report(FieldBoard, square, "unknown piece "+piece.String())
+				continue
+			}
+
+			color := determineColor(piece)
+			pieces[color]++
+			switch piece.Type() {
+			case King:
+				kings[color]++
+			case Pawn:
+				pawns[color]++
+				if square.Rank() == 0 || square.Rank() == 7 {
+					report(FieldBoard, square, "pawn on the back rank")
+				}
+			}
+		}
+	}
+
+	for _, color := range []Color{White, Black} {
+		name := color.Name()
+		if kings[color] != 1 {
+			report(FieldBoard, NoSquare, "expected one "+name+" king, found "+strconv.Itoa(kings[color]))
+		}
+		if pawns[color] > 8 {
+			report(FieldBoard, NoSquare, "more than 8 "+name+" pawns")
+		}
+		if pieces[color] > 16 {
+			report(FieldBoard, NoSquare, "more than 16 "+name+" pieces")
+		}
+	}
+
+	// Turn
+	if kings[White] == 1 && kings[Black] == 1 && c.checkIfChecked(determineEnemy(c.turn), c.boardTable) {
+		report(FieldTurn, NoSquare, determineEnemy(c.turn).Name()+" is in check but it is not their turn")
+	}
+
+	// Castle
+	castles := []struct {
+		available bool
+		name      string
+		king      Square
+		rook      Square
+		color     Color
+	}{
+		{c.castle.WhiteKing, "K", E1, H1, White},
+		{c.castle.WhiteQueen, "Q", E1, A1, White},
+		{c.castle.BlackKing, "k", E8, H8, Black},
+		{c.castle.BlackQueen, "q", E8, A8, Black},
+	}
+	for _, castle := range castles {
+		if !castle.available {
+			continue
+		}
+		if c.PieceAt(castle.king) != NewPiece(castle.color, King) {
+			report(FieldCastling, castle.king, castle.name+" needs the "+castle.color.Name()+" king")
+		}
+		if c.PieceAt(castle.rook) != NewPiece(castle.color, Rook) {
+			report(FieldCastling, castle.rook, castle.name+" needs the "+castle.color.Name()+" rook")
+		}
+	}
+
+	// Pawn Passant
+	if c.pawnPassant != NoSquare && (c.turn == White || c.turn == Black) {
+		// The square behind a pawn of the side not to move that just moved twice
+		passantRank, direction := 5, -1
+		if c.turn == Black {
+			passantRank, direction = 2, 1
+		}
+
+		pawn := offsetSquare(c.pawnPassant, direction*-1, 0)
+		origin := offsetSquare(c.pawnPassant, direction, 0)
+		switch {
+		case c.pawnPassant.Rank() != passantRank:
+			report(FieldEnPassant, c.pawnPassant, "square is not on rank "+strconv.Itoa(passantRank+1))
+		case checkIfThereIsPieceInCoords(c.pawnPassant, c.boardTable) ||
+			checkIfThereIsPieceInCoords(origin, c.boardTable):
+			report(FieldEnPassant, c.pawnPassant, "the pawn could not have moved through an occupied square")
+		case c.PieceAt(pawn) != NewPiece(determineEnemy(c.turn), Pawn):
+			report(FieldEnPassant, c.pawnPassant, "no pawn that just moved twice")
+		}
+	}
+
+	// Half Moves and Full Moves
+	if c.halfmoves < 0 {
+		report(FieldHalfmoves, NoSquare, "must not be negative")
+	}
+	if c.fullmoves < 1 {
+		report(FieldFullmoves, NoSquare, "must be at least 1")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -1,37 +1,81 @@
 package main
 
 import (
-	"chess-go/engine"
+	"chess-go/uci"
+	"chess-go/xboard"
 	"fmt"
-	"os/exec"
+	"os"
 )
 
 func main() {
-	chess := engine.NewGameChess()
+	// Speak CECP to XBoard and WinBoard
+	if len(os.Args) > 1 && os.Args[1] == "xboard" {
+		if err := xboard.Run(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	//var from, to string
-	var pgn string
+	// Speak UCI to interfaces and match runners
+	if len(os.Args) > 1 && os.Args[1] == "uci" {
+		if err := uci.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	for {
-		chess.PrintBoard()
+	// Play a match between two engines
+	if len(os.Args) > 1 && os.Args[1] == "match" {
+		os.Exit(runMatch(os.Args[2:]))
+	}
 
-		fmt.Printf("\nMake a move (%s): ", string(chess.Turn()))
-		_, err := fmt.Scan(&pgn)
-		if err != nil {
-			panic(err)
-		}
+	// Test a candidate engine against a base engine
+	if len(os.Args) > 1 && os.Args[1] == "sprt" {
+		os.Exit(runSPRT(os.Args[2:]))
+	}
 
-		//_, err1 := chess.Move(from, to)
-		_, err1 := chess.MovePGN(pgn)
-		if err1 != nil {
-			println(err1.Error(), "\ntry again")
-			var scan string
-			_, err1 = fmt.Scan(&scan)
-			if err1 != nil {
-				panic(err1)
-			}
-		}
+	// Run a round-robin or Swiss tournament
+	if len(os.Args) > 1 && os.Args[1] == "tournament" {
+		os.Exit(runTournament(os.Args[2:]))
+	}
+
+	// Rate the players of PGN databases
+	if len(os.Args) > 1 && os.Args[1] == "ratings" {
+		os.Exit(runRatings(os.Args[2:]))
+	}
 
-		exec.Command("clear")
+	// Tag the games of PGN databases with their openings
+	if len(os.Args) > 1 && os.Args[1] == "eco" {
+		os.Exit(runECO(os.Args[2:]))
 	}
+
+	// Annotate the games of PGN databases with their mistakes
+	if len(os.Args) > 1 && os.Args[1] == "annotate" {
+		os.Exit(runAnnotate(os.Args[2:]))
+	}
+
+	// Report the accuracy of the players of PGN databases
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit(runStats(os.Args[2:]))
+	}
+
+	// Solve a mate in N moves
+	if len(os.Args) > 1 && os.Args[1] == "solve" {
+		os.Exit(runSolve(os.Args[2:]))
+	}
+
+	// Find training puzzles in the games of PGN databases
+	if len(os.Args) > 1 && os.Args[1] == "puzzles" {
+		os.Exit(runPuzzles(os.Args[2:]))
+	}
+
+	// Serve games over HTTP
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+
+	// Play in the terminal
+	os.Exit(runTUI(os.Args[1:]))
 }
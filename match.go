@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"chess-go/match"
+)
+
+// engineFlags collects the engines given with -engine
+type engineFlags []match.EngineConfig
+
+func (e *engineFlags) String() string {
+	return fmt.Sprint(len(*e), " engines")
+}
+
+func (e *engineFlags) Set(value string) error {
+	config, err := match.ParseEngineConfig(value)
+	if err != nil {
+		return err
+	}
+	*e = append(*e, config)
+	return nil
+}
+
+// matchFlags are the flags of the games shared by the match and sprt commands
+type matchFlags struct {
+	openings    *string
+	plies       *int
+	tc          *string
+	concurrency *int
+	output      *string
+	event       *string
+	adjudicator match.Adjudicator
+	config      match.Config
+}
+
+// addMatchFlags defines the flags of the games on a flag set
+func addMatchFlags(flags *flag.FlagSet, concurrency int, event string) *matchFlags {
+	m := &matchFlags{}
+	m.openings = flags.String("openings", "", "opening suite, a PGN file or a FEN per line")
+	m.plies = flags.Int("plies", 0, "moves taken from each game of a PGN opening suite, 0 for all")
+	m.tc = flags.String("tc", "inf", `time control "moves/base+increment" in seconds, "st=seconds" per move or "inf"`)
+	flags.DurationVar(&m.config.TimeMargin, "margin", 0, "how late a move may be before losing on time")
+	m.concurrency = flags.Int("concurrency", concurrency, "games played at the same time")
+	m.output = flags.String("pgn", "", "file the games are appended to in PGN")
+	m.event = flags.String("event", event, "event name of the games")
+	flags.IntVar(&m.adjudicator.ResignMoves, "resign-moves", 0, "moves both engines must agree a side is lost before adjudicating it")
+	flags.IntVar(&m.adjudicator.ResignScore, "resign-score", 0, "score in centipawns of a lost side")
+	flags.IntVar(&m.adjudicator.DrawMoveNumber, "draw-number", 0, "first move a draw may be adjudicated")
+	flags.IntVar(&m.adjudicator.DrawMoves, "draw-moves", 0, "moves both engines must agree the game is drawn before adjudicating it")
+	flags.IntVar(&m.adjudicator.DrawScore, "draw-score", 0, "largest score in centipawns of a drawn game")
+	flags.IntVar(&m.adjudicator.MaxMoves, "max-moves", 0, "moves after which a game is drawn, 0 for no limit")
+	return m
+}
+
+// matchConfig returns the config of a match between two engines from the
+// parsed flags, and the file the games are written to which must be closed
+func (m *matchFlags) matchConfig(engines [2]match.EngineConfig, games int) (match.Config, io.WriteCloser, error) {
+	config := m.config
+	config.Engines = engines
+	config.Games = games
+	config.Concurrency = *m.concurrency
+	config.Event = *m.event
+	config.Adjudicator = m.adjudicator
+
+	for i := range config.Engines {
+		if config.Engines[i].Name == "" && config.Engines[i].Command == "" {
+			config.Engines[i].Name = fmt.Sprintf("chess-go %d", i+1)
+		}
+	}
+
+	var err error
+	if config.TimeControl, err = match.ParseTimeControl(*m.tc); err != nil {
+		return config, nil, err
+	}
+	if *m.openings != "" {
+		if config.Openings, err = match.LoadOpenings(*m.openings, *m.plies); err != nil {
+			return config, nil, err
+		}
+	}
+
+	var out io.WriteCloser = nopCloser{io.Discard}
+	if *m.output != "" {
+		if out, err = os.OpenFile(*m.output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return config, nil, err
+		}
+	}
+	return config, out, nil
+}
+
+// nopCloser is a writer with nothing to close
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// runMatch plays a match between two engines and returns the exit code
+func runMatch(args []string) int {
+	flags := flag.NewFlagSet("match", flag.ExitOnError)
+	var engines engineFlags
+	flags.Var(&engines, "engine", `engine settings "name=N cmd=PATH arg=A depth=D option.NAME=VALUE", given twice, without cmd for the built-in engine`)
+	games := flags.Int("games", 2, "number of games")
+	gameFlags := addMatchFlags(flags, 1, "Engine match")
+	flags.Parse(args)
+
+	if len(engines) != 2 {
+		fmt.Fprintln(os.Stderr, "match: give two engines with -engine")
+		return 2
+	}
+
+	config, out, err := gameFlags.matchConfig([2]match.EngineConfig{engines[0], engines[1]}, *games)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer out.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var names [2]string
+	score, err := match.Run(ctx, config, func(result match.GameResult) {
+		names = reportGame(result, out)
+	})
+
+	if score.Games() > 0 {
+		diff, errorBar := score.Elo()
+		fmt.Printf("Score of %s vs %s: %s\n", names[0], names[1], score)
+		fmt.Printf("Elo difference: %.1f +/- %.1f\n", diff, errorBar)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, strings.TrimPrefix(err.Error(), "match: "))
+		return 1
+	}
+	return 0
+}
+
+// reportGame prints the result of a game and writes it to out, it returns
+// the names of the first and second engines
+func reportGame(result match.GameResult, out io.Writer) [2]string {
+	white, black := result.Game.Tag("White"), result.Game.Tag("Black")
+
+	fmt.Printf("Finished game %d (%s vs %s): %s {%s}\n", result.Round, white, black, result.Outcome, result.Reason)
+	if _, err := result.Game.WriteTo(out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if result.FirstIsWhite {
+		return [2]string{white, black}
+	}
+	return [2]string{black, white}
+}
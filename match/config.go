@@ -0,0 +1,179 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EngineConfig describes how to start one of the engines of a match
+type EngineConfig struct {
+	Name string
+	// Command is the path of a UCI engine, the built-in engine is used when
+	// it is empty
+	Command string
+	Args    []string
+	// Options are the UCI options set when the engine starts
+	Options map[string]string
+	// Depth bounds every search of the engine when it is not 0
+	Depth int
+
+	// New creates the player instead when it is set
+	New func(ctx context.Context) (Player, error)
+}
+
+// Start starts a player of the engine
+func (e EngineConfig) Start(ctx context.Context) (Player, error) {
+	switch {
+	case e.New != nil:
+		return e.New(ctx)
+	case e.Command != "":
+		return StartUCI(ctx, e.Name, e.Command, e.Args, e.Options, e.Depth)
+	}
+
+	name := e.Name
+	if name == "" {
+		name = "chess-go"
+	}
+	return NewBuiltin(name, e.Depth), nil
+}
+
+// ParseEngineConfig parses an engine description made of space separated
+// key=value pairs: name, cmd, arg (repeatable), depth and option.<Name>
+func ParseEngineConfig(s string) (EngineConfig, error) {
+	var config EngineConfig
+
+	for _, field := range strings.Fields(s) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return config, fmt.Errorf("match: invalid engine setting %q", field)
+		}
+
+		switch {
+		case key == "name":
+			config.Name = value
+		case key == "cmd":
+			config.Command = value
+		case key == "arg":
+			config.Args = append(config.Args, value)
+		case key == "depth":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 0 {
+				return config, fmt.Errorf("match: invalid depth %q", value)
+			}
+			config.Depth = depth
+		case strings.HasPrefix(key, "option."):
+			if config.Options == nil {
+				config.Options = map[string]string{}
+			}
+			config.Options[strings.TrimPrefix(key, "option.")] = value
+		default:
+			return config, fmt.Errorf("match: unknown engine setting %q", key)
+		}
+	}
+
+	return config, nil
+}
+
+// TimeControl is the time the players have to make their moves, the zero
+// value lets them think without a clock
+type TimeControl struct {
+	// Moves is the number of moves of each period, 0 when Base is for the
+	// whole game
+	Moves     int
+	Base      time.Duration
+	Increment time.Duration
+	// MoveTime is a fixed time for each move, instead of a clock
+	MoveTime time.Duration
+}
+
+// ParseTimeControl parses a time control written as "moves/base+increment"
+// in seconds, where the move count and increment are optional and the base
+// may be "minutes:seconds", "st=seconds" for a fixed time per move, or "inf"
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	invalid := fmt.Errorf("match: invalid time control %q", s)
+
+	if s == "" || s == "inf" {
+		return tc, nil
+	}
+
+	if moveTime, ok := strings.CutPrefix(s, "st="); ok {
+		d, err := parseSeconds(moveTime)
+		if err != nil || d <= 0 {
+			return tc, invalid
+		}
+		tc.MoveTime = d
+		return tc, nil
+	}
+
+	if moves, rest, ok := strings.Cut(s, "/"); ok {
+		n, err := strconv.Atoi(moves)
+		if err != nil || n <= 0 {
+			return tc, invalid
+		}
+		tc.Moves = n
+		s = rest
+	}
+
+	base, increment, _ := strings.Cut(s, "+")
+	minutes, seconds, ok := strings.Cut(base, ":")
+	if ok {
+		m, err := strconv.Atoi(minutes)
+		if err != nil || m < 0 {
+			return tc, invalid
+		}
+		tc.Base = time.Duration(m) * time.Minute
+		base = seconds
+	}
+
+	d, err := parseSeconds(base)
+	if err != nil {
+		return tc, invalid
+	}
+	tc.Base += d
+
+	if increment != "" {
+		if tc.Increment, err = parseSeconds(increment); err != nil {
+			return tc, invalid
+		}
+	}
+
+	if tc.Base <= 0 {
+		return tc, invalid
+	}
+	return tc, nil
+}
+
+// String returns the time control as the value of a PGN TimeControl tag,
+// "-" when there is no clock
+func (tc TimeControl) String() string {
+	if tc.Base <= 0 {
+		return "-"
+	}
+
+	s := formatSeconds(tc.Base)
+	if tc.Moves > 0 {
+		s = strconv.Itoa(tc.Moves) + "/" + s
+	}
+	if tc.Increment > 0 {
+		s += "+" + formatSeconds(tc.Increment)
+	}
+	return s
+}
+
+// parseSeconds parses a number of seconds, which may have a fraction
+func parseSeconds(s string) (time.Duration, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("match: invalid seconds %q", s)
+	}
+	return time.Duration(n * float64(time.Second)), nil
+}
+
+// formatSeconds writes a duration in seconds without trailing zeros
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,80 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Score counts the games of a match from the point of view of its first
+// engine
+type Score struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Add counts a game with the points of the first engine: 1, 0.5 or 0
+func (s *Score) Add(points float64) {
+	switch points {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+// Games returns the number of games played
+func (s Score) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Points returns the points of the first engine
+func (s Score) Points() float64 {
+	return float64(s.Wins) + float64(s.Draws)/2
+}
+
+// Ratio returns the share of the points won by the first engine
+func (s Score) Ratio() float64 {
+	if s.Games() == 0 {
+		return 0
+	}
+	return s.Points() / float64(s.Games())
+}
+
+// Elo returns the Elo difference between the engines, and the margin of its
+// 95% confidence interval. The difference is infinite when an engine won all
+// the points, the margin is NaN before any game.
+func (s Score) Elo() (diff, margin float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, math.NaN()
+	}
+
+	p := s.Ratio()
+	variance := (float64(s.Wins)*math.Pow(1-p, 2) +
+		float64(s.Draws)*math.Pow(0.5-p, 2) +
+		float64(s.Losses)*math.Pow(p, 2)) / n
+	deviation := math.Sqrt(variance / n)
+
+	low := EloFromScore(p - 1.959964*deviation)
+	high := EloFromScore(p + 1.959964*deviation)
+	return EloFromScore(p), (high - low) / 2
+}
+
+// String returns the score as "wins - losses - draws [ratio] games"
+func (s Score) String() string {
+	return fmt.Sprintf("%d - %d - %d [%.3f] %d", s.Wins, s.Losses, s.Draws, s.Ratio(), s.Games())
+}
+
+// EloFromScore returns the Elo difference expected for a share of the points
+func EloFromScore(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(score/(1-score))
+}
@@ -0,0 +1,523 @@
+// Package match plays games between two engines, built-in or external UCI
+// processes, and keeps the score
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+	"chess-go/uci"
+)
+
+// Termination is why a game ended, as written in the PGN Termination tag
+type Termination string
+
+const (
+	// Normal games end by the rules of chess
+	Normal          Termination = "normal"
+	TimeForfeit     Termination = "time forfeit"
+	Adjudication    Termination = "adjudication"
+	RulesInfraction Termination = "rules infraction"
+	// Abandoned games end when a player fails to answer
+	Abandoned Termination = "abandoned"
+)
+
+// Adjudicator ends games early, zero values turn its rules off
+type Adjudicator struct {
+	// ResignMoves and ResignScore give the win to a side once both players
+	// scored it ahead by at least ResignScore centipawns for ResignMoves moves
+	// in a row
+	ResignMoves int
+	ResignScore int
+
+	// DrawMoveNumber, DrawMoves and DrawScore draw a game from move
+	// DrawMoveNumber once both players scored it within DrawScore centipawns
+	// for DrawMoves moves in a row
+	DrawMoveNumber int
+	DrawMoves      int
+	DrawScore      int
+
+	// MaxMoves draws a game after that many moves
+	MaxMoves int
+
+	// Tablebase returns the result of positions it knows, no tablebase comes
+	// with this package
+	Tablebase func(chess *engine.Chess) (engine.Outcome, bool)
+}
+
+// Config is the setup of a match
+type Config struct {
+	Engines [2]EngineConfig
+	// Games is the number of games, played in pairs from the same opening
+	// with the colors swapped
+	Games int
+	// Openings are used in turn by the pairs of games, the standard position
+	// when there are none
+	Openings    []Opening
+	TimeControl TimeControl
+	// TimeMargin is how late a player may move before losing on time
+	TimeMargin  time.Duration
+	Adjudicator Adjudicator
+	// Concurrency is the number of games played at the same time
+	Concurrency int
+	Event       string
+}
+
+// GameResult is a finished game of a match
+type GameResult struct {
+	// Round is the number of the game, from 1
+	Round int
+	// Pair is the number of the pair of games from the same opening, from 1
+	Pair int
+	// FirstIsWhite tells whether the first engine had the white pieces
+	FirstIsWhite bool
+
+	Outcome     engine.Outcome
+	Termination Termination
+	// Reason tells how the game ended ("White mates")
+	Reason string
+	Game   *pgn.Game
+}
+
+// Points returns the points of the first engine in the game
+func (r GameResult) Points() float64 {
+	switch r.Outcome.Winner() {
+	case engine.White:
+		if r.FirstIsWhite {
+			return 1
+		}
+		return 0
+	case engine.Black:
+		if r.FirstIsWhite {
+			return 0
+		}
+		return 1
+	}
+	return 0.5
+}
+
+// Run plays the games of a match and returns the score of the first engine.
+// report is called after each game in the order the games finish. When ctx
+// is done the games in progress are abandoned and not counted.
+func Run(ctx context.Context, config Config, report func(GameResult)) (Score, error) {
+	var score Score
+
+	if config.Games <= 0 {
+		return score, errors.New("match: no games to play")
+	}
+	if len(config.Openings) == 0 {
+		config.Openings = []Opening{{FEN: engine.DefaultFen}}
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.Concurrency > config.Games {
+		config.Concurrency = config.Games
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rounds := make(chan int)
+	go func() {
+		defer close(rounds)
+		for round := 1; round <= config.Games; round++ {
+			select {
+			case rounds <- round:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := playRounds(ctx, config, rounds, func(result GameResult) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				score.Add(result.Points())
+				if report != nil {
+					report(result)
+				}
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return score, firstErr
+}
+
+// playRounds plays the rounds it receives with its own players, which are
+// restarted after a failure
+func playRounds(ctx context.Context, config Config, rounds <-chan int, report func(GameResult)) error {
+	var players [2]Player
+	defer func() {
+		for _, player := range players {
+			if player != nil {
+				player.Close()
+			}
+		}
+	}()
+
+	for round := range rounds {
+		for i := range players {
+			if players[i] != nil {
+				continue
+			}
+			player, err := config.Engines[i].Start(ctx)
+			if err != nil {
+				return fmt.Errorf("match: starting engine %d: %w", i+1, err)
+			}
+			players[i] = player
+		}
+
+		pair := (round + 1) / 2
+		opening := config.Openings[(pair-1)%len(config.Openings)]
+		result := GameResult{Round: round, Pair: pair, FirstIsWhite: round%2 == 1}
+
+		white, black := players[0], players[1]
+		if !result.FirstIsWhite {
+			white, black = black, white
+		}
+
+		result, failed, err := playGame(ctx, config, white, black, opening, result)
+		if err != nil {
+			return err
+		}
+		report(result)
+
+		if failed != engine.NoColor {
+			// The first engine played the white pieces in odd rounds
+			i := 0
+			if (failed == engine.White) != result.FirstIsWhite {
+				i = 1
+			}
+			players[i].Close()
+			players[i] = nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+// PlayGame plays a single game between two players from an opening, with
+// the time control and adjudication of config. The result counts white as
+// the first engine.
+func PlayGame(ctx context.Context, config Config, white, black Player, opening Opening) (GameResult, error) {
+	result := GameResult{Round: 1, Pair: 1, FirstIsWhite: true}
+	result, _, err := playGame(ctx, config, white, black, opening, result)
+	return result, err
+}
+
+// playGame plays a game of the match and fills result. failed is the color
+// of the player that stopped answering, if any; err is only set when the
+// game could not be played.
+func playGame(ctx context.Context, config Config, white, black Player, opening Opening, result GameResult) (GameResult, engine.Color, error) {
+	chess, err := opening.Position()
+	if err != nil {
+		return result, engine.NoColor, err
+	}
+	names := [2]string{white.Name(), black.Name()}
+
+	// end finishes the game, comments describe the moves of the players
+	var comments []string
+	end := func(outcome engine.Outcome, termination Termination, reason string) GameResult {
+		result.Termination = termination
+		result.Reason = reason
+		return finish(result, names, chess, outcome, comments, config)
+	}
+
+	for _, color := range []engine.Color{engine.White, engine.Black} {
+		player := white
+		if color == engine.Black {
+			player = black
+		}
+		if err := player.NewGame(ctx); err != nil {
+			if ctx.Err() != nil {
+				return result, engine.NoColor, ctx.Err()
+			}
+			return end(engine.WinFor(color.Other()), Abandoned, color.Title()+" fails to start the game"), color, nil
+		}
+	}
+
+	tc := config.TimeControl
+	clocks := map[engine.Color]time.Duration{engine.White: tc.Base, engine.Black: tc.Base}
+	played := map[engine.Color]int{}
+	var scores []*Reply
+
+	for !chess.IsGameOver() {
+		turn := chess.Turn()
+		player := white
+		if turn == engine.Black {
+			player = black
+		}
+
+		// The player is stopped once it runs out of time
+		limits := uci.GoParams{MoveTime: tc.MoveTime}
+		timeout := time.Duration(0)
+		switch {
+		case tc.Base > 0:
+			limits.WTime, limits.BTime = clocks[engine.White], clocks[engine.Black]
+			limits.WInc, limits.BInc = tc.Increment, tc.Increment
+			if tc.Moves > 0 {
+				limits.MovesToGo = tc.Moves - played[turn]%tc.Moves
+			}
+			timeout = clocks[turn] + config.TimeMargin
+		case tc.MoveTime > 0:
+			timeout = tc.MoveTime + config.TimeMargin
+		}
+
+		var moveCtx context.Context
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			moveCtx, cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			moveCtx, cancel = context.WithCancel(ctx)
+		}
+
+		start := time.Now()
+		reply, err := player.Play(moveCtx, chess, limits)
+		elapsed := time.Since(start)
+		cancel()
+
+		if ctx.Err() != nil {
+			return result, engine.NoColor, ctx.Err()
+		}
+
+		// A player over its time loses before its move is looked at
+		late := tc.MoveTime > 0 && elapsed > tc.MoveTime+config.TimeMargin
+		if tc.Base > 0 {
+			clocks[turn] -= elapsed
+			late = clocks[turn]+config.TimeMargin < 0
+		}
+		if late {
+			chess.Timeout(turn)
+			return end(chess.Outcome(), TimeForfeit, describe(chess)), engine.NoColor, nil
+		}
+
+		if err != nil {
+			if errors.Is(err, uci.ErrIllegalBestMove) {
+				return end(engine.WinFor(turn.Other()), RulesInfraction, turn.Title()+" makes an illegal move"), engine.NoColor, nil
+			}
+			return end(engine.WinFor(turn.Other()), Abandoned, turn.Title()+" stops answering"), turn, nil
+		}
+
+		if _, err := chess.Move(reply.Move); err != nil {
+			return end(engine.WinFor(turn.Other()), RulesInfraction, turn.Title()+" makes an illegal move"), engine.NoColor, nil
+		}
+
+		played[turn]++
+		if tc.Base > 0 {
+			clocks[turn] += tc.Increment
+			if tc.Moves > 0 && played[turn]%tc.Moves == 0 {
+				clocks[turn] += tc.Base
+			}
+		}
+
+		comments = append(comments, moveComment(reply, elapsed))
+		if reply.HasScore {
+			reply := reply
+			scores = append(scores, &reply)
+		} else {
+			scores = append(scores, nil)
+		}
+
+		// The arbiter claims the draws of the players
+		if chess.CanClaimDraw() {
+			chess.ClaimDraw()
+			continue
+		}
+
+		if outcome, reason, ok := config.Adjudicator.adjudicate(chess, scores); ok {
+			return end(outcome, Adjudication, reason), engine.NoColor, nil
+		}
+	}
+
+	return end(chess.Outcome(), Normal, describe(chess)), engine.NoColor, nil
+}
+
+// finish sets the outcome of a game and writes it to PGN, names are those of
+// the white and black players
+func finish(result GameResult, names [2]string, chess *engine.Chess, outcome engine.Outcome, comments []string, config Config) GameResult {
+	result.Outcome = outcome
+
+	game := pgn.NewGame(chess)
+	game.SetResult(outcome)
+	if config.Event != "" {
+		game.SetTag("Event", config.Event)
+	}
+	game.SetTag("Round", strconv.Itoa(result.Round))
+	game.SetTag("White", names[0])
+	game.SetTag("Black", names[1])
+	game.SetTag("TimeControl", config.TimeControl.String())
+	game.SetTag("PlyCount", strconv.Itoa(len(game.Moves)))
+	game.SetTag("Termination", string(result.Termination))
+
+	// The comments are those of the moves played by the players, after the
+	// moves of the opening
+	offset := len(game.Moves) - len(comments)
+	for i, comment := range comments {
+		game.Moves[offset+i].Comment = comment
+	}
+	if len(game.Moves) > 0 {
+		last := &game.Moves[len(game.Moves)-1]
+		if last.Comment != "" {
+			last.Comment += ", "
+		}
+		last.Comment += result.Reason
+	} else {
+		game.Comment = result.Reason
+	}
+
+	result.Game = game
+	return result
+}
+
+// adjudicate returns the outcome of a game that does not need to be played
+// to the end. scores are the replies of the players, nil when a reply had no
+// score.
+func (a Adjudicator) adjudicate(chess *engine.Chess, scores []*Reply) (engine.Outcome, string, bool) {
+	if chess.IsGameOver() {
+		return engine.NoOutcome, "", false
+	}
+
+	if a.Tablebase != nil {
+		if outcome, ok := a.Tablebase(chess); ok {
+			return outcome, "tablebase " + outcomeReason(outcome), true
+		}
+	}
+
+	if a.MaxMoves > 0 && len(chess.History()) >= a.MaxMoves*2 {
+		return engine.Draw, "draw by move limit", true
+	}
+
+	// whiteScores returns the last scores from the point of view of white,
+	// false when one of them is missing
+	whiteScores := func(moves int) ([]int, bool) {
+		if moves <= 0 || len(scores) < moves*2 {
+			return nil, false
+		}
+
+		var last []int
+		turn := chess.Turn()
+		for i := len(scores) - 1; i >= len(scores)-moves*2; i-- {
+			turn = turn.Other()
+			reply := scores[i]
+			if reply == nil {
+				return nil, false
+			}
+
+			score := reply.Score
+			if reply.Mate > 0 {
+				score = engine.MateScore - reply.Mate
+			} else if reply.Mate < 0 {
+				score = -engine.MateScore - reply.Mate
+			}
+			if turn == engine.Black {
+				score = -score
+			}
+			last = append(last, score)
+		}
+		return last, true
+	}
+
+	if last, ok := whiteScores(a.ResignMoves); ok && a.ResignScore > 0 {
+		white, black := true, true
+		for _, score := range last {
+			white = white && score >= a.ResignScore
+			black = black && score <= -a.ResignScore
+		}
+		if white {
+			return engine.WhiteWon, "Black resigns", true
+		}
+		if black {
+			return engine.BlackWon, "White resigns", true
+		}
+	}
+
+	if last, ok := whiteScores(a.DrawMoves); ok && chess.FullMoves() >= a.DrawMoveNumber {
+		draw := true
+		for _, score := range last {
+			draw = draw && score <= a.DrawScore && score >= -a.DrawScore
+		}
+		if draw {
+			return engine.Draw, "draw by adjudication", true
+		}
+	}
+
+	return engine.NoOutcome, "", false
+}
+
+// moveComment describes the reply of a player as "+0.35/12 1.2s", or with a
+// mate score "+M3/12 1.2s"
+func moveComment(reply Reply, elapsed time.Duration) string {
+	score := ""
+	switch {
+	case !reply.HasScore:
+	case reply.Mate > 0:
+		score = fmt.Sprintf("+M%d/%d ", reply.Mate, reply.Depth)
+	case reply.Mate < 0:
+		score = fmt.Sprintf("-M%d/%d ", -reply.Mate, reply.Depth)
+	default:
+		score = fmt.Sprintf("%+.2f/%d ", float64(reply.Score)/100, reply.Depth)
+	}
+	return fmt.Sprintf("%s%.1fs", score, elapsed.Seconds())
+}
+
+// describe tells how a game ended by the rules
+func describe(chess *engine.Chess) string {
+	switch chess.Method() {
+	case engine.Checkmate:
+		return chess.Outcome().Winner().Title() + " mates"
+	case engine.Stalemate:
+		return "draw by stalemate"
+	case engine.InsufficientMaterial:
+		return "draw by insufficient material"
+	case engine.FiftyMoveRule:
+		return "draw by fifty move rule"
+	case engine.ThreefoldRepetition:
+		return "draw by threefold repetition"
+	case engine.SeventyFiveMoveRule:
+		return "draw by seventy-five move rule"
+	case engine.FivefoldRepetition:
+		return "draw by fivefold repetition"
+	case engine.Timeout:
+		return chess.Outcome().Winner().Other().Title() + " loses on time"
+	case engine.TimeoutVsInsufficientMaterial:
+		return "draw by timeout vs insufficient material"
+	}
+	return ""
+}
+
+// outcomeReason describes an outcome as "White wins" or "draw"
+func outcomeReason(outcome engine.Outcome) string {
+	if winner := outcome.Winner(); winner != engine.NoColor {
+		return winner.Title() + " wins"
+	}
+	return "draw"
+}
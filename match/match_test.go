@@ -0,0 +1,252 @@
+package match
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/uci"
+)
+
+// scriptedPlayer plays the first legal move with a fixed score, after
+// waiting delay, or an illegal move when asked to
+type scriptedPlayer struct {
+	name    string
+	score   int
+	delay   time.Duration
+	illegal bool
+}
+
+func (s *scriptedPlayer) Name() string                  { return s.name }
+func (s *scriptedPlayer) NewGame(context.Context) error { return nil }
+func (s *scriptedPlayer) Close() error                  { return nil }
+
+func (s *scriptedPlayer) Play(ctx context.Context, chess *engine.Chess, _ uci.GoParams) (Reply, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+	}
+
+	if s.illegal {
+		return Reply{Move: engine.Move{From: engine.E2, To: engine.E5}}, nil
+	}
+	return Reply{Move: chess.LegalMoves()[0], Score: s.score, HasScore: true, Depth: 1}, nil
+}
+
+// scripted returns the config of an engine made of a scripted player
+func scripted(player scriptedPlayer) EngineConfig {
+	return EngineConfig{New: func(context.Context) (Player, error) {
+		p := player
+		return &p, nil
+	}}
+}
+
+func TestMatch_Run(t *testing.T) {
+	config := Config{
+		Engines: [2]EngineConfig{
+			{Name: "first", Depth: 3},
+			{Name: "second", Depth: 3},
+		},
+		Games:       4,
+		Openings:    []Opening{{FEN: "7k/8/5K2/8/8/8/8/6Q1 w - - 0 1"}, {Moves: []string{"f3", "e5", "g4"}}},
+		Concurrency: 2,
+		Event:       "Test",
+	}
+
+	var results []GameResult
+	score, err := Run(context.Background(), config, func(result GameResult) {
+		results = append(results, result)
+	})
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	// White mates in both games of the first pair, and in the second pair
+	// black mates after f3 e5 g4
+	if score != (Score{Wins: 2, Losses: 2}) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", score, Score{Wins: 2, Losses: 2})
+	}
+	if len(results) != 4 {
+		t.Fatalf("FAILED\n\tgot:     %+v\n\texpected:%+v", len(results), 4)
+	}
+
+	for _, result := range results {
+		game := result.Game
+		white, black := "first", "second"
+		if !result.FirstIsWhite {
+			white, black = black, white
+		}
+
+		if result.Termination != Normal || game.Tag("White") != white || game.Tag("Black") != black ||
+			game.Tag("Event") != "Test" || game.Tag("Termination") != "normal" || game.Result != result.Outcome {
+			t.Errorf("FAILED: round %d\n\tgot:     %+v\n\texpected:%s vs %s", result.Round, game, white, black)
+		}
+
+		if _, err := game.Replay(); err != nil {
+			t.Errorf("FAILED: round %d\n\t%s", result.Round, err.Error())
+		}
+		if result.Pair == 2 && (len(game.Moves) < 2 || game.Moves[0].SAN != "f3" || game.Moves[0].Comment != "") {
+			t.Errorf("FAILED: round %d\n\tgot:     %+v\n\texpected:%s", result.Round, game.Moves, "the opening moves first")
+		}
+	}
+}
+func TestMatch_Terminations(t *testing.T) {
+	inputs := []Config{
+		{
+			Engines:     [2]EngineConfig{scripted(scriptedPlayer{delay: 200 * time.Millisecond}), scripted(scriptedPlayer{})},
+			TimeControl: TimeControl{MoveTime: 50 * time.Millisecond},
+			TimeMargin:  10 * time.Millisecond,
+		},
+		{
+			Engines:     [2]EngineConfig{scripted(scriptedPlayer{delay: 30 * time.Millisecond}), scripted(scriptedPlayer{})},
+			TimeControl: TimeControl{Base: 100 * time.Millisecond},
+		},
+		{
+			Engines: [2]EngineConfig{scripted(scriptedPlayer{illegal: true}), scripted(scriptedPlayer{})},
+		},
+		{
+			Engines:     [2]EngineConfig{scripted(scriptedPlayer{score: 600}), scripted(scriptedPlayer{score: -600})},
+			Adjudicator: Adjudicator{ResignMoves: 3, ResignScore: 500},
+		},
+		{
+			Engines:     [2]EngineConfig{scripted(scriptedPlayer{}), scripted(scriptedPlayer{score: 100})},
+			Adjudicator: Adjudicator{DrawMoveNumber: 5, DrawMoves: 2, DrawScore: 10, MaxMoves: 10},
+		},
+		{
+			Engines:     [2]EngineConfig{scripted(scriptedPlayer{score: 5}), scripted(scriptedPlayer{score: -5})},
+			Adjudicator: Adjudicator{DrawMoveNumber: 5, DrawMoves: 2, DrawScore: 10},
+		},
+		{
+			Engines: [2]EngineConfig{scripted(scriptedPlayer{}), scripted(scriptedPlayer{})},
+			Adjudicator: Adjudicator{Tablebase: func(chess *engine.Chess) (engine.Outcome, bool) {
+				return engine.BlackWon, len(chess.History()) == 4
+			}},
+		},
+	}
+
+	expectedOutputs := []GameResult{
+		{Outcome: engine.BlackWon, Termination: TimeForfeit, Reason: "White loses on time"},
+		{Outcome: engine.BlackWon, Termination: TimeForfeit, Reason: "White loses on time"},
+		{Outcome: engine.BlackWon, Termination: RulesInfraction, Reason: "White makes an illegal move"},
+		{Outcome: engine.WhiteWon, Termination: Adjudication, Reason: "Black resigns"},
+		{Outcome: engine.Draw, Termination: Adjudication, Reason: "draw by move limit"},
+		{Outcome: engine.Draw, Termination: Adjudication, Reason: "draw by adjudication"},
+		{Outcome: engine.BlackWon, Termination: Adjudication, Reason: "tablebase Black wins"},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		input.Games = 1
+
+		var output GameResult
+		if _, err := Run(context.Background(), input, func(result GameResult) {
+			output = result
+		}); err != nil {
+			t.Fatalf("FAILED: %d\n\t%s", i, err.Error())
+		}
+
+		if output.Outcome != expected.Outcome || output.Termination != expected.Termination || output.Reason != expected.Reason {
+			t.Errorf("FAILED: %d\n\tgot:     %+v\n\texpected:%+v", i, output, expected)
+		}
+	}
+}
+func TestMatch_ParseTimeControl(t *testing.T) {
+	inputs := []string{"inf", "10+0.1", "40/90:00+30", "2:30", "st=0.5", "x", "40/", "+1", "10+y"}
+
+	expectedOutputs := []TimeControl{
+		{},
+		{Base: 10 * time.Second, Increment: 100 * time.Millisecond},
+		{Moves: 40, Base: 90 * time.Minute, Increment: 30 * time.Second},
+		{Base: 150 * time.Second},
+		{MoveTime: 500 * time.Millisecond},
+		{}, {}, {}, {},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output, err := ParseTimeControl(input)
+
+		if output != expected && err == nil || (i >= 5) != (err != nil) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %v\n\texpected:%+v", input, output, err, expected)
+		}
+	}
+
+	tc := TimeControl{Moves: 40, Base: 90 * time.Minute, Increment: 30 * time.Second}
+	if tc.String() != "40/5400+30" {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", tc.String(), "40/5400+30")
+	}
+}
+func TestMatch_ParseEngineConfig(t *testing.T) {
+	output, err := ParseEngineConfig("name=Fish cmd=/usr/bin/fish arg=--uci depth=8 option.Hash=64 option.Threads=2")
+	expected := EngineConfig{
+		Name:    "Fish",
+		Command: "/usr/bin/fish",
+		Args:    []string{"--uci"},
+		Options: map[string]string{"Hash": "64", "Threads": "2"},
+		Depth:   8,
+	}
+	if err != nil || !reflect.DeepEqual(output, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", output, err, expected)
+	}
+
+	for _, input := range []string{"name", "depth=x", "color=white"} {
+		if _, err := ParseEngineConfig(input); err == nil {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, err, "an error")
+		}
+	}
+}
+func TestMatch_ReadOpenings(t *testing.T) {
+	fens := `# suite
+rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1
+
+4k3/8/8/8/8/8/4P3/4K3 w - - id "pawn";
+`
+	openings, err := ReadFENOpenings(strings.NewReader(fens))
+	expected := []Opening{
+		{FEN: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{FEN: "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"},
+	}
+	if err != nil || !reflect.DeepEqual(openings, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", openings, err, expected)
+	}
+
+	openings, err = ReadPGNOpenings(strings.NewReader("1. e4 e5 2. Nf3 *\n\n1. d4 *\n"), 2)
+	expected = []Opening{
+		{FEN: engine.DefaultFen, Moves: []string{"e4", "e5"}},
+		{FEN: engine.DefaultFen, Moves: []string{"d4"}},
+	}
+	if err != nil || !reflect.DeepEqual(openings, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", openings, err, expected)
+	}
+
+	if _, err := ReadFENOpenings(strings.NewReader("8/8/8/8/8/8/8/8 w - - 0 1")); err == nil {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, "an error")
+	}
+}
+func TestMatch_Elo(t *testing.T) {
+	inputs := []Score{
+		{Wins: 5, Draws: 10, Losses: 5},
+		{Wins: 60, Draws: 20, Losses: 20},
+		{Wins: 3},
+	}
+
+	expectedOutputs := [][2]float64{
+		{0, 111.33},
+		{147.19, 66.01},
+		{math.Inf(1), math.NaN()},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		diff, margin := input.Elo()
+
+		if math.Abs(diff-expected[0]) > 0.01 && diff != expected[0] ||
+			math.Abs(margin-expected[1]) > 0.01 && !math.IsNaN(expected[1]) {
+			t.Errorf("FAILED: %+v\n\tgot:     %.2f +/- %.2f\n\texpected:%.2f +/- %.2f", input, diff, margin, expected[0], expected[1])
+		}
+	}
+}
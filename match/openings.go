@@ -0,0 +1,118 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// Opening is a position the games of a match start from
+type Opening struct {
+	FEN string
+	// Moves are played from the FEN before the players take over, in SAN
+	Moves []string
+}
+
+// Position returns the position of the opening after its moves
+func (o Opening) Position() (*engine.Chess, error) {
+	fen := o.FEN
+	if fen == "" {
+		fen = engine.DefaultFen
+	}
+
+	chess, err := engine.NewChessGameWithFen(fen, engine.Strict)
+	if err != nil {
+		return nil, err
+	}
+	for _, move := range o.Moves {
+		if _, err := chess.MovePGN(move); err != nil {
+			return nil, err
+		}
+	}
+
+	if chess.IsGameOver() {
+		return nil, fmt.Errorf("match: opening %s is already over", fen)
+	}
+	return chess, nil
+}
+
+// LoadOpenings reads an opening suite from a file, a PGN file when its
+// extension is .pgn and otherwise a FEN or EPD per line. plies bounds the
+// moves taken from each PGN game when it is not 0.
+func LoadOpenings(path string, plies int) ([]Opening, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".pgn") {
+		return ReadPGNOpenings(file, plies)
+	}
+	return ReadFENOpenings(file)
+}
+
+// ReadPGNOpenings reads the first plies of each game of a PGN as openings,
+// all of the moves when plies is 0
+func ReadPGNOpenings(r io.Reader, plies int) ([]Opening, error) {
+	games, err := pgn.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var openings []Opening
+	for i, game := range games {
+		opening := Opening{FEN: game.StartingFEN()}
+		for j, move := range game.Moves {
+			if plies > 0 && j >= plies {
+				break
+			}
+			opening.Moves = append(opening.Moves, move.SAN)
+		}
+
+		if _, err := opening.Position(); err != nil {
+			return nil, fmt.Errorf("match: opening %d: %w", i+1, err)
+		}
+		openings = append(openings, opening)
+	}
+
+	return openings, nil
+}
+
+// ReadFENOpenings reads a FEN or EPD per line as openings, empty lines and
+// lines starting with # are skipped
+func ReadFENOpenings(r io.Reader) ([]Opening, error) {
+	var openings []Opening
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// EPD has the first four fields of a FEN followed by operations
+		fields := strings.Fields(text)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("match: line %d: invalid position %q", line, text)
+		}
+		fen := text
+		if len(fields) != 6 || strings.Contains(text, ";") {
+			fen = strings.Join(fields[:4], " ") + " 0 1"
+		}
+
+		opening := Opening{FEN: fen}
+		if _, err := opening.Position(); err != nil {
+			return nil, fmt.Errorf("match: line %d: %w", line, err)
+		}
+		openings = append(openings, opening)
+	}
+
+	return openings, scanner.Err()
+}
@@ -0,0 +1,157 @@
+package match
+
+import (
+	"context"
+	"path/filepath"
+
+	"chess-go/engine"
+	"chess-go/uci"
+)
+
+// defaultDepth bounds the built-in search when there is no time control
+const defaultDepth = 4
+
+// Reply is the move chosen by a player with what it thought of the position
+type Reply struct {
+	Move engine.Move
+	// Score is in centipawns from the point of view of the player
+	Score int
+	// Mate is the number of moves to mate, negative when being mated
+	Mate     int
+	HasScore bool
+	Depth    int
+}
+
+// Player plays the moves of one side of a game
+type Player interface {
+	Name() string
+	// NewGame is called before each game the player takes part in
+	NewGame(ctx context.Context) error
+	// Play returns the move to play in the position within the limits, the
+	// position must not be changed
+	Play(ctx context.Context, chess *engine.Chess, limits uci.GoParams) (Reply, error)
+	Close() error
+}
+
+// Builtin is a player searching with engine.Chess
+type Builtin struct {
+	name  string
+	depth int
+}
+
+// NewBuiltin returns a built-in player, depth bounds every search when it is
+// not 0
+func NewBuiltin(name string, depth int) *Builtin {
+	return &Builtin{name: name, depth: depth}
+}
+
+func (b *Builtin) Name() string {
+	return b.name
+}
+
+func (b *Builtin) NewGame(context.Context) error {
+	return nil
+}
+
+func (b *Builtin) Play(ctx context.Context, chess *engine.Chess, limits uci.GoParams) (Reply, error) {
+	search := engine.SearchLimits{
+		Depth:    b.depth,
+		Nodes:    int(limits.Nodes),
+		MoveTime: limits.MoveTime,
+	}
+	if limits.Depth > 0 && (search.Depth == 0 || limits.Depth < search.Depth) {
+		search.Depth = limits.Depth
+	}
+
+	remaining, increment := limits.WTime, limits.WInc
+	if chess.Turn() == engine.Black {
+		remaining, increment = limits.BTime, limits.BInc
+	}
+	if search.MoveTime == 0 && remaining > 0 {
+		search.MoveTime = engine.AllocateTime(remaining, increment, limits.MovesToGo)
+	}
+
+	if search.Depth == 0 && search.Nodes == 0 && search.MoveTime == 0 {
+		search.Depth = defaultDepth
+	}
+
+	result := chess.Search(ctx, search, nil)
+	return Reply{
+		Move:     result.BestMove,
+		Score:    result.Info.Score,
+		Mate:     result.Info.Mate,
+		HasScore: result.Info.Depth > 0,
+		Depth:    result.Info.Depth,
+	}, nil
+}
+
+func (b *Builtin) Close() error {
+	return nil
+}
+
+// UCIPlayer is a player backed by an external UCI engine
+type UCIPlayer struct {
+	name   string
+	depth  int
+	engine *uci.Engine
+}
+
+// StartUCI starts a UCI engine and sets its options, the name of the engine
+// is used when name is empty
+func StartUCI(ctx context.Context, name, path string, args []string, options map[string]string, depth int) (*UCIPlayer, error) {
+	e, err := uci.Start(ctx, path, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for option, value := range options {
+		if err := e.SetOption(option, value); err != nil {
+			e.Close()
+			return nil, err
+		}
+	}
+	if err := e.IsReady(ctx); err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	if name == "" {
+		name = e.Name
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	return &UCIPlayer{name: name, depth: depth, engine: e}, nil
+}
+
+func (u *UCIPlayer) Name() string {
+	return u.name
+}
+
+func (u *UCIPlayer) NewGame(ctx context.Context) error {
+	return u.engine.NewGame(ctx)
+}
+
+func (u *UCIPlayer) Play(ctx context.Context, chess *engine.Chess, limits uci.GoParams) (Reply, error) {
+	if u.depth > 0 && (limits.Depth == 0 || u.depth < limits.Depth) {
+		limits.Depth = u.depth
+	}
+
+	move, best, err := u.engine.BestMove(ctx, chess, limits, nil)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	return Reply{
+		Move:     move,
+		Score:    best.Info.Score.CP,
+		Mate:     best.Info.Score.Mate,
+		HasScore: best.Info.HasScore,
+		Depth:    best.Info.Depth,
+	}, nil
+}
+
+func (u *UCIPlayer) Close() error {
+	return u.engine.Close()
+}
@@ -0,0 +1,241 @@
+// Package pgn reads and writes games in Portable Game Notation
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"chess-go/engine"
+)
+
+// rosterTags are the tags of the Seven Tag Roster, written first and in order
+var rosterTags = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Tag is a name and value pair of the tag section of a game
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Move is a move of the movetext with its annotations
+type Move struct {
+	// SAN is the move in Standard Algebraic Notation, without annotation
+	// suffixes like "!?" which are read as NAGs
+	SAN string
+	// NAGs are the Numeric Annotation Glyphs of the move ("$1" is 1)
+	NAGs    []int
+	Comment string
+}
+
+// Game is a game of a PGN file, variations are not kept
+type Game struct {
+	Tags []Tag
+	// Comment is the comment written before the first move
+	Comment string
+	Moves   []Move
+	Result  engine.Outcome
+}
+
+// NewGame returns the game played in chess with the Seven Tag Roster filled
+// with unknown values, and the FEN tags if it did not start from the
+// standard position
+func NewGame(chess *engine.Chess) *Game {
+	game := &Game{Result: chess.Outcome()}
+
+	for _, name := range rosterTags {
+		game.SetTag(name, "?")
+	}
+	game.SetTag("Date", time.Now().Format("2006.01.02"))
+	game.SetTag("Result", game.Result.String())
+
+	if fen := chess.StartingFEN(); fen != engine.DefaultFen {
+		game.SetTag("SetUp", "1")
+		game.SetTag("FEN", fen)
+	}
+
+	for _, san := range chess.HistorySAN() {
+		game.Moves = append(game.Moves, Move{SAN: san})
+	}
+
+	return game
+}
+
+// Tag returns the value of a tag, or "" if the game does not have it
+func (g *Game) Tag(name string) string {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// SetTag sets the value of a tag, adding it if the game does not have it
+func (g *Game) SetTag(name, value string) {
+	for i, tag := range g.Tags {
+		if tag.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// SetResult sets the result of the game and its Result tag
+func (g *Game) SetResult(result engine.Outcome) {
+	g.Result = result
+	g.SetTag("Result", result.String())
+}
+
+// StartingFEN returns the FEN of the position the game starts from
+func (g *Game) StartingFEN() string {
+	if fen := g.Tag("FEN"); fen != "" {
+		return fen
+	}
+	return engine.DefaultFen
+}
+
+// Replay plays the moves of the game from its starting position and returns
+// the final position
+func (g *Game) Replay() (*engine.Chess, error) {
+	return g.ReplayPlies(len(g.Moves))
+}
+
+// ReplayPlies plays the first plies of the game from its starting position
+func (g *Game) ReplayPlies(plies int) (*engine.Chess, error) {
+	chess, err := engine.NewChessGameWithFen(g.StartingFEN())
+	if err != nil {
+		return nil, err
+	}
+
+	for i, move := range g.Moves {
+		if i >= plies {
+			break
+		}
+		if _, err := chess.MovePGN(move.SAN); err != nil {
+			return nil, fmt.Errorf("pgn: move %d: %w", i/2+1, err)
+		}
+	}
+
+	return chess, nil
+}
+
+// String returns the game in PGN
+func (g *Game) String() string {
+	var b strings.Builder
+	g.WriteTo(&b)
+	return b.String()
+}
+
+// WriteTo writes the game in PGN export format: the Seven Tag Roster first,
+// then the other tags and the movetext wrapped at 80 columns
+func (g *Game) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	writeTag := func(name, value string) {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		fmt.Fprintf(&b, "[%s \"%s\"]\n", name, value)
+	}
+
+	for _, name := range rosterTags {
+		value := g.Tag(name)
+		switch {
+		case name == "Result":
+			value = g.Result.String()
+		case value == "":
+			value = "?"
+		}
+		writeTag(name, value)
+	}
+	for _, tag := range g.Tags {
+		if !isRosterTag(tag.Name) {
+			writeTag(tag.Name, tag.Value)
+		}
+	}
+	b.WriteString("\n")
+
+	// Move numbers start from the position of the FEN tag
+	number, black := 1, false
+	if fields := strings.Fields(g.StartingFEN()); len(fields) == 6 {
+		black = fields[1] == "b"
+		if n, err := strconv.Atoi(fields[5]); err == nil && n > 0 {
+			number = n
+		}
+	}
+
+	var tokens []string
+	if g.Comment != "" {
+		tokens = append(tokens, "{"+g.Comment+"}")
+	}
+	for i, move := range g.Moves {
+		switch {
+		case !black:
+			tokens = append(tokens, strconv.Itoa(number)+".")
+		case i == 0 || g.Moves[i-1].Comment != "":
+			tokens = append(tokens, strconv.Itoa(number)+"...")
+		}
+
+		tokens = append(tokens, move.SAN)
+		for _, nag := range move.NAGs {
+			tokens = append(tokens, "$"+strconv.Itoa(nag))
+		}
+		if move.Comment != "" {
+			tokens = append(tokens, "{"+move.Comment+"}")
+		}
+
+		if black {
+			number++
+		}
+		black = !black
+	}
+	tokens = append(tokens, g.Result.String())
+
+	width := 0
+	for _, token := range tokens {
+		// Comments may be longer than a line, they are split on spaces
+		for _, word := range strings.Fields(token) {
+			if width > 0 && width+1+len(word) > 80 {
+				b.WriteString("\n")
+				width = 0
+			} else if width > 0 {
+				b.WriteString(" ")
+				width++
+			}
+			b.WriteString(word)
+			width += len(word)
+		}
+	}
+	b.WriteString("\n\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// isRosterTag reports whether a tag belongs to the Seven Tag Roster
+func isRosterTag(name string) bool {
+	for _, roster := range rosterTags {
+		if name == roster {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseResult parses a game termination marker, "*" is NoOutcome
+func ParseResult(s string) (engine.Outcome, bool) {
+	switch s {
+	case "1-0":
+		return engine.WhiteWon, true
+	case "0-1":
+		return engine.BlackWon, true
+	case "1/2-1/2":
+		return engine.Draw, true
+	case "*":
+		return engine.NoOutcome, true
+	}
+	return engine.NoOutcome, false
+}
@@ -0,0 +1,134 @@
+package pgn
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+)
+
+const sample = `% exported by a test
+[Event "Casual \"blitz\""]
+[Site "?"]
+[Date "2024.01.02"]
+[Round "1"]
+[White "Alice"]
+[Black "Bob"]
+[Result "0-1"]
+
+{Fool's mate} 1. f3 e5 2.g4?? (2. Kf2 Qh4+ 3. Kg2 {not better}) Qh4# $1 ; the end
+0-1
+
+[Event "Unfinished"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]
+
+40... Kd7 41. e4
+`
+
+func TestPGN_Read(t *testing.T) {
+	games, err := ReadAll(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	expectedOutputs := []*Game{
+		{
+			Tags: []Tag{
+				{"Event", `Casual "blitz"`}, {"Site", "?"}, {"Date", "2024.01.02"}, {"Round", "1"},
+				{"White", "Alice"}, {"Black", "Bob"}, {"Result", "0-1"},
+			},
+			Comment: "Fool's mate",
+			Moves: []Move{
+				{SAN: "f3"}, {SAN: "e5"}, {SAN: "g4", NAGs: []int{4}},
+				{SAN: "Qh4#", NAGs: []int{1}, Comment: "the end"},
+			},
+			Result: engine.BlackWon,
+		},
+		{
+			Tags:   []Tag{{"Event", "Unfinished"}, {"FEN", "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"}},
+			Moves:  []Move{{SAN: "Kd7"}, {SAN: "e4"}},
+			Result: engine.NoOutcome,
+		},
+	}
+
+	if !reflect.DeepEqual(games, expectedOutputs) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", games, expectedOutputs)
+	}
+
+	chess, err := games[0].Replay()
+	if err != nil || chess.Outcome() != engine.BlackWon {
+		t.Errorf("FAILED: replay\n\tgot:     %v\n\texpected:%v", err, engine.BlackWon)
+	}
+	chess, err = games[1].Replay()
+	if err != nil || chess.GetFEN() != "8/3k4/8/8/4P3/8/8/4K3 b - e3 0 41" {
+		t.Errorf("FAILED: replay\n\tgot:     %v\n\texpected:%v", err, "8/3k4/8/8/4P3/8/8/4K3 b - e3 0 41")
+	}
+}
+func TestPGN_ReadErrors(t *testing.T) {
+	inputs := []string{
+		`[Event "Broken]`,
+		`1. e4 (1. d4`,
+		`1. e4 e5 ) 2. Nf3`,
+		`1. e4 {unterminated`,
+		`1. e4?!? e5`,
+		`$3 1. e4`,
+	}
+
+	for _, input := range inputs {
+		if _, err := ReadAll(strings.NewReader(input)); err == nil {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, err, "an error")
+		}
+	}
+}
+func TestPGN_Write(t *testing.T) {
+	chess, _ := engine.NewChessGameWithFen("4k3/8/8/8/8/8/4P3/4K3 b - - 0 40")
+	for _, move := range []string{"Kd7", "e4", "Ke6"} {
+		if _, err := chess.MovePGN(move); err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", move, err.Error())
+		}
+	}
+
+	game := NewGame(chess)
+	game.SetTag("Date", "2024.01.02")
+	game.SetTag("White", "Alice")
+	game.SetTag("Annotator", "Test")
+	game.Moves[1].Comment = "the pawn runs"
+	game.Moves[1].NAGs = []int{6}
+
+	expected := `[Event "?"]
+[Site "?"]
+[Date "2024.01.02"]
+[Round "?"]
+[White "Alice"]
+[Black "?"]
+[Result "*"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]
+[Annotator "Test"]
+
+40... Kd7 41. e4 $6 {the pawn runs} 41... Ke6 *
+
+`
+	if game.String() != expected {
+		t.Errorf("FAILED\n\tgot:     %s\n\texpected:%s", game.String(), expected)
+	}
+
+	// Writing and reading gives back the same game
+	games, err := ReadAll(strings.NewReader(game.String()))
+	if err != nil || !reflect.DeepEqual(games[0], game) {
+		t.Errorf("FAILED: round trip\n\tgot:     %+v %v\n\texpected:%+v", games, err, game)
+	}
+}
+func TestPGN_WriteWrap(t *testing.T) {
+	game := &Game{Result: engine.Draw}
+	for i := 0; i < 40; i++ {
+		game.Moves = append(game.Moves, Move{SAN: "Nf3"}, Move{SAN: "Nf6"}, Move{SAN: "Ng1"}, Move{SAN: "Ng8"})
+	}
+
+	for _, line := range strings.Split(game.String(), "\n") {
+		if len(line) > 80 {
+			t.Errorf("FAILED\n\tgot:     %d\n\texpected:%s", len(line), "at most 80 columns")
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// suffixNAGs are the move suffix annotations and the NAGs they stand for
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// ParseError is a PGN that can not be read
+type ParseError struct {
+	Line int
+
+	err string
+}
+
+func (p *ParseError) Error() string {
+	return "Invalid PGN at line " + strconv.Itoa(p.Line) + ": " + p.err
+}
+
+// Reader reads the games of a PGN file one by one
+type Reader struct {
+	r    *bufio.Reader
+	line int
+	// lineStart tells whether the next rune starts a line, for escape lines
+	lineStart bool
+}
+
+// NewReader returns a reader of the games in r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1, lineStart: true}
+}
+
+// ReadAll reads all the games in r
+func ReadAll(r io.Reader) ([]*Game, error) {
+	reader := NewReader(r)
+
+	var games []*Game
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		games = append(games, game)
+	}
+}
+
+// Read reads the next game, it returns io.EOF when there are no more games.
+// Variations are skipped, and a game without a termination marker ends where
+// the tags of the next game begin.
+func (r *Reader) Read() (*Game, error) {
+	game := &Game{}
+	started, inMoves := false, false
+	depth := 0
+
+	for {
+		c, err := r.next()
+		if err == io.EOF {
+			if !started {
+				return nil, io.EOF
+			}
+			if depth > 0 {
+				return nil, &ParseError{Line: r.line, err: "unterminated variation"}
+			}
+			return game, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		started = true
+
+		switch c {
+		case '[':
+			if inMoves {
+				r.unread(c)
+				return game, nil
+			}
+			tag, err := r.readTag()
+			if err != nil {
+				return nil, err
+			}
+			game.Tags = append(game.Tags, tag)
+
+		case '{', ';':
+			end := '}'
+			if c == ';' {
+				end = '\n'
+			}
+			text, err := r.readUntil(end)
+			if err != nil && !(err == io.EOF && c == ';') {
+				return nil, &ParseError{Line: r.line, err: "unterminated comment"}
+			}
+			if depth == 0 {
+				addComment(game, strings.Join(strings.Fields(text), " "))
+			}
+
+		case '(':
+			inMoves = true
+			depth++
+
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, &ParseError{Line: r.line, err: "unexpected )"}
+			}
+
+		default:
+			inMoves = true
+			token, err := r.readSymbol(c)
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if depth > 0 {
+				continue
+			}
+
+			if result, ok := ParseResult(token); ok {
+				game.Result = result
+				return game, nil
+			}
+			if err := addToken(game, token); err != nil {
+				return nil, &ParseError{Line: r.line, err: err.Error()}
+			}
+		}
+	}
+}
+
+// addComment adds a comment to the last move, or before the first one
+func addComment(game *Game, text string) {
+	target := &game.Comment
+	if len(game.Moves) > 0 {
+		target = &game.Moves[len(game.Moves)-1].Comment
+	}
+
+	if *target != "" {
+		*target += " " + text
+	} else {
+		*target = text
+	}
+}
+
+// addToken adds a move or a NAG of the movetext to the game, move numbers
+// are skipped
+func addToken(game *Game, token string) error {
+	if strings.HasPrefix(token, "$") {
+		nag, err := strconv.Atoi(token[1:])
+		if err != nil || len(game.Moves) == 0 {
+			return errors.New("invalid NAG " + token)
+		}
+		last := &game.Moves[len(game.Moves)-1]
+		last.NAGs = append(last.NAGs, nag)
+		return nil
+	}
+
+	// Move numbers may be glued to the move ("1.e4")
+	token = strings.TrimLeft(token, "0123456789")
+	token = strings.TrimLeft(token, ".")
+	if token == "" {
+		return nil
+	}
+
+	move := Move{SAN: strings.TrimRight(token, "!?")}
+	if suffix := token[len(move.SAN):]; suffix != "" {
+		nag, ok := suffixNAGs[suffix]
+		if !ok {
+			return errors.New("invalid annotation " + token)
+		}
+		move.NAGs = append(move.NAGs, nag)
+	}
+	if move.SAN == "" {
+		return errors.New("invalid move " + token)
+	}
+
+	game.Moves = append(game.Moves, move)
+	return nil
+}
+
+// next returns the next rune that is not a space, skipping escaped lines
+func (r *Reader) next() (rune, error) {
+	for {
+		lineStart := r.lineStart
+		c, err := r.read()
+		if err != nil {
+			return 0, err
+		}
+
+		if c == '%' && lineStart {
+			if _, err := r.readUntil('\n'); err != nil && err != io.EOF {
+				return 0, err
+			}
+			continue
+		}
+		if !unicode.IsSpace(c) {
+			return c, nil
+		}
+	}
+}
+
+// read returns the next rune and keeps track of the line
+func (r *Reader) read() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	r.lineStart = c == '\n'
+	if c == '\n' {
+		r.line++
+	}
+	return c, nil
+}
+
+// unread puts back the last rune read
+func (r *Reader) unread(c rune) {
+	r.r.UnreadRune()
+	if c == '\n' {
+		r.line--
+	}
+}
+
+// readUntil reads the text up to the end rune, which is consumed
+func (r *Reader) readUntil(end rune) (string, error) {
+	var b strings.Builder
+	for {
+		c, err := r.read()
+		if err != nil {
+			return b.String(), err
+		}
+		if c == end {
+			return b.String(), nil
+		}
+		b.WriteRune(c)
+	}
+}
+
+// readSymbol reads a token of the movetext that starts with c
+func (r *Reader) readSymbol(c rune) (string, error) {
+	var b strings.Builder
+	b.WriteRune(c)
+
+	for {
+		c, err := r.read()
+		if err != nil {
+			return b.String(), err
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune("{}()[];$", c) {
+			r.unread(c)
+			return b.String(), nil
+		}
+		b.WriteRune(c)
+	}
+}
+
+// readTag reads a tag pair after its opening bracket
+func (r *Reader) readTag() (Tag, error) {
+	line := r.line
+
+	text, err := r.readUntil('"')
+	if err != nil {
+		return Tag{}, &ParseError{Line: line, err: "unterminated tag"}
+	}
+	name := strings.TrimSpace(text)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return Tag{}, &ParseError{Line: line, err: "invalid tag name " + strconv.Quote(name)}
+	}
+
+	// The value is a string with \" and \\ escapes
+	var value strings.Builder
+	for escaped := false; ; {
+		c, err := r.read()
+		if err != nil {
+			return Tag{}, &ParseError{Line: line, err: "unterminated tag"}
+		}
+		if c == '"' && !escaped {
+			break
+		}
+		escaped = c == '\\' && !escaped
+		if !escaped {
+			value.WriteRune(c)
+		}
+	}
+
+	rest, err := r.readUntil(']')
+	if err != nil || strings.TrimSpace(rest) != "" {
+		return Tag{}, &ParseError{Line: line, err: "unterminated tag"}
+	}
+
+	return Tag{Name: name, Value: value.String()}, nil
+}
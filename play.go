@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/tui"
+)
+
+// runTUI plays a game in the terminal, against the computer when a color is
+// chosen, and returns the exit code
+func runTUI(args []string) int {
+	flags := flag.NewFlagSet("chess-go", flag.ExitOnError)
+	color := flags.String("color", "", `color played against the computer, "white", "black" or "random", two people play when empty`)
+	level := flags.Int("level", 5, fmt.Sprintf("strength of the computer from 1 to %d, the lowest levels make mistakes on purpose", len(engine.Levels)))
+	depth := flags.Int("depth", 0, "depth the computer searches to, instead of the one of the level")
+	nodes := flags.Int("nodes", 0, "nodes the computer searches, instead of the limit of the level")
+	moveTime := flags.Duration("movetime", 0, "time the computer searches each move, instead of the one of the level")
+	fen := flags.String("fen", "", "starting position, the standard one when empty")
+	thinking := flags.Bool("thinking", false, "show the score and the best line of the computer as it searches")
+	flags.Parse(args)
+
+	ui := tui.New(os.Stdout)
+	ui.Color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	ui.Clear = isTerminal(os.Stdout)
+	ui.Thinking = *thinking
+
+	switch *color {
+	case "":
+	case "white", "black", "random":
+		ui.Computer = engine.Black
+		if *color == "black" || *color == "random" && rand.New(rand.NewSource(time.Now().UnixNano())).Intn(2) == 0 {
+			ui.Computer = engine.White
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "chess-go: invalid color %q\n", *color)
+		return 2
+	}
+
+	strength, err := engine.Level(*level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chess-go:", err)
+		return 2
+	}
+	// Explicit limits replace those of the level, keeping its mistakes
+	if *depth > 0 || *nodes > 0 || *moveTime > 0 {
+		strength.Limits = engine.SearchLimits{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime}
+	}
+	ui.Strength = strength
+
+	if *fen != "" {
+		if err := ui.SetFEN(*fen); err != nil {
+			fmt.Fprintln(os.Stderr, "chess-go:", err)
+			return 2
+		}
+	}
+
+	// Leave the terminal clean when interrupted in the middle of a line
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		fmt.Println()
+		os.Exit(0)
+	}()
+
+	if err := ui.Run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// isTerminal reports whether a file is a terminal rather than a pipe or a
+// regular file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,265 @@
+// Package problem solves chess problems of the kind "mate in N moves": the
+// side to move attacks, and every defense is tried until the forced mates are
+// proven. The whole tree is searched, so the solutions found are all of them.
+package problem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"chess-go/engine"
+)
+
+// ErrMoves is returned when a problem is not a mate in at least one move
+var ErrMoves = errors.New("problem: the number of moves must be at least 1")
+
+// Attack is a move of the attacker with the defenses against it
+type Attack struct {
+	Move engine.Move
+	SAN  string
+	// Defenses are the legal replies of the defender, none when the move
+	// mates
+	Defenses []Defense
+}
+
+// Defense is a reply of the defender with the attacks that still mate in
+// time after it
+type Defense struct {
+	Move engine.Move
+	SAN  string
+	// Attacks are the moves that mate in time, more than one is a dual
+	Attacks []Attack
+}
+
+// Dual is a position of a solution where the attacker has more than one
+// move that mates in time
+type Dual struct {
+	// Line are the moves in SAN from the problem to the position
+	Line []string
+	// Moves are the moves that mate in time in SAN
+	Moves []string
+}
+
+// Solution is the solution tree of a mate in N moves
+type Solution struct {
+	Moves int
+	// Keys are the first moves that force mate in at most Moves moves. A
+	// composed problem has a single key, the others are cooks.
+	Keys []Attack
+}
+
+// Solved reports whether the problem has a key
+func (s *Solution) Solved() bool {
+	return len(s.Keys) > 0
+}
+
+// Cooked reports whether the problem has more than one key
+func (s *Solution) Cooked() bool {
+	return len(s.Keys) > 1
+}
+
+// Duals returns the positions of the solution tree where the attacker has
+// more than one way to mate in time, the last moves included
+func (s *Solution) Duals() []Dual {
+	var duals []Dual
+	var walk func(line []string, attacks []Attack)
+	walk = func(line []string, attacks []Attack) {
+		for _, attack := range attacks {
+			for _, defense := range attack.Defenses {
+				next := append(append(append([]string{}, line...), attack.SAN), defense.SAN)
+				if len(defense.Attacks) > 1 {
+					dual := Dual{Line: next}
+					for _, continuation := range defense.Attacks {
+						dual.Moves = append(dual.Moves, continuation.SAN)
+					}
+					duals = append(duals, dual)
+				}
+				walk(next, defense.Attacks)
+			}
+		}
+	}
+	walk(nil, s.Keys)
+	return duals
+}
+
+// String writes the solution tree, a key and each defense on their own
+// line with the mating moves after it
+func (s *Solution) String() string {
+	if !s.Solved() {
+		return fmt.Sprintf("No mate in %d\n", s.Moves)
+	}
+
+	var b strings.Builder
+	var write func(attacks []Attack, number int, indent string)
+	write = func(attacks []Attack, number int, indent string) {
+		for _, attack := range attacks {
+			fmt.Fprintf(&b, "%s%d. %s", indent, number, attack.SAN)
+			if number == 1 {
+				b.WriteString("!")
+			}
+			b.WriteString("\n")
+			for _, defense := range attack.Defenses {
+				fmt.Fprintf(&b, "%s  %d... %s\n", indent, number, defense.SAN)
+				write(defense.Attacks, number+1, indent+"    ")
+			}
+		}
+	}
+	write(s.Keys, 1, "")
+	return b.String()
+}
+
+// solver searches the tree of a problem, playing and taking back the moves
+// on a copy of its position
+type solver struct {
+	ctx   context.Context
+	chess *engine.Chess
+}
+
+// Solve proves the forced mates in at most moves moves of the side to move,
+// and returns every key with its full solution tree
+func Solve(ctx context.Context, chess *engine.Chess, moves int) (*Solution, error) {
+	if moves < 1 {
+		return nil, ErrMoves
+	}
+	if chess.IsGameOver() {
+		return nil, engine.ErrGameOver
+	}
+
+	position, err := engine.NewChessGameWithFen(chess.GetFEN())
+	if err != nil {
+		return nil, err
+	}
+	s := &solver{ctx: ctx, chess: position}
+
+	keys, err := s.attacks(moves)
+	if err != nil {
+		return nil, err
+	}
+	return &Solution{Moves: moves, Keys: keys}, nil
+}
+
+// attacks returns the moves of the side to move that force mate in at most
+// n moves, with their trees
+func (s *solver) attacks(n int) ([]Attack, error) {
+	var attacks []Attack
+	for _, move := range s.candidates(n) {
+		if err := s.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		played, err := s.chess.Move(move)
+		if err != nil {
+			return nil, err
+		}
+		attack := Attack{Move: move, SAN: played.SAN}
+
+		mates := played.Method == engine.Checkmate
+		if !mates && !played.GameOver && n > 1 {
+			// The tree is only built once the mate is proven, the proof stops
+			// at the first escape
+			if mates, err = s.forced(n - 1); err == nil && mates {
+				attack.Defenses, err = s.defenses(n - 1)
+			}
+		}
+		s.chess.Undo()
+
+		if err != nil {
+			return nil, err
+		}
+		if mates {
+			attacks = append(attacks, attack)
+		}
+	}
+	return attacks, nil
+}
+
+// defenses returns every defense of the side to move, which is mated in at
+// most n moves, with the attacks that mate in time after it
+func (s *solver) defenses(n int) ([]Defense, error) {
+	var defenses []Defense
+	for _, move := range s.chess.LegalMoves() {
+		played, err := s.chess.Move(move)
+		if err != nil {
+			return nil, err
+		}
+		attacks, err := s.attacks(n)
+		s.chess.Undo()
+		if err != nil {
+			return nil, err
+		}
+		defenses = append(defenses, Defense{Move: move, SAN: played.SAN, Attacks: attacks})
+	}
+	return defenses, nil
+}
+
+// forced reports whether every defense of the side to move is mated in at
+// most n moves
+func (s *solver) forced(n int) (bool, error) {
+	for _, move := range s.chess.LegalMoves() {
+		played, err := s.chess.Move(move)
+		if err != nil {
+			return false, err
+		}
+		// The defense ends the game: the defender mates or draws
+		escapes := played.GameOver
+		if !escapes {
+			var mates bool
+			mates, err = s.mates(n)
+			escapes = !mates
+		}
+		s.chess.Undo()
+
+		if err != nil || escapes {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// mates reports whether the side to move mates in at most n moves
+func (s *solver) mates(n int) (bool, error) {
+	for _, move := range s.candidates(n) {
+		if err := s.ctx.Err(); err != nil {
+			return false, err
+		}
+
+		played, err := s.chess.Move(move)
+		if err != nil {
+			return false, err
+		}
+		mates := played.Method == engine.Checkmate
+		if !mates && !played.GameOver && n > 1 {
+			mates, err = s.forced(n - 1)
+		}
+		s.chess.Undo()
+
+		if err != nil || mates {
+			return mates, err
+		}
+	}
+	return false, nil
+}
+
+// candidates returns the moves that may mate in n moves: the checks for a
+// mate in one, every move otherwise with the checks first, as they mate
+// sooner
+func (s *solver) candidates(n int) []engine.Move {
+	checks := s.chess.LegalChecks()
+	if n == 1 {
+		return checks
+	}
+
+	giving := map[engine.Move]bool{}
+	for _, check := range checks {
+		giving[check] = true
+	}
+	moves := checks
+	for _, move := range s.chess.LegalMoves() {
+		if !giving[move] {
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
@@ -0,0 +1,121 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"chess-go/engine"
+)
+
+func TestProblem_Solve(t *testing.T) {
+	inputs := []struct {
+		FEN   string
+		Moves int
+	}{
+		// Morphy
+		{"kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 2},
+		{"kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 1},
+		{"k7/8/1K6/8/8/8/8/6RR w - - 0 1", 1},
+		{"6k1/8/8/8/8/8/r4PPP/6K1 b - - 0 1", 1},
+	}
+
+	expectedOutputs := []string{
+		"1. Ra6!\n" +
+			"  1... Bc7\n    2. Rxa7#\n" +
+			"  1... Bd6\n    2. Rxa7#\n" +
+			"  1... Be5\n    2. Rxa7#\n" +
+			"  1... Bf4\n    2. Rxa7#\n" +
+			"  1... Bg3\n    2. Rxa7#\n" +
+			"  1... Bh2\n    2. Rxa7#\n" +
+			"  1... bxa6\n    2. b7#\n",
+		"No mate in 1\n",
+		"1. Rg8#!\n1. Rh8#!\n",
+		"1. Ra1#!\n",
+	}
+
+	for i, input := range inputs {
+		chess, _ := engine.NewChessGameWithFen(input.FEN)
+		solution, err := Solve(context.Background(), chess, input.Moves)
+		if err != nil {
+			t.Fatalf("FAILED: %+v\n\t%s", input, err.Error())
+		}
+		if output := solution.String(); output != expectedOutputs[i] {
+			t.Errorf("FAILED: %+v\n\tgot:\n%s\n\texpected:\n%s", input, output, expectedOutputs[i])
+		}
+		if solution.Cooked() != (i == 2) {
+			t.Errorf("FAILED: %+v\n\tgot:     cooked %v\n\texpected:cooked %v", input, solution.Cooked(), i == 2)
+		}
+	}
+}
+func TestProblem_Duals(t *testing.T) {
+	inputs := []string{
+		// Morphy with a rook on d8: the king mates too after 1... Bc7
+		"kbKR4/pp6/1P6/8/8/8/8/R7 w - - 0 1",
+		// Morphy with a bishop on b1: cooked by 1. Be4
+		"kbK5/pp6/1P6/8/8/8/8/RB6 w - - 0 1",
+	}
+
+	dual := func(key, defense string, moves ...string) Dual {
+		return Dual{Line: []string{key, defense}, Moves: moves}
+	}
+	expectedOutputs := []struct {
+		Keys  []string
+		Duals []Dual
+	}{
+		{[]string{"Ra6"}, []Dual{dual("Ra6", "Bc7", "Kxc7#", "Rxa7#")}},
+		{[]string{"Ra6", "Be4"}, []Dual{
+			dual("Ra6", "bxa6", "b7#", "Be4#"),
+			dual("Be4", "Bc7", "Bxb7#", "Rxa7#"),
+			dual("Be4", "Bd6", "Bxb7#", "Rxa7#"),
+			dual("Be4", "Be5", "Bxb7#", "Rxa7#"),
+			dual("Be4", "Bf4", "Bxb7#", "Rxa7#"),
+			dual("Be4", "Bg3", "Bxb7#", "Rxa7#"),
+			dual("Be4", "Bh2", "Bxb7#", "Rxa7#"),
+		}},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		chess, _ := engine.NewChessGameWithFen(input)
+		solution, err := Solve(context.Background(), chess, 2)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+
+		var keys []string
+		for _, key := range solution.Keys {
+			keys = append(keys, key.SAN)
+		}
+		if !reflect.DeepEqual(keys, expected.Keys) || solution.Cooked() != (len(expected.Keys) > 1) {
+			t.Errorf("FAILED: %s\n\tgot:     %v cooked %v\n\texpected:%v", input, keys, solution.Cooked(), expected.Keys)
+		}
+		if output := solution.Duals(); !reflect.DeepEqual(output, expected.Duals) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected.Duals)
+		}
+	}
+}
+func TestProblem_Errors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := []struct {
+		Ctx   context.Context
+		FEN   string
+		Moves int
+	}{
+		{context.Background(), "kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 0},
+		{context.Background(), "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1", 1},
+		{canceled, "kbK5/pp6/1P6/8/8/8/8/R7 w - - 0 1", 2},
+	}
+
+	expectedOutputs := []error{ErrMoves, engine.ErrGameOver, context.Canceled}
+
+	for i, input := range inputs {
+		chess, _ := engine.NewChessGameWithFen(input.FEN)
+		if _, err := Solve(input.Ctx, chess, input.Moves); !errors.Is(err, expectedOutputs[i]) {
+			t.Errorf("FAILED: %s %d\n\tgot:     %v\n\texpected:%v", input.FEN, input.Moves, err, expectedOutputs[i])
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package puzzle finds training puzzles in games: positions where a single
+// move wins after a mistake of the opponent, with the line that proves it
+package puzzle
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"chess-go/analysis"
+	"chess-go/engine"
+)
+
+// DefaultLimits bounds the searches of the moves of a puzzle
+var DefaultLimits = engine.SearchLimits{Depth: 4, MoveTime: time.Second}
+
+// Options are the settings of the puzzle search
+type Options struct {
+	// Limits bounds the searches, DefaultLimits when zero
+	Limits engine.SearchLimits
+	// Winning is the least advantage in centipawns of a winning move, 300
+	// when zero
+	Winning int
+	// Others is the largest advantage in centipawns the other moves may
+	// keep for the winning move to be the only one, 100 when zero. A mate
+	// must be the only mate.
+	Others int
+	// MaxMoves is the largest number of moves of the solver, 4 when zero
+	MaxMoves int
+}
+
+// withDefaults returns the options with the defaults of the zero fields
+func (o Options) withDefaults() Options {
+	if o.Limits == (engine.SearchLimits{}) {
+		o.Limits = DefaultLimits
+	}
+	if o.Winning == 0 {
+		o.Winning = 300
+	}
+	if o.Others == 0 {
+		o.Others = 100
+	}
+	if o.MaxMoves == 0 {
+		o.MaxMoves = 4
+	}
+	return o
+}
+
+// Puzzle is a position with a single winning line
+type Puzzle struct {
+	// FEN is the position of the puzzle, the solver is to move
+	FEN string
+	// Moves are the solution in UCI, the moves of the solver alternating
+	// with the replies, the last one is the solver's
+	Moves []string
+	// SAN are the moves of the solution in SAN
+	SAN    []string
+	Themes []string
+	// Ply is the number of plies played in the game before the puzzle
+	Ply int
+}
+
+// Find analyzes a game and returns its puzzles: the positions where a
+// mistake of the opponent left a single winning move to the solver
+func Find(ctx context.Context, chess *engine.Chess, options Options) ([]Puzzle, error) {
+	options = options.withDefaults()
+
+	moves, err := analysis.Analyze(ctx, chess, analysis.Options{Limits: options.Limits}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := engine.NewChessGameWithFen(chess.StartingFEN())
+	if err != nil {
+		return nil, err
+	}
+
+	var puzzles []Puzzle
+	for i, played := range chess.History() {
+		if _, err := position.Move(played); err != nil {
+			return nil, err
+		}
+
+		// The move turned a position that was not won into a won one
+		solver := position.Turn()
+		before, after := moves[i].Before.For(solver), moves[i].After.For(solver)
+		if position.IsGameOver() || !options.winning(after) || options.winning(before) {
+			continue
+		}
+
+		puzzle, ok, err := FromPosition(ctx, position, options)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			puzzle.Ply = i + 1
+			puzzles = append(puzzles, puzzle)
+		}
+	}
+	return puzzles, nil
+}
+
+// FromPosition verifies that the side to move has a single winning move and
+// follows the line while the solver keeps having one, the opponent
+// answering with the best defense. The line ends once the solver mates or
+// has won material, it is rejected when a mate is not forced to the end.
+func FromPosition(ctx context.Context, chess *engine.Chess, options Options) (Puzzle, bool, error) {
+	options = options.withDefaults()
+
+	position, err := engine.NewChessGameWithFen(chess.GetFEN())
+	if err != nil {
+		return Puzzle{}, false, err
+	}
+	solver := position.Turn()
+	material := balance(position, solver)
+
+	puzzle := Puzzle{FEN: position.GetFEN()}
+	var line []engine.Move
+	mating, mated := false, false
+	for solved := 0; solved < options.MaxMoves; solved++ {
+		ranked, err := rank(ctx, position, options.Limits)
+		if err != nil {
+			return Puzzle{}, false, err
+		}
+		if len(ranked) == 0 || !options.winning(ranked[0].eval) {
+			break
+		}
+		best := ranked[0]
+		if solved == 0 {
+			mating = best.eval.Mate > 0
+		}
+		// Any mate is as good as another one on the last move of a line
+		lastMate := solved > 0 && best.eval.Mate == 1
+		if len(ranked) > 1 && !lastMate && !options.only(best.eval, ranked[1].eval) {
+			break
+		}
+
+		played, err := position.Move(best.move)
+		if err != nil {
+			return Puzzle{}, false, err
+		}
+		line = append(line, best.move)
+		puzzle.Moves = append(puzzle.Moves, played.UCI)
+		puzzle.SAN = append(puzzle.SAN, played.SAN)
+		if played.Method == engine.Checkmate {
+			mated = true
+			break
+		}
+		if played.GameOver {
+			break
+		}
+
+		reply := position.Search(ctx, options.Limits, nil)
+		if err := ctx.Err(); err != nil {
+			return Puzzle{}, false, err
+		}
+		answered, err := position.Move(reply.BestMove)
+		if err != nil {
+			return Puzzle{}, false, err
+		}
+		line = append(line, reply.BestMove)
+		puzzle.Moves = append(puzzle.Moves, answered.UCI)
+		puzzle.SAN = append(puzzle.SAN, answered.SAN)
+
+		// The material is won for good once the reply did not take it back,
+		// a mate goes on to the end
+		if answered.GameOver || !mating && balance(position, solver)-material >= options.Winning {
+			break
+		}
+	}
+
+	// The line ends with a move of the solver
+	if len(line) > 0 && len(line)%2 == 0 {
+		line = line[:len(line)-1]
+		puzzle.Moves = puzzle.Moves[:len(line)]
+		puzzle.SAN = puzzle.SAN[:len(line)]
+	}
+	if len(line) == 0 || mating && !mated {
+		return Puzzle{}, false, nil
+	}
+
+	start, _ := engine.NewChessGameWithFen(puzzle.FEN)
+	puzzle.Themes = themes(start, line, mated)
+	return puzzle, true, nil
+}
+
+// scored is a move with the evaluation of the position it leads to, from
+// the point of view of its player
+type scored struct {
+	move engine.Move
+	eval analysis.Eval
+}
+
+// rank searches the position after each legal move and returns the moves
+// from the best to the worst
+func rank(ctx context.Context, position *engine.Chess, limits engine.SearchLimits) ([]scored, error) {
+	// The replies are searched a ply less deep
+	if limits.Depth > 1 {
+		limits.Depth--
+	}
+
+	var ranked []scored
+	for _, move := range position.LegalMoves() {
+		played, err := position.Move(move)
+		if err != nil {
+			return nil, err
+		}
+
+		var eval analysis.Eval
+		switch {
+		case played.Method == engine.Checkmate:
+			eval.Mate = 1
+		case played.GameOver:
+		default:
+			result := position.Search(ctx, limits, nil)
+			eval = analysis.Eval{Centipawns: -result.Info.Score, Mate: -result.Info.Mate}
+			// The mover mates a move after the reply
+			if eval.Mate > 0 {
+				eval.Mate++
+			}
+		}
+		position.Undo()
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ranked = append(ranked, scored{move: move, eval: eval})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return better(ranked[i].eval, ranked[j].eval)
+	})
+	return ranked, nil
+}
+
+// better reports whether an evaluation is better than another one for the
+// same player: faster mates first, then the scores, and being mated later
+// before being mated sooner
+func better(a, b analysis.Eval) bool {
+	switch {
+	case a.Mate > 0 && b.Mate > 0:
+		return a.Mate < b.Mate
+	case a.Mate < 0 && b.Mate < 0:
+		return a.Mate < b.Mate
+	case a.Mate != 0 || b.Mate != 0:
+		return a.Mate > 0 || b.Mate < 0
+	}
+	return a.Centipawns > b.Centipawns
+}
+
+// winning reports whether an evaluation wins for its player
+func (o Options) winning(eval analysis.Eval) bool {
+	return eval.Mate > 0 || eval.Mate == 0 && eval.Centipawns >= o.Winning
+}
+
+// only reports whether the best move is the only winning one given the
+// evaluation of the second best
+func (o Options) only(best, second analysis.Eval) bool {
+	if best.Mate > 0 {
+		return second.Mate <= 0
+	}
+	return second.Mate < 0 || second.Mate == 0 && second.Centipawns <= o.Others
+}
+
+// balance returns the material of a color less the material of the other
+// one, in centipawns
+func balance(chess *engine.Chess, color engine.Color) int {
+	total := 0
+	for square := engine.A1; square <= engine.H8; square++ {
+		piece := chess.PieceAt(square)
+		if piece == engine.NoPiece || piece.Type() == engine.King {
+			continue
+		}
+		if piece.Color() == color {
+			total += engine.PieceValue(piece.Type())
+		} else {
+			total -= engine.PieceValue(piece.Type())
+		}
+	}
+	return total
+}
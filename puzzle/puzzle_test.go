@@ -0,0 +1,121 @@
+package puzzle
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+var testOptions = Options{Limits: engine.SearchLimits{Depth: 3}}
+
+func TestPuzzle_FromPosition(t *testing.T) {
+	inputs := []string{
+		"2q3k1/8/8/3N4/8/8/5PPP/6K1 w - - 0 1",
+		"6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1",
+		// Promoting to a rook wins too
+		"r5k1/1P6/8/8/8/8/6K1/8 w - - 0 1",
+		"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+	}
+
+	expectedOutputs := []Puzzle{
+		{
+			FEN:    "2q3k1/8/8/3N4/8/8/5PPP/6K1 w - - 0 1",
+			Moves:  []string{"d5e7", "g8f7", "e7c8"},
+			SAN:    []string{"Ne7+", "Kf7", "Nxc8"},
+			Themes: []string{"fork"},
+		},
+		{
+			FEN:    "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1",
+			Moves:  []string{"a1a8"},
+			SAN:    []string{"Ra8#"},
+			Themes: []string{"mate", "mateIn1", "backRankMate"},
+		},
+		{},
+		{},
+	}
+
+	for i, input := range inputs {
+		chess, _ := engine.NewChessGameWithFen(input)
+		output, ok, err := FromPosition(context.Background(), chess, testOptions)
+		if err != nil {
+			t.Fatalf("FAILED: %s\n\t%s", input, err.Error())
+		}
+		if !reflect.DeepEqual(output, expectedOutputs[i]) || ok != (expectedOutputs[i].FEN != "") {
+			t.Errorf("FAILED: %s\n\tgot:     %v %+v\n\texpected:%+v", input, ok, output, expectedOutputs[i])
+		}
+	}
+}
+func TestPuzzle_Find(t *testing.T) {
+	games, err := pgn.ReadAll(strings.NewReader(`[FEN "1r4k1/5ppp/8/8/8/8/5PPP/R5K1 b - - 0 1"]
+
+1... Rb2 2. Ra8+ Rb8 3. Rxb8# 1-0
+`))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	chess, err := games[0].Replay()
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	output, err := Find(context.Background(), chess, testOptions)
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	expected := []Puzzle{{
+		FEN:    "6k1/5ppp/8/8/8/8/1r3PPP/R5K1 w - - 1 2",
+		Moves:  []string{"a1a8", "b2b8", "a8b8"},
+		SAN:    []string{"Ra8+", "Rb8", "Rxb8#"},
+		Themes: []string{"mate", "mateIn2", "backRankMate"},
+		Ply:    1,
+	}}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+	}
+}
+func TestPuzzle_Themes(t *testing.T) {
+	inputs := []struct {
+		FEN  string
+		Line []string
+	}{
+		{"4k3/8/2n5/8/8/8/8/4KB2 w - - 0 1", []string{"f1b5"}},
+		{"3k4/8/2n5/8/8/8/8/4KB2 w - - 0 1", []string{"f1b5"}},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", []string{"b7b8q", "e8d7", "b8b5"}},
+		{"r3k3/8/8/1N6/8/8/8/4K3 w - - 0 1", []string{"b5c7"}},
+		{"r3k3/8/2n5/8/8/8/8/4KB2 w - - 0 1", []string{"f1g2"}},
+		{"4k3/8/8/8/8/8/2P3q1/1N2K3 b - - 0 1", []string{"g2e4"}},
+	}
+
+	expectedOutputs := [][]string{
+		{"pin"},
+		// Nothing behind the knight
+		nil,
+		{"promotion"},
+		{"fork"},
+		{"pin"},
+		// The check hits the loose pawn, the knight behind it is worth less
+		// than the queen
+		{"fork"},
+	}
+
+	for i, input := range inputs {
+		chess, _ := engine.NewChessGameWithFen(input.FEN)
+		played, _ := engine.NewChessGameWithFen(input.FEN)
+		var line []engine.Move
+		for _, uci := range input.Line {
+			result, err := played.MoveUCI(uci)
+			if err != nil {
+				t.Fatalf("FAILED: %+v\n\t%s", input, err.Error())
+			}
+			line = append(line, result.Move)
+		}
+		if output := themes(chess, line, false); !reflect.DeepEqual(output, expectedOutputs[i]) {
+			t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%v", input, output, expectedOutputs[i])
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package puzzle
+
+import (
+	"strconv"
+
+	"chess-go/engine"
+)
+
+// Directions of the moves of the pieces as file and rank steps
+var (
+	knightSteps   = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps     = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	rookSteps     = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	bishopSteps   = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	rookAndBishop = append(append([][2]int{}, rookSteps...), bishopSteps...)
+)
+
+// themes returns the themes of a solution played from a position: "mate"
+// and "mateInN" when it mates, "backRankMate", "fork", "pin" and
+// "promotion"
+func themes(chess *engine.Chess, line []engine.Move, mated bool) []string {
+	solver := chess.Turn()
+	var fork, pin, promotion bool
+
+	for i, move := range line {
+		if _, err := chess.Move(move); err != nil {
+			break
+		}
+		if i%2 == 1 {
+			continue
+		}
+		promotion = promotion || move.IsPromotion()
+		fork = fork || forks(chess, move.To)
+		pin = pin || pins(chess, move.To)
+	}
+
+	var found []string
+	if mated {
+		found = append(found, "mate", "mateIn"+strconv.Itoa((len(line)+1)/2))
+		if backRank(chess, solver) {
+			found = append(found, "backRankMate")
+		}
+	}
+	if fork {
+		found = append(found, "fork")
+	}
+	if pin {
+		found = append(found, "pin")
+	}
+	if promotion {
+		found = append(found, "promotion")
+	}
+	return found
+}
+
+// forks reports whether the piece on a square attacks two pieces of the
+// other color that are each the king, worth more than it or undefended
+func forks(chess *engine.Chess, square engine.Square) bool {
+	piece := chess.PieceAt(square)
+	targets := 0
+	for _, attacked := range attacks(chess, square) {
+		target := chess.PieceAt(attacked)
+		if target == engine.NoPiece || target.Color() == piece.Color() {
+			continue
+		}
+		if target.Type() == engine.King ||
+			engine.PieceValue(target.Type()) > engine.PieceValue(piece.Type()) ||
+			!defended(chess, attacked, target.Color()) {
+			targets++
+		}
+	}
+	return targets >= 2
+}
+
+// pins reports whether the piece on a square pins a piece of the other
+// color to its king, or to a piece behind it worth more than both
+func pins(chess *engine.Chess, square engine.Square) bool {
+	piece := chess.PieceAt(square)
+	for _, step := range slides(piece.Type()) {
+		first := engine.NoPiece
+		for _, next := range ray(square, step) {
+			target := chess.PieceAt(next)
+			if target == engine.NoPiece {
+				continue
+			}
+			if target.Color() == piece.Color() {
+				break
+			}
+			if first == engine.NoPiece {
+				first = target
+				continue
+			}
+			// A piece pinned to a piece worth less than the pinner may move
+			behind := engine.PieceValue(target.Type())
+			if target.Type() == engine.King ||
+				behind > engine.PieceValue(first.Type()) && behind > engine.PieceValue(piece.Type()) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// backRank reports whether the king of the color mated by another one is on
+// its first rank, mated by a rook or a queen on that rank
+func backRank(chess *engine.Chess, solver engine.Color) bool {
+	history := chess.History()
+	if len(history) == 0 {
+		return false
+	}
+	last := history[len(history)-1]
+
+	firstRank := 7
+	if solver == engine.Black {
+		firstRank = 0
+	}
+	checker := chess.PieceAt(last.To).Type()
+	king := chess.KingSquare(solver.Other())
+	return king != engine.NoSquare && king.Rank() == firstRank && last.To.Rank() == firstRank &&
+		(checker == engine.Rook || checker == engine.Queen)
+}
+
+// defended reports whether a piece of a color attacks a square
+func defended(chess *engine.Chess, square engine.Square, color engine.Color) bool {
+	for from := engine.A1; from <= engine.H8; from++ {
+		if piece := chess.PieceAt(from); piece == engine.NoPiece || piece.Color() != color {
+			continue
+		}
+		for _, attacked := range attacks(chess, from) {
+			if attacked == square {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attacks returns the squares attacked by the piece on a square
+func attacks(chess *engine.Chess, square engine.Square) []engine.Square {
+	piece := chess.PieceAt(square)
+	var squares []engine.Square
+
+	switch piece.Type() {
+	case engine.Pawn:
+		forward := 1
+		if piece.Color() == engine.Black {
+			forward = -1
+		}
+		for _, side := range []int{-1, 1} {
+			if next, ok := offset(square, side, forward); ok {
+				squares = append(squares, next)
+			}
+		}
+	case engine.Knight, engine.King:
+		steps := knightSteps
+		if piece.Type() == engine.King {
+			steps = kingSteps
+		}
+		for _, step := range steps {
+			if next, ok := offset(square, step[0], step[1]); ok {
+				squares = append(squares, next)
+			}
+		}
+	default:
+		for _, step := range slides(piece.Type()) {
+			for _, next := range ray(square, step) {
+				squares = append(squares, next)
+				if chess.PieceAt(next) != engine.NoPiece {
+					break
+				}
+			}
+		}
+	}
+	return squares
+}
+
+// slides returns the directions a piece slides in, none for the pieces that
+// step
+func slides(pieceType engine.PieceType) [][2]int {
+	switch pieceType {
+	case engine.Rook:
+		return rookSteps
+	case engine.Bishop:
+		return bishopSteps
+	case engine.Queen:
+		return rookAndBishop
+	}
+	return nil
+}
+
+// ray returns the squares from a square to the edge of the board in a
+// direction, the square excluded
+func ray(square engine.Square, step [2]int) []engine.Square {
+	var squares []engine.Square
+	for next, ok := offset(square, step[0], step[1]); ok; next, ok = offset(next, step[0], step[1]) {
+		squares = append(squares, next)
+	}
+	return squares
+}
+
+// offset returns the square some files and ranks away, if it is on the board
+func offset(square engine.Square, files, ranks int) (engine.Square, bool) {
+	file, rank := square.File()+files, square.Rank()+ranks
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return engine.NoSquare, false
+	}
+	return engine.NewSquare(file, rank), true
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+	"chess-go/puzzle"
+)
+
+// runPuzzles finds the puzzles of the games of PGN databases, writes them
+// in CSV to the standard output and returns the exit code
+func runPuzzles(args []string) int {
+	flags := flag.NewFlagSet("puzzles", flag.ExitOnError)
+	depth := flags.Int("depth", 0, "depth each position is searched to")
+	nodes := flags.Int("nodes", 0, "nodes searched in each position")
+	moveTime := flags.Duration("movetime", 0, "time each position is searched")
+	winning := flags.Int("winning", 300, "least advantage in centipawns of a winning move")
+	others := flags.Int("others", 100, "largest advantage in centipawns the other moves may keep")
+	maxMoves := flags.Int("maxmoves", 4, "largest number of moves of the solver")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go puzzles [flags] GAMES.pgn... > PUZZLES.csv")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	options := puzzle.Options{
+		Limits:   engine.SearchLimits{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime},
+		Winning:  *winning,
+		Others:   *others,
+		MaxMoves: *maxMoves,
+	}
+
+	out := csv.NewWriter(os.Stdout)
+	out.Write([]string{"FEN", "Moves", "SAN", "Themes", "White", "Black", "Date", "Ply"})
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		games, err := pgn.ReadAll(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			return 1
+		}
+
+		for i, game := range games {
+			chess, err := game.Replay()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+				continue
+			}
+			puzzles, err := puzzle.Find(context.Background(), chess, options)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+				continue
+			}
+
+			for _, found := range puzzles {
+				out.Write([]string{
+					found.FEN,
+					strings.Join(found.Moves, " "),
+					strings.Join(found.SAN, " "),
+					strings.Join(found.Themes, " "),
+					game.Tag("White"),
+					game.Tag("Black"),
+					game.Tag("Date"),
+					strconv.Itoa(found.Ply),
+				})
+			}
+			// Puzzles come out game by game as they are found
+			out.Flush()
+		}
+	}
+
+	out.Flush()
+	if err := out.Error(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"chess-go/pgn"
+	"chess-go/ratings"
+)
+
+// runRatings rates the players of PGN databases and returns the exit code
+func runRatings(args []string) int {
+	flags := flag.NewFlagSet("ratings", flag.ExitOnError)
+	k := flags.Float64("k", 0, "K-factor of the Elo ratings, 0 for the K-factors of FIDE")
+	tau := flags.Float64("tau", 0.5, "constraint of the change of Glicko-2 volatility")
+	period := flags.String("period", "month", `Glicko-2 rating period from the Date tag: "day", "month", "year" or "all"`)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go ratings [flags] GAMES.pgn...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	elo := ratings.Elo{K: ratings.FIDEK}
+	if *k > 0 {
+		elo.K = ratings.FixedK(*k)
+	}
+
+	periods := map[string]func(*pgn.Game) string{
+		"day":   ratings.ByDay,
+		"month": ratings.ByMonth,
+		"year":  ratings.ByYear,
+		"all":   nil,
+	}
+	byPeriod, ok := periods[*period]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ratings: unknown period %q\n", *period)
+		return 2
+	}
+
+	var games []*pgn.Game
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		read, err := pgn.ReadAll(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			return 1
+		}
+		games = append(games, read...)
+	}
+
+	ladder := ratings.Recompute(games, elo, ratings.Glicko2{Tau: *tau}, byPeriod)
+	if err := ladder.WriteTable(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+// Package ratings rates players from the results of their games with the Elo
+// and Glicko-2 systems, game by game or from a PGN database
+package ratings
+
+import "math"
+
+// KFactor returns the K-factor of a player from their rating and the number
+// of games they played before
+type KFactor func(rating float64, games int) float64
+
+// FixedK returns a K-factor that is the same for every player
+func FixedK(k float64) KFactor {
+	return func(float64, int) float64 {
+		return k
+	}
+}
+
+// FIDEK is the K-factor of FIDE: 40 for the first 30 games, then 20 until
+// the player reaches 2400 and 10 above
+func FIDEK(rating float64, games int) float64 {
+	switch {
+	case games < 30:
+		return 40
+	case rating < 2400:
+		return 20
+	}
+	return 10
+}
+
+// ExpectedScore returns the points a player is expected to score against an
+// opponent
+func ExpectedScore(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
+
+// Elo rates players with the Elo system
+type Elo struct {
+	// K is the K-factor, 20 for every player when nil
+	K KFactor
+}
+
+// Update returns the rating of a player after scoring 1, 0.5 or 0 points
+// against an opponent, games is the number of games they played before
+func (e Elo) Update(rating, opponent, score float64, games int) float64 {
+	k := 20.0
+	if e.K != nil {
+		k = e.K(rating, games)
+	}
+	return rating + k*(score-ExpectedScore(rating, opponent))
+}
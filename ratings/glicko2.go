@@ -0,0 +1,120 @@
+package ratings
+
+import "math"
+
+const (
+	// glickoScale converts ratings to and from the Glicko-2 scale
+	glickoScale = 173.7178
+	// volatilityTolerance is when the iteration of the volatility stops
+	volatilityTolerance = 0.000001
+)
+
+// Rating is a Glicko-2 rating
+type Rating struct {
+	Rating float64
+	// Deviation is the uncertainty of the rating, RD
+	Deviation float64
+	// Volatility is how much the strength of the player fluctuates
+	Volatility float64
+}
+
+// DefaultRating is the rating of a player who never played
+var DefaultRating = Rating{Rating: 1500, Deviation: 350, Volatility: 0.06}
+
+// Result is a game of a rating period against an opponent, Score is 1, 0.5 or
+// 0 points
+type Result struct {
+	Opponent Rating
+	Score    float64
+}
+
+// Glicko2 rates players with the Glicko-2 system, over rating periods
+type Glicko2 struct {
+	// Tau constrains the change of volatility, 0.5 when zero
+	Tau float64
+}
+
+// Update returns the rating of a player at the end of a rating period in
+// which they played the results, their deviation grows when there are none
+func (g Glicko2) Update(rating Rating, results []Result) Rating {
+	tau := g.Tau
+	if tau == 0 {
+		tau = 0.5
+	}
+
+	mu := (rating.Rating - 1500) / glickoScale
+	phi := rating.Deviation / glickoScale
+	sigma := rating.Volatility
+
+	if len(results) == 0 {
+		rating.Deviation = math.Sqrt(phi*phi+sigma*sigma) * glickoScale
+		return rating
+	}
+
+	// The estimated variance of the rating from the results, and the
+	// improvement they show
+	var variance, improvement float64
+	for _, result := range results {
+		opponentMu := (result.Opponent.Rating - 1500) / glickoScale
+		gPhi := glickoG(result.Opponent.Deviation / glickoScale)
+		expected := 1 / (1 + math.Exp(-gPhi*(mu-opponentMu)))
+
+		variance += gPhi * gPhi * expected * (1 - expected)
+		improvement += gPhi * (result.Score - expected)
+	}
+	variance = 1 / variance
+	delta := variance * improvement
+
+	sigma = volatility(delta, phi, variance, sigma, tau)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/variance)
+	mu += phi * phi * improvement
+
+	return Rating{
+		Rating:     mu*glickoScale + 1500,
+		Deviation:  phi * glickoScale,
+		Volatility: sigma,
+	}
+}
+
+// glickoG weighs a result by the deviation of the opponent
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// volatility returns the new volatility with the Illinois algorithm
+func volatility(delta, phi, variance, sigma, tau float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + variance + ex
+		return ex*(delta*delta-phi*phi-variance-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+variance {
+		upper = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		upper = a - k*tau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > volatilityTolerance {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fC := f(c)
+		if fC*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = c, fC
+	}
+
+	return math.Exp(lower / 2)
+}
@@ -0,0 +1,230 @@
+package ratings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// DefaultElo is the Elo rating of a player who never played
+const DefaultElo = 1500
+
+// ErrNoResult is returned when recording a game that is not finished
+var ErrNoResult = errors.New("ratings: the game has no result")
+
+// Player is a rated player of a ladder
+type Player struct {
+	Name   string
+	Elo    float64
+	Glicko Rating
+
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Games returns the number of games rated of the player
+func (p Player) Games() int {
+	return p.Wins + p.Draws + p.Losses
+}
+
+// Ladder keeps the ratings of players. Elo ratings change after each game,
+// Glicko-2 ratings at the end of each rating period.
+type Ladder struct {
+	Elo    Elo
+	Glicko Glicko2
+
+	players map[string]*Player
+	// period are the results of the rating period in progress
+	period map[string][]Result
+}
+
+// NewLadder returns a ladder without players
+func NewLadder(elo Elo, glicko Glicko2) *Ladder {
+	return &Ladder{
+		Elo:     elo,
+		Glicko:  glicko,
+		players: map[string]*Player{},
+		period:  map[string][]Result{},
+	}
+}
+
+// Player returns a player by name, with the default ratings when they are
+// not on the ladder
+func (l *Ladder) Player(name string) Player {
+	if player, ok := l.players[name]; ok {
+		return *player
+	}
+	return Player{Name: name, Elo: DefaultElo, Glicko: DefaultRating}
+}
+
+// SetRating puts a player on the ladder with an Elo rating, which is also
+// their Glicko-2 rating
+func (l *Ladder) SetRating(name string, elo float64) {
+	player := l.player(name)
+	player.Elo = elo
+	player.Glicko.Rating = elo
+}
+
+// player returns a player of the ladder, adding them when they are new
+func (l *Ladder) player(name string) *Player {
+	player, ok := l.players[name]
+	if !ok {
+		player = &Player{Name: name, Elo: DefaultElo, Glicko: DefaultRating}
+		l.players[name] = player
+	}
+	return player
+}
+
+// Record rates a game between two players
+func (l *Ladder) Record(white, black string, outcome engine.Outcome) error {
+	var score float64
+	switch outcome {
+	case engine.WhiteWon:
+		score = 1
+	case engine.Draw:
+		score = 0.5
+	case engine.BlackWon:
+		score = 0
+	default:
+		return ErrNoResult
+	}
+
+	w, b := l.player(white), l.player(black)
+	l.period[white] = append(l.period[white], Result{Opponent: b.Glicko, Score: score})
+	l.period[black] = append(l.period[black], Result{Opponent: w.Glicko, Score: 1 - score})
+
+	whiteElo := l.Elo.Update(w.Elo, b.Elo, score, w.Games())
+	blackElo := l.Elo.Update(b.Elo, w.Elo, 1-score, b.Games())
+	w.Elo, b.Elo = whiteElo, blackElo
+
+	for _, side := range []struct {
+		player *Player
+		score  float64
+	}{{w, score}, {b, 1 - score}} {
+		switch side.score {
+		case 1:
+			side.player.Wins++
+		case 0:
+			side.player.Losses++
+		default:
+			side.player.Draws++
+		}
+	}
+
+	return nil
+}
+
+// RecordChess rates a game played with engine.Chess
+func (l *Ladder) RecordChess(white, black string, chess *engine.Chess) error {
+	return l.Record(white, black, chess.Outcome())
+}
+
+// RecordGame rates a PGN game from its White, Black and Result tags. Players
+// new to the ladder start with the ratings of the WhiteElo and BlackElo tags
+// when they have one.
+func (l *Ladder) RecordGame(game *pgn.Game) error {
+	outcome, ok := pgn.ParseResult(game.Tag("Result"))
+	if !ok || outcome == engine.NoOutcome {
+		return ErrNoResult
+	}
+
+	for _, color := range []string{"White", "Black"} {
+		name := game.Tag(color)
+		if _, ok := l.players[name]; ok {
+			continue
+		}
+		if elo, err := strconv.Atoi(game.Tag(color + "Elo")); err == nil && elo > 0 {
+			l.SetRating(name, float64(elo))
+		}
+	}
+
+	return l.Record(game.Tag("White"), game.Tag("Black"), outcome)
+}
+
+// EndPeriod ends the rating period, updating the Glicko-2 ratings of every
+// player from their results in it
+func (l *Ladder) EndPeriod() {
+	for name, player := range l.players {
+		player.Glicko = l.Glicko.Update(player.Glicko, l.period[name])
+	}
+	l.period = map[string][]Result{}
+}
+
+// Players returns the players of the ladder by Elo rating, best first
+func (l *Ladder) Players() []Player {
+	var players []Player
+	for _, player := range l.players {
+		players = append(players, *player)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Elo != players[j].Elo {
+			return players[i].Elo > players[j].Elo
+		}
+		return players[i].Name < players[j].Name
+	})
+	return players
+}
+
+// WriteTable writes the players of the ladder as a table
+func (l *Ladder) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tName\tElo\tGlicko-2\tRD\tGames\tW\tD\tL")
+
+	for i, player := range l.Players() {
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.0f\t%.0f\t%d\t%d\t%d\t%d\n", i+1, player.Name,
+			player.Elo, player.Glicko.Rating, player.Glicko.Deviation,
+			player.Games(), player.Wins, player.Draws, player.Losses)
+	}
+
+	return tw.Flush()
+}
+
+// Recompute rates the games of a PGN database in order on a new ladder.
+// period returns the rating period of a game, a period ends when it changes;
+// all the games are one period when it is nil. Unfinished games are skipped.
+func Recompute(games []*pgn.Game, elo Elo, glicko Glicko2, period func(*pgn.Game) string) *Ladder {
+	ladder := NewLadder(elo, glicko)
+
+	for i, game := range games {
+		if period != nil && i > 0 && period(game) != period(games[i-1]) {
+			ladder.EndPeriod()
+		}
+		ladder.RecordGame(game)
+	}
+	ladder.EndPeriod()
+
+	return ladder
+}
+
+// ByDay makes a rating period of the games of a day from their Date tag
+func ByDay(game *pgn.Game) string {
+	return datePrefix(game, 10)
+}
+
+// ByMonth makes a rating period of the games of a month
+func ByMonth(game *pgn.Game) string {
+	return datePrefix(game, 7)
+}
+
+// ByYear makes a rating period of the games of a year
+func ByYear(game *pgn.Game) string {
+	return datePrefix(game, 4)
+}
+
+// datePrefix returns the start of the Date tag of a game
+func datePrefix(game *pgn.Game, length int) string {
+	date := strings.TrimSpace(game.Tag("Date"))
+	if len(date) < length {
+		return date
+	}
+	return date[:length]
+}
@@ -0,0 +1,125 @@
+package ratings
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+func TestRatings_Elo(t *testing.T) {
+	inputs := []struct {
+		k                       KFactor
+		rating, opponent, score float64
+		games                   int
+	}{
+		{nil, 1500, 1500, 1, 10},
+		{FixedK(32), 1500, 1700, 1, 100},
+		{FIDEK, 1500, 1500, 0.5, 0},
+		{FIDEK, 2000, 1600, 0, 5},
+		{FIDEK, 2100, 2300, 1, 50},
+		{FIDEK, 2500, 2500, 0, 50},
+	}
+
+	expectedOutputs := []float64{1510, 1524.31, 1500, 1963.64, 2115.20, 2495}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := Elo{K: input.k}.Update(input.rating, input.opponent, input.score, input.games)
+		if math.Abs(output-expected) > 0.01 {
+			t.Errorf("FAILED: %+v\n\tgot:     %.2f\n\texpected:%.2f", input, output, expected)
+		}
+	}
+}
+func TestRatings_Glicko2(t *testing.T) {
+	// The example of Glickman's description of the Glicko-2 system
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	inputs := [][]Result{
+		{
+			{Opponent: Rating{Rating: 1400, Deviation: 30}, Score: 1},
+			{Opponent: Rating{Rating: 1550, Deviation: 100}, Score: 0},
+			{Opponent: Rating{Rating: 1700, Deviation: 300}, Score: 0},
+		},
+		nil,
+	}
+
+	expectedOutputs := []Rating{
+		{Rating: 1464.06, Deviation: 151.52, Volatility: 0.05999},
+		{Rating: 1500, Deviation: 200.27, Volatility: 0.06},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := Glicko2{}.Update(player, input)
+		if math.Abs(output.Rating-expected.Rating) > 0.01 || math.Abs(output.Deviation-expected.Deviation) > 0.01 ||
+			math.Abs(output.Volatility-expected.Volatility) > 0.00001 {
+			t.Errorf("FAILED: %+v\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}
+func TestRatings_Recompute(t *testing.T) {
+	database := `[White "Ann"]
+[Black "Bob"]
+[Date "2024.01.10"]
+[WhiteElo "1800"]
+[BlackElo "1600"]
+[Result "1-0"]
+
+1-0
+
+[White "Bob"]
+[Black "Cid"]
+[Date "2024.01.20"]
+[WhiteElo "1700"]
+[Result "1/2-1/2"]
+
+1/2-1/2
+
+[White "Cid"]
+[Black "Ann"]
+[Date "2024.02.02"]
+[Result "*"]
+
+*
+
+[White "Cid"]
+[Black "Ann"]
+[Date "2024.02.03"]
+[Result "1-0"]
+
+1-0
+`
+	games, err := pgn.ReadAll(strings.NewReader(database))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	ladder := Recompute(games, Elo{K: FixedK(32)}, Glicko2{}, ByMonth)
+
+	var out bytes.Buffer
+	ladder.WriteTable(&out)
+	expected := `#  Name  Elo   Glicko-2  RD   Games  W  D  L
+1  Ann   1780  1654      269  2      1  0  1
+2  Bob   1588  1494      259  2      0  1  1
+3  Cid   1531  1776      265  2      1  1  0
+`
+	if out.String() != expected {
+		t.Errorf("FAILED\n\tgot:     \n%s\n\texpected:\n%s", out.String(), expected)
+	}
+
+	chess := engine.NewGameChess()
+	if err := ladder.RecordChess("Ann", "Bob", chess); !errors.Is(err, ErrNoResult) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrNoResult)
+	}
+	for _, move := range []string{"f3", "e5", "g4", "Qh4#"} {
+		chess.MovePGN(move)
+	}
+	ladder.RecordChess("Ann", "Bob", chess)
+	if bob := ladder.Player("Bob"); bob.Games() != 3 || bob.Elo <= 1588 {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", bob, "a win of Bob")
+	}
+}
@@ -0,0 +1,32 @@
+package render
+
+import (
+	"fmt"
+	"io"
+
+	"chess-go/engine"
+)
+
+// LaTeX draws the board as a diagram of the skak package, which xskak
+// extends, from the FEN of the position
+type LaTeX struct {
+	// Flipped draws the board from the side of black
+	Flipped bool
+	// Coordinates writes the ranks and the files around the board
+	Coordinates bool
+}
+
+// Render writes the diagram of a game
+func (l LaTeX) Render(w io.Writer, chess *engine.Chess) error {
+	notation := `\notationOff`
+	if l.Coordinates {
+		notation = `\notationOn`
+	}
+	show := `\showboard`
+	if l.Flipped {
+		show = `\showinverseboard`
+	}
+
+	_, err := fmt.Fprintf(w, "\\fenboard{%s}\n%s\n%s\n", chess.GetFEN(), notation, show)
+	return err
+}
@@ -0,0 +1,489 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"chess-go/engine"
+)
+
+// spriteSize is the size of the sprites of the pieces in pixels, the squares
+// are a multiple of it
+const spriteSize = 16
+
+// sprites are the pieces of raster images, 'x' is the outline and 'o' the
+// body painted in the color of the piece
+var sprites = map[engine.PieceType][spriteSize]string{
+	engine.Pawn: {
+		"................",
+		"................",
+		"................",
+		"......xxxx......",
+		".....xoooox.....",
+		".....xoooox.....",
+		"......xoox......",
+		".....xoooox.....",
+		"......xoox......",
+		"......xoox......",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+	engine.Knight: {
+		"................",
+		"................",
+		".......xx.......",
+		"......xoxxx.....",
+		".....xoooooxx...",
+		"....xooxooooox..",
+		"...xoooooooooox.",
+		"...xoooxxxxooox.",
+		"....xxx..xoooox.",
+		"........xoooox..",
+		".......xoooox...",
+		"......xooooox...",
+		".....xoooooooox.",
+		".....xxxxxxxxxx.",
+		"................",
+		"................",
+	},
+	engine.Bishop: {
+		"................",
+		".......xx.......",
+		"......xoox......",
+		".....xooxox.....",
+		"....xooxooox....",
+		"....xoxoooox....",
+		"....xoooooox....",
+		".....xoooox.....",
+		"......xoox......",
+		".....xoooox.....",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+	engine.Rook: {
+		"................",
+		"................",
+		"...xx.xxxx.xx...",
+		"...xoxxooxxox...",
+		"...xoooooooox...",
+		"....xoooooox....",
+		".....xoooox.....",
+		".....xoooox.....",
+		".....xoooox.....",
+		".....xoooox.....",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+	engine.Queen: {
+		"................",
+		"..x....xx....x..",
+		".xox..xoox..xox.",
+		"..xox.xoox.xox..",
+		"..xoxxxooxxxox..",
+		"...xoooooooox...",
+		"...xoooooooox...",
+		"....xoooooox....",
+		".....xoooox.....",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"..xoooooooooox..",
+		"..xxxxxxxxxxxx..",
+		"................",
+		"................",
+	},
+	engine.King: {
+		".......xx.......",
+		"......xoox......",
+		".....xxooxx.....",
+		"......xoox......",
+		"...xxxxxxxxxx...",
+		"..xoooooooooox..",
+		"..xoooooooooox..",
+		"...xoooooooox...",
+		"....xoooooox....",
+		".....xoooox.....",
+		".....xoooox.....",
+		"....xoooooox....",
+		"...xoooooooox...",
+		"...xxxxxxxxxx...",
+		"................",
+		"................",
+	},
+}
+
+// glyphs are the characters of the captions, 5 by 7 pixels. They cover the
+// notation of moves, other characters are left blank.
+var glyphs = map[rune][7]string{
+	'0': {".xxx.", "x...x", "x..xx", "x.x.x", "xx..x", "x...x", ".xxx."},
+	'1': {"..x..", ".xx..", "..x..", "..x..", "..x..", "..x..", ".xxx."},
+	'2': {".xxx.", "x...x", "....x", "...x.", "..x..", ".x...", "xxxxx"},
+	'3': {"xxxxx", "...x.", "..x..", "...x.", "....x", "x...x", ".xxx."},
+	'4': {"...x.", "..xx.", ".x.x.", "x..x.", "xxxxx", "...x.", "...x."},
+	'5': {"xxxxx", "x....", "xxxx.", "....x", "....x", "x...x", ".xxx."},
+	'6': {"..xx.", ".x...", "x....", "xxxx.", "x...x", "x...x", ".xxx."},
+	'7': {"xxxxx", "....x", "...x.", "..x..", ".x...", ".x...", ".x..."},
+	'8': {".xxx.", "x...x", "x...x", ".xxx.", "x...x", "x...x", ".xxx."},
+	'9': {".xxx.", "x...x", "x...x", ".xxxx", "....x", "...x.", ".xx.."},
+	'a': {".....", ".....", ".xxx.", "....x", ".xxxx", "x...x", ".xxxx"},
+	'b': {"x....", "x....", "x.xx.", "xx..x", "x...x", "x...x", "xxxx."},
+	'c': {".....", ".....", ".xxx.", "x....", "x....", "x...x", ".xxx."},
+	'd': {"....x", "....x", ".xx.x", "x..xx", "x...x", "x...x", ".xxxx"},
+	'e': {".....", ".....", ".xxx.", "x...x", "xxxxx", "x....", ".xxx."},
+	'f': {"..xx.", ".x..x", ".x...", "xxx..", ".x...", ".x...", ".x..."},
+	'g': {".....", ".xxxx", "x...x", "x...x", ".xxxx", "....x", ".xxx."},
+	'h': {"x....", "x....", "x.xx.", "xx..x", "x...x", "x...x", "x...x"},
+	'x': {".....", ".....", "x...x", ".x.x.", "..x..", ".x.x.", "x...x"},
+	'K': {"x...x", "x..x.", "x.x..", "xx...", "x.x..", "x..x.", "x...x"},
+	'Q': {".xxx.", "x...x", "x...x", "x...x", "x.x.x", "x..x.", ".xx.x"},
+	'R': {"xxxx.", "x...x", "x...x", "xxxx.", "x.x..", "x..x.", "x...x"},
+	'B': {"xxxx.", "x...x", "x...x", "xxxx.", "x...x", "x...x", "xxxx."},
+	'N': {"x...x", "x...x", "xx..x", "x.x.x", "x..xx", "x...x", "x...x"},
+	'O': {".xxx.", "x...x", "x...x", "x...x", "x...x", "x...x", ".xxx."},
+	'+': {".....", "..x..", "..x..", "xxxxx", "..x..", "..x..", "....."},
+	'#': {".x.x.", ".x.x.", "xxxxx", ".x.x.", "xxxxx", ".x.x.", ".x.x."},
+	'=': {".....", ".....", "xxxxx", ".....", "xxxxx", ".....", "....."},
+	'-': {".....", ".....", ".....", "xxxxx", ".....", ".....", "....."},
+	'.': {".....", ".....", ".....", ".....", ".....", ".xx..", ".xx.."},
+	'/': {".....", "....x", "...x.", "..x..", ".x...", "x....", "....."},
+	'!': {"..x..", "..x..", "..x..", "..x..", "..x..", ".....", "..x.."},
+	'?': {".xxx.", "x...x", "....x", "...x.", "..x..", ".....", "..x.."},
+}
+
+// Colors of the pieces of raster images
+var (
+	outline   = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	whiteBody = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	blackBody = color.RGBA{0x33, 0x33, 0x33, 0xff}
+)
+
+// Raster draws the board as an image with the standard library only
+type Raster struct {
+	// SquareSize is the size of a square in pixels, rounded down to a
+	// multiple of 16 and at least 16, 48 when zero
+	SquareSize int
+	// Colors are DefaultColors when Light is empty, they must be written
+	// "#rgb" or "#rrggbb"
+	Colors Colors
+	// Flipped draws the board from the side of black
+	Flipped bool
+	// LastMove highlights the squares of the last move, Check the king in
+	// check
+	LastMove bool
+	Check    bool
+}
+
+// rasterColors are the parsed colors of a raster image
+type rasterColors struct {
+	light, dark, lastMove, check, text color.RGBA
+}
+
+// Image returns the image of the board
+func (r Raster) Image(chess *engine.Chess) (*image.RGBA, error) {
+	colors, err := r.colors()
+	if err != nil {
+		return nil, err
+	}
+	size := r.squareSize()
+	img := image.NewRGBA(image.Rect(0, 0, 8*size, 8*size))
+	r.draw(img, chess, colors)
+	return img, nil
+}
+
+// squareSize returns the size of a square in pixels
+func (r Raster) squareSize() int {
+	if r.SquareSize < spriteSize {
+		if r.SquareSize <= 0 {
+			return 3 * spriteSize
+		}
+		return spriteSize
+	}
+	return r.SquareSize / spriteSize * spriteSize
+}
+
+// colors parses the colors of the image
+func (r Raster) colors() (rasterColors, error) {
+	colors := r.Colors
+	if colors.Light == "" {
+		colors = DefaultColors
+	}
+
+	var parsed rasterColors
+	for _, c := range []struct {
+		value string
+		color *color.RGBA
+	}{
+		{colors.Light, &parsed.light},
+		{colors.Dark, &parsed.dark},
+		{or(colors.LastMove, DefaultColors.LastMove), &parsed.lastMove},
+		{or(colors.Check, DefaultColors.Check), &parsed.check},
+		{or(colors.Coordinates, DefaultColors.Coordinates), &parsed.text},
+	} {
+		var err error
+		if *c.color, err = parseHex(c.value); err != nil {
+			return rasterColors{}, err
+		}
+	}
+	return parsed, nil
+}
+
+// draw draws the board in the top left corner of an image
+func (r Raster) draw(img *image.RGBA, chess *engine.Chess, colors rasterColors) {
+	size := r.squareSize()
+
+	var lastMove []engine.Square
+	if history := chess.History(); r.LastMove && len(history) > 0 {
+		lastMove = []engine.Square{history[len(history)-1].From, history[len(history)-1].To}
+	}
+	checked := engine.NoSquare
+	if r.Check && chess.InCheck() {
+		checked = chess.KingSquare(chess.Turn())
+	}
+
+	for square := engine.A1; square <= engine.H8; square++ {
+		column, row := square.File(), 7-square.Rank()
+		if r.Flipped {
+			column, row = 7-column, 7-row
+		}
+		rect := image.Rect(column*size, row*size, (column+1)*size, (row+1)*size)
+
+		fill := colors.dark
+		if (square.File()+square.Rank())%2 == 1 {
+			fill = colors.light
+		}
+		for _, s := range lastMove {
+			if s == square {
+				fill = blend(fill, colors.lastMove, 0.5)
+			}
+		}
+		if square == checked {
+			fill = blend(fill, colors.check, 0.6)
+		}
+		draw.Draw(img, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+
+		if piece := chess.PieceAt(square); piece != engine.NoPiece {
+			drawSprite(img, rect.Min, size/spriteSize, piece)
+		}
+	}
+}
+
+// drawSprite draws a piece scaled from its sprite
+func drawSprite(img *image.RGBA, at image.Point, scale int, piece engine.Piece) {
+	body := whiteBody
+	if piece.Color() == engine.Black {
+		body = blackBody
+	}
+
+	for y, line := range sprites[piece.Type()] {
+		for x, pixel := range line {
+			var c color.RGBA
+			switch pixel {
+			case 'x':
+				c = outline
+			case 'o':
+				c = body
+			default:
+				continue
+			}
+			rect := image.Rect(at.X+x*scale, at.Y+y*scale, at.X+(x+1)*scale, at.Y+(y+1)*scale)
+			draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+}
+
+// drawText writes a caption in the glyphs scaled by scale
+func drawText(img *image.RGBA, at image.Point, scale int, text string, c color.RGBA) {
+	for i, char := range text {
+		glyph, ok := glyphs[char]
+		if !ok {
+			continue
+		}
+		for y, line := range glyph {
+			for x, pixel := range line {
+				if pixel != 'x' {
+					continue
+				}
+				left := at.X + (i*6+x)*scale
+				rect := image.Rect(left, at.Y+y*scale, left+scale, at.Y+(y+1)*scale)
+				draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
+
+// blend lays a color over another with an opacity
+func blend(under, over color.RGBA, opacity float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-opacity) + float64(b)*opacity + 0.5)
+	}
+	return color.RGBA{mix(under.R, over.R), mix(under.G, over.G), mix(under.B, over.B), 0xff}
+}
+
+// parseHex parses a color written "#rgb" or "#rrggbb"
+func parseHex(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if !ok || len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("render: invalid color %q, expected #rgb or #rrggbb", s)
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, nil
+}
+
+// PNG draws the board as a PNG image
+type PNG struct {
+	Raster
+}
+
+// Render writes the image of a game
+func (p PNG) Render(w io.Writer, chess *engine.Chess) error {
+	img, err := p.Image(chess)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// GIF animates the moves of a game from its starting position, the board
+// is drawn like Raster draws it
+type GIF struct {
+	Raster
+	// Delay is how long each position is shown, one second when zero
+	Delay time.Duration
+	// Delays are the delays of the positions when they have one, the
+	// starting position first
+	Delays []time.Duration
+	// FinalDelay is how long the last position is shown, three seconds when
+	// zero
+	FinalDelay time.Duration
+	// Captions writes the last move under the board
+	Captions bool
+	// Loop plays the animation forever instead of once
+	Loop bool
+}
+
+// Render writes the animation of a game
+func (g GIF) Render(w io.Writer, chess *engine.Chess) error {
+	colors, err := g.colors()
+	if err != nil {
+		return err
+	}
+	position, err := engine.NewChessGameWithFen(chess.StartingFEN())
+	if err != nil {
+		return err
+	}
+
+	size := g.squareSize()
+	scale := size / spriteSize
+	bounds := image.Rect(0, 0, 8*size, 8*size)
+	if g.Captions {
+		bounds.Max.Y += 11 * scale
+	}
+
+	// The frames are drawn in full colors, their palette is made once all
+	// are drawn
+	history := chess.History()
+	frames := make([]*image.RGBA, 0, len(history)+1)
+	for ply := 0; ply <= len(history); ply++ {
+		caption := ""
+		if ply > 0 {
+			result, err := position.Move(history[ply-1])
+			if err != nil {
+				return err
+			}
+			caption = moveNumber(position) + result.SAN
+		}
+
+		frame := image.NewRGBA(bounds)
+		draw.Draw(frame, bounds, image.NewUniform(colors.light), image.Point{}, draw.Src)
+		g.draw(frame, position, colors)
+		if g.Captions {
+			drawText(frame, image.Pt(2*scale, 8*size+2*scale), scale, caption, colors.text)
+		}
+		frames = append(frames, frame)
+	}
+
+	animation := &gif.GIF{LoopCount: -1}
+	if g.Loop {
+		animation.LoopCount = 0
+	}
+	framePalette := paletteOf(frames)
+	for i, frame := range frames {
+		paletted := image.NewPaletted(bounds, framePalette)
+		draw.Draw(paletted, bounds, frame, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, int(g.delay(i, len(frames))/(10*time.Millisecond)))
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+// delay returns how long a frame is shown
+func (g GIF) delay(frame, frames int) time.Duration {
+	if frame < len(g.Delays) && g.Delays[frame] > 0 {
+		return g.Delays[frame]
+	}
+	if frame == frames-1 {
+		if g.FinalDelay > 0 {
+			return g.FinalDelay
+		}
+		return 3 * time.Second
+	}
+	if g.Delay > 0 {
+		return g.Delay
+	}
+	return time.Second
+}
+
+// moveNumber returns the number written before the move that led to a
+// position, "12." for white and "12..." for black
+func moveNumber(position *engine.Chess) string {
+	if position.Turn() == engine.Black {
+		return strconv.Itoa(position.FullMoves()) + ". "
+	}
+	return strconv.Itoa(position.FullMoves()-1) + "... "
+}
+
+// paletteOf returns the colors of the frames, or a web palette when they
+// have more than a GIF can hold
+func paletteOf(frames []*image.RGBA) color.Palette {
+	seen := map[color.RGBA]bool{}
+	var colors color.Palette
+	for _, frame := range frames {
+		for i := 0; i < len(frame.Pix); i += 4 {
+			c := color.RGBA{frame.Pix[i], frame.Pix[i+1], frame.Pix[i+2], frame.Pix[i+3]}
+			if seen[c] {
+				continue
+			}
+			if len(colors) == 256 {
+				return palette.WebSafe
+			}
+			seen[c] = true
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
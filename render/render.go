@@ -0,0 +1,186 @@
+// Package render draws the board of engine.Chess for terminals and documents
+package render
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"chess-go/engine"
+)
+
+// ANSI escape sequences of the colored board
+const (
+	reset       = "\x1b[0m"
+	lightSquare = "\x1b[48;5;180m"
+	darkSquare  = "\x1b[48;5;137m"
+	lightMoved  = "\x1b[48;5;186m"
+	darkMoved   = "\x1b[48;5;143m"
+	checkSquare = "\x1b[48;5;167m"
+	whitePiece  = "\x1b[1;97m"
+	blackPiece  = "\x1b[1;30m"
+)
+
+// symbols are the Unicode pieces, the outlined ones are white
+var symbols = map[engine.Piece]string{
+	engine.WhiteKing: "♔", engine.WhiteQueen: "♕", engine.WhiteRook: "♖",
+	engine.WhiteBishop: "♗", engine.WhiteKnight: "♘", engine.WhitePawn: "♙",
+	engine.BlackKing: "♚", engine.BlackQueen: "♛", engine.BlackRook: "♜",
+	engine.BlackBishop: "♝", engine.BlackKnight: "♞", engine.BlackPawn: "♟",
+}
+
+// Renderer draws the board of a game
+type Renderer interface {
+	Render(w io.Writer, chess *engine.Chess) error
+}
+
+// ASCII draws the board in plain text with borders around the squares and
+// the FEN letters of the pieces
+type ASCII struct {
+	// Flipped draws the board from the side of black
+	Flipped bool
+	// Coordinates writes the ranks on the left and the files below
+	Coordinates bool
+}
+
+// Render writes the board of a game
+func (a ASCII) Render(w io.Writer, chess *engine.Chess) error {
+	bw := bufio.NewWriter(w)
+	margin := ""
+	if a.Coordinates {
+		margin = "  "
+	}
+	border := margin + strings.Repeat("+---", 8) + "+\n"
+
+	bw.WriteString(border)
+	for row := 0; row < 8; row++ {
+		if a.Coordinates {
+			bw.WriteString(rankLabel(row, a.Flipped) + " ")
+		}
+		for column := 0; column < 8; column++ {
+			letter := " "
+			if piece := chess.PieceAt(orient(row, column, a.Flipped)); piece != engine.NoPiece {
+				letter = piece.String()
+			}
+			bw.WriteString("| " + letter + " ")
+		}
+		bw.WriteString("|\n")
+		bw.WriteString(border)
+	}
+
+	if a.Coordinates {
+		files := margin
+		for column := 0; column < 8; column++ {
+			files += "  " + fileLabel(column, a.Flipped) + " "
+		}
+		bw.WriteString(strings.TrimRight(files, " ") + "\n")
+	}
+	return bw.Flush()
+}
+
+// Unicode draws the board with the Unicode chess symbols, a line per rank
+type Unicode struct {
+	// Flipped draws the board from the side of black
+	Flipped bool
+	// Coordinates writes the ranks on the left and the files below
+	Coordinates bool
+	// Color paints the squares with ANSI colors, highlighting the last move
+	// and a king in check
+	Color bool
+}
+
+// Render writes the board of a game
+func (u Unicode) Render(w io.Writer, chess *engine.Chess) error {
+	bw := bufio.NewWriter(w)
+
+	lastMove := engine.Move{From: engine.NoSquare, To: engine.NoSquare}
+	if history := chess.History(); len(history) > 0 {
+		lastMove = history[len(history)-1]
+	}
+	checked := engine.NoSquare
+	if chess.InCheck() {
+		checked = chess.KingSquare(chess.Turn())
+	}
+
+	for row := 0; row < 8; row++ {
+		if u.Coordinates {
+			bw.WriteString(rankLabel(row, u.Flipped) + " ")
+		}
+
+		for column := 0; column < 8; column++ {
+			square := orient(row, column, u.Flipped)
+			piece := chess.PieceAt(square)
+
+			if !u.Color {
+				if column > 0 {
+					bw.WriteString(" ")
+				}
+				if piece == engine.NoPiece {
+					bw.WriteString("·")
+				} else {
+					bw.WriteString(symbols[piece])
+				}
+				continue
+			}
+
+			light := (square.File()+square.Rank())%2 == 1
+			switch {
+			case square == checked:
+				bw.WriteString(checkSquare)
+			case square == lastMove.From || square == lastMove.To:
+				bw.WriteString(pick(light, lightMoved, darkMoved))
+			default:
+				bw.WriteString(pick(light, lightSquare, darkSquare))
+			}
+			if piece == engine.NoPiece {
+				bw.WriteString("   ")
+				continue
+			}
+			// Both colors use the filled pieces, told apart by their color
+			bw.WriteString(pick(piece.Color() == engine.White, whitePiece, blackPiece))
+			bw.WriteString(" " + symbols[engine.NewPiece(engine.Black, piece.Type())] + " ")
+		}
+
+		if u.Color {
+			bw.WriteString(reset)
+		}
+		bw.WriteString("\n")
+	}
+
+	if u.Coordinates {
+		bw.WriteString(" ")
+		for column := 0; column < 8; column++ {
+			bw.WriteString(pick(u.Color, "  ", " ") + fileLabel(column, u.Flipped))
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+// orient returns the square drawn on a row and a column, counted from the
+// top left corner
+func orient(row, column int, flipped bool) engine.Square {
+	if flipped {
+		return engine.NewSquare(7-column, row)
+	}
+	return engine.NewSquare(column, 7-row)
+}
+
+// rankLabel returns the rank of a row
+func rankLabel(row int, flipped bool) string {
+	return string(rune('1' + orient(row, 0, flipped).Rank()))
+}
+
+// fileLabel returns the file of a column
+func fileLabel(column int, flipped bool) string {
+	return string(rune('a' + orient(0, column, flipped).File()))
+}
+
+// pick returns a when the condition holds and b otherwise
+func pick(condition bool, a, b string) string {
+	if condition {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,311 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+)
+
+func TestRender_Unicode(t *testing.T) {
+	chess := engine.NewGameChess()
+	chess.MovePGN("e4")
+
+	inputs := []Unicode{{Coordinates: true}, {Flipped: true, Coordinates: true}, {}}
+
+	expectedOutputs := []string{
+		"8 ♜ ♞ ♝ ♛ ♚ ♝ ♞ ♜\n" +
+			"7 ♟ ♟ ♟ ♟ ♟ ♟ ♟ ♟\n" +
+			"6 · · · · · · · ·\n" +
+			"5 · · · · · · · ·\n" +
+			"4 · · · · ♙ · · ·\n" +
+			"3 · · · · · · · ·\n" +
+			"2 ♙ ♙ ♙ ♙ · ♙ ♙ ♙\n" +
+			"1 ♖ ♘ ♗ ♕ ♔ ♗ ♘ ♖\n" +
+			"  a b c d e f g h\n",
+		"1 ♖ ♘ ♗ ♔ ♕ ♗ ♘ ♖\n" +
+			"2 ♙ ♙ ♙ · ♙ ♙ ♙ ♙\n" +
+			"3 · · · · · · · ·\n" +
+			"4 · · · ♙ · · · ·\n" +
+			"5 · · · · · · · ·\n" +
+			"6 · · · · · · · ·\n" +
+			"7 ♟ ♟ ♟ ♟ ♟ ♟ ♟ ♟\n" +
+			"8 ♜ ♞ ♝ ♚ ♛ ♝ ♞ ♜\n" +
+			"  h g f e d c b a\n",
+		"♜ ♞ ♝ ♛ ♚ ♝ ♞ ♜\n" +
+			"♟ ♟ ♟ ♟ ♟ ♟ ♟ ♟\n" +
+			"· · · · · · · ·\n" +
+			"· · · · · · · ·\n" +
+			"· · · · ♙ · · ·\n" +
+			"· · · · · · · ·\n" +
+			"♙ ♙ ♙ ♙ · ♙ ♙ ♙\n" +
+			"♖ ♘ ♗ ♕ ♔ ♗ ♘ ♖\n",
+	}
+
+	for i, input := range inputs {
+		var output strings.Builder
+		input.Render(&output, chess)
+		if output.String() != expectedOutputs[i] {
+			t.Errorf("FAILED: %+v\n\tgot:\n%s\n\texpected:\n%s", input, output.String(), expectedOutputs[i])
+		}
+	}
+}
+func TestRender_Highlights(t *testing.T) {
+	chess, _ := engine.NewChessGameWithFen("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	chess.MovePGN("Rh8+")
+
+	var output strings.Builder
+	Unicode{Color: true}.Render(&output, chess)
+	lines := strings.Split(output.String(), "\n")
+
+	// The king in check and both squares of the last move are highlighted
+	inputs := []struct {
+		line   int
+		square string
+	}{
+		{0, checkSquare + blackPiece + " ♚ "},
+		{0, darkMoved + whitePiece + " ♜ "},
+		{7, lightMoved + "   "},
+		{7, darkSquare + whitePiece + " ♚ "},
+	}
+
+	for _, input := range inputs {
+		if !strings.Contains(lines[input.line], input.square) {
+			t.Errorf("FAILED: %q\n\tgot:     %q\n\texpected:%q", input.square, lines[input.line], input.square)
+		}
+	}
+}
+func TestRender_ASCII(t *testing.T) {
+	chess, _ := engine.NewChessGameWithFen("4k3/8/8/8/8/8/8/R3K3 w Q - 0 1")
+
+	inputs := []ASCII{{Coordinates: true}, {Flipped: true}}
+
+	expectedOutputs := []string{
+		"  +---+---+---+---+---+---+---+---+\n" +
+			"8 |   |   |   |   | k |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"7 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"6 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"5 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"4 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"3 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"2 |   |   |   |   |   |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"1 | R |   |   |   | K |   |   |   |\n" +
+			"  +---+---+---+---+---+---+---+---+\n" +
+			"    a   b   c   d   e   f   g   h\n",
+		"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   | K |   |   |   | R |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   |   |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n" +
+			"|   |   |   | k |   |   |   |   |\n" +
+			"+---+---+---+---+---+---+---+---+\n",
+	}
+
+	for i, input := range inputs {
+		var output strings.Builder
+		input.Render(&output, chess)
+		if output.String() != expectedOutputs[i] {
+			t.Errorf("FAILED: %+v\n\tgot:\n%s\n\texpected:\n%s", input, output.String(), expectedOutputs[i])
+		}
+	}
+}
+func TestRender_LaTeX(t *testing.T) {
+	chess := engine.NewGameChess()
+	chess.MovePGN("e4")
+
+	inputs := []LaTeX{{}, {Flipped: true, Coordinates: true}}
+
+	expectedOutputs := []string{
+		"\\fenboard{rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1}\n\\notationOff\n\\showboard\n",
+		"\\fenboard{rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1}\n\\notationOn\n\\showinverseboard\n",
+	}
+
+	for i, input := range inputs {
+		var output strings.Builder
+		input.Render(&output, chess)
+		if output.String() != expectedOutputs[i] {
+			t.Errorf("FAILED: %+v\n\tgot:     %q\n\texpected:%q", input, output.String(), expectedOutputs[i])
+		}
+	}
+
+	// Every format is a renderer
+	for _, renderer := range []Renderer{ASCII{}, Unicode{}, LaTeX{}, SVG{}, PNG{}, GIF{}} {
+		if err := renderer.Render(io.Discard, chess); err != nil {
+			t.Errorf("FAILED: %T\n\tgot:     %s\n\texpected:%v", renderer, err, nil)
+		}
+	}
+}
+func TestRender_SVG(t *testing.T) {
+	chess := engine.NewGameChess()
+	chess.MovePGN("e4")
+	chess.MovePGN("f6")
+	chess.MovePGN("Qh5")
+
+	inputs := []SVG{
+		{},
+		{SquareSize: 10, Flipped: true, Coordinates: true, Pieces: LetterPieces},
+		{LastMove: true, Check: true, Colors: Colors{Light: "#eee", Dark: "#999", LastMove: "yellow", Check: "red"}},
+		{
+			Highlights: []Highlight{{Square: engine.D4, Color: "blue"}},
+			Circles:    []Circle{{Square: engine.E8}},
+			Arrows:     []Arrow{{From: engine.H5, To: engine.E8, Color: "red"}},
+		},
+	}
+
+	expectedOutputs := [][]string{
+		{`width="360" height="360"`, `<rect x="0" y="315" width="45" height="45" fill="#b58863"/>`, `<g transform="translate(315 135) scale(1)"><text`},
+		{`width="90" height="90"`, `<text x="80" y="87.5"`, `>a</text>`, `<g transform="translate(5 45) scale(0.22)"><circle cx="22.5" cy="22.5" r="18" fill="#fff"`, `fill="#000">Q</text>`},
+		{`<rect x="315" y="135" width="45" height="45" fill="yellow" opacity="0.5"/>`, `<rect x="180" y="0" width="45" height="45" fill="red" opacity="0.6"/>`},
+		{`<rect x="135" y="180" width="45" height="45" fill="blue" opacity="0.5"/>`, `<circle cx="202.5" cy="22.5" r="19.8" fill="none" stroke="#15781b"`, `<polygon points="339.89,155.11 `},
+	}
+
+	for i, input := range inputs {
+		var output strings.Builder
+		if err := input.Render(&output, chess); err != nil {
+			t.Fatalf("FAILED: diagram %d\n\t%s", i, err)
+		}
+
+		// The diagram is well formed
+		decoder := xml.NewDecoder(strings.NewReader(output.String()))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("FAILED: diagram %d\n\tgot:     %s\n\texpected:%s", i, err, "XML")
+				break
+			}
+		}
+
+		for _, expected := range expectedOutputs[i] {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("FAILED: diagram %d\n\tgot:\n%s\n\texpected:%s", i, output.String(), expected)
+			}
+		}
+	}
+}
+func TestRender_PNG(t *testing.T) {
+	for piece, sprite := range sprites {
+		for _, line := range sprite {
+			if len(line) != spriteSize {
+				t.Errorf("FAILED: sprite %s\n\tgot:     %q\n\texpected:%d pixels", piece, line, spriteSize)
+			}
+		}
+	}
+	for char, glyph := range glyphs {
+		for _, line := range glyph {
+			if len(line) != 5 {
+				t.Errorf("FAILED: glyph %c\n\tgot:     %q\n\texpected:%d pixels", char, line, 5)
+			}
+		}
+	}
+
+	chess := engine.NewGameChess()
+	chess.MovePGN("e4")
+
+	inputs := []PNG{
+		{},
+		{Raster{SquareSize: 20, Flipped: true, LastMove: true, Colors: Colors{Light: "#fff", Dark: "#000000", LastMove: "#ff0000"}}},
+		{Raster{Colors: Colors{Light: "white"}}},
+	}
+
+	// The corner pixels of the a1 and e4 squares, or the error
+	expectedOutputs := []struct {
+		bounds int
+		a1, e4 color.RGBA
+		err    string
+	}{
+		{384, color.RGBA{0xb5, 0x88, 0x63, 0xff}, color.RGBA{0xf0, 0xd9, 0xb5, 0xff}, ""},
+		{128, color.RGBA{0, 0, 0, 0xff}, color.RGBA{0xff, 0x80, 0x80, 0xff}, ""},
+		{0, color.RGBA{}, color.RGBA{}, `render: invalid color "white", expected #rgb or #rrggbb`},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		var output bytes.Buffer
+		err := input.Render(&output, chess)
+		if err != nil || expected.err != "" {
+			if err == nil || err.Error() != expected.err {
+				t.Errorf("FAILED: %+v\n\tgot:     %v\n\texpected:%s", input, err, expected.err)
+			}
+			continue
+		}
+
+		img, err := png.Decode(&output)
+		if err != nil {
+			t.Fatalf("FAILED: %+v\n\t%s", input, err)
+		}
+		size := expected.bounds / 8
+		a1, e4 := image.Pt(0, 7*size), image.Pt(4*size, 4*size)
+		if input.Flipped {
+			a1, e4 = image.Pt(7*size, 0), image.Pt(3*size, 3*size)
+		}
+		output1 := color.RGBAModel.Convert(img.At(a1.X, a1.Y))
+		output2 := color.RGBAModel.Convert(img.At(e4.X, e4.Y))
+		if img.Bounds().Dx() != expected.bounds || output1 != expected.a1 || output2 != expected.e4 {
+			t.Errorf("FAILED: %+v\n\tgot:     %d %v %v\n\texpected:%d %v %v", input, img.Bounds().Dx(), output1, output2, expected.bounds, expected.a1, expected.e4)
+		}
+	}
+}
+func TestRender_GIF(t *testing.T) {
+	chess := engine.NewGameChess()
+	for _, move := range []string{"f3", "e5", "g4", "Qh4"} {
+		chess.MovePGN(move)
+	}
+
+	input := GIF{Raster: Raster{SquareSize: 16}, Delays: []time.Duration{2 * time.Second}, Captions: true}
+	var output bytes.Buffer
+	if err := input.Render(&output, chess); err != nil {
+		t.Fatalf("FAILED: %+v\n\t%s", input, err)
+	}
+	animation, err := gif.DecodeAll(&output)
+	if err != nil {
+		t.Fatalf("FAILED: %+v\n\t%s", input, err)
+	}
+
+	expected := []int{200, 100, 100, 100, 300}
+	if !reflect.DeepEqual(animation.Delay, expected) || animation.Config.Height != 8*16+11 {
+		t.Errorf("FAILED\n\tgot:     %v %d\n\texpected:%v %d", animation.Delay, animation.Config.Height, expected, 8*16+11)
+	}
+
+	// The start has no caption, the moves have theirs
+	for i, frame := range animation.Image {
+		caption := false
+		for x := 0; x < frame.Bounds().Dx(); x++ {
+			if color.RGBAModel.Convert(frame.At(x, 8*16+5)) == (color.RGBA{0x33, 0x33, 0x33, 0xff}) {
+				caption = true
+			}
+		}
+		if caption != (i > 0) {
+			t.Errorf("FAILED: frame %d\n\tgot:     %v\n\texpected:%v", i, caption, i > 0)
+		}
+	}
+	if moveNumber(chess) != "2... " {
+		t.Errorf("FAILED\n\tgot:     %q\n\texpected:%q", moveNumber(chess), "2... ")
+	}
+}
@@ -0,0 +1,276 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"chess-go/engine"
+)
+
+// pieceBox is the size of the box the pieces of a piece set are drawn in
+const pieceBox = 45
+
+// PieceSet is the SVG drawing of each piece in a box of 45 by 45 units
+type PieceSet map[engine.Piece]string
+
+// GlyphPieces draws the pieces with the Unicode chess symbols of the fonts
+// of the viewer
+var GlyphPieces = PieceSet{}
+
+// LetterPieces draws the pieces as discs with their FEN letter, for viewers
+// without chess symbols in their fonts
+var LetterPieces = PieceSet{}
+
+func init() {
+	for piece := range symbols {
+		// ink writes on the body of the piece
+		fill, ink := "#fff", "#000"
+		if piece.Color() == engine.Black {
+			fill, ink = "#000", "#fff"
+		}
+		// Both colors use the filled symbols, painted in their color
+		symbol := symbols[engine.NewPiece(engine.Black, piece.Type())]
+
+		GlyphPieces[piece] = fmt.Sprintf(`<text x="22.5" y="24" font-size="40" text-anchor="middle" dominant-baseline="central" fill="%s" stroke="#000" stroke-width="1">%s</text>`, fill, symbol)
+		LetterPieces[piece] = fmt.Sprintf(`<circle cx="22.5" cy="22.5" r="18" fill="%s" stroke="#000" stroke-width="1.5"/>`+
+			`<text x="22.5" y="23" font-family="sans-serif" font-weight="bold" font-size="22" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`,
+			fill, ink, strings.ToUpper(piece.Type().String()))
+	}
+}
+
+// Colors are the colors of a diagram, in any CSS syntax
+type Colors struct {
+	Light string
+	Dark  string
+	// LastMove, Check and Highlight are laid over the squares
+	LastMove  string
+	Check     string
+	Highlight string
+	// Mark is the color of arrows and circles without a color of their own
+	Mark        string
+	Coordinates string
+}
+
+// DefaultColors are brown squares with green marks
+var DefaultColors = Colors{
+	Light:       "#f0d9b5",
+	Dark:        "#b58863",
+	LastMove:    "#cdd26a",
+	Check:       "#e8423b",
+	Highlight:   "#15781b",
+	Mark:        "#15781b",
+	Coordinates: "#333",
+}
+
+// Highlight colors a square, with the highlight color of the diagram when
+// Color is empty
+type Highlight struct {
+	Square engine.Square
+	Color  string
+}
+
+// Circle circles a square, with the mark color of the diagram when Color is
+// empty
+type Circle struct {
+	Square engine.Square
+	Color  string
+}
+
+// Arrow points from a square to another, with the mark color of the diagram
+// when Color is empty
+type Arrow struct {
+	From  engine.Square
+	To    engine.Square
+	Color string
+}
+
+// SVG draws a diagram of the board in SVG
+type SVG struct {
+	// SquareSize is the size of a square in pixels, 45 when zero
+	SquareSize int
+	// Pieces is the piece set, GlyphPieces when nil
+	Pieces PieceSet
+	// Colors are DefaultColors when Light is empty
+	Colors Colors
+	// Flipped draws the board from the side of black
+	Flipped bool
+	// Coordinates draws the files and ranks in a margin around the board
+	Coordinates bool
+	// LastMove highlights the squares of the last move, Check the king in
+	// check
+	LastMove bool
+	Check    bool
+
+	Highlights []Highlight
+	Circles    []Circle
+	Arrows     []Arrow
+}
+
+// Render writes the diagram of a game
+func (s SVG) Render(w io.Writer, chess *engine.Chess) error {
+	size := float64(s.SquareSize)
+	if size <= 0 {
+		size = pieceBox
+	}
+	pieces := s.Pieces
+	if pieces == nil {
+		pieces = GlyphPieces
+	}
+	colors := s.Colors
+	if colors.Light == "" {
+		colors = DefaultColors
+	}
+	margin := 0.0
+	if s.Coordinates {
+		margin = size / 2
+	}
+
+	// corner returns the top left corner of a square
+	corner := func(square engine.Square) (float64, float64) {
+		column, row := square.File(), 7-square.Rank()
+		if s.Flipped {
+			column, row = 7-column, 7-row
+		}
+		return margin + float64(column)*size, margin + float64(row)*size
+	}
+	center := func(square engine.Square) (float64, float64) {
+		x, y := corner(square)
+		return x + size/2, y + size/2
+	}
+	overlay := func(square engine.Square, color string, opacity float64) string {
+		x, y := corner(square)
+		return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s" opacity="%s"/>`,
+			num(x), num(y), num(size), num(size), attr(color), num(opacity))
+	}
+
+	bw := bufio.NewWriter(w)
+	total := 8*size + 2*margin
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		num(total), num(total), num(total), num(total))
+
+	// Squares
+	for square := engine.A1; square <= engine.H8; square++ {
+		color := colors.Dark
+		if (square.File()+square.Rank())%2 == 1 {
+			color = colors.Light
+		}
+		x, y := corner(square)
+		fmt.Fprintf(bw, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n", num(x), num(y), num(size), num(size), attr(color))
+	}
+
+	// Marks under the pieces
+	if history := chess.History(); s.LastMove && len(history) > 0 {
+		last := history[len(history)-1]
+		fmt.Fprintln(bw, overlay(last.From, colors.LastMove, 0.5))
+		fmt.Fprintln(bw, overlay(last.To, colors.LastMove, 0.5))
+	}
+	for _, highlight := range s.Highlights {
+		fmt.Fprintln(bw, overlay(highlight.Square, or(highlight.Color, colors.Highlight), 0.5))
+	}
+	if s.Check && chess.InCheck() {
+		if king := chess.KingSquare(chess.Turn()); king != engine.NoSquare {
+			fmt.Fprintln(bw, overlay(king, colors.Check, 0.6))
+		}
+	}
+
+	// Coordinates
+	if s.Coordinates {
+		for i := 0; i < 8; i++ {
+			file, rank := i, 7-i
+			if s.Flipped {
+				file, rank = 7-i, i
+			}
+			offset := margin + float64(i)*size + size/2
+			fmt.Fprintf(bw, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="middle" dominant-baseline="central" fill="%s">%c</text>`+"\n",
+				num(offset), num(total-margin/2), num(margin*0.6), attr(colors.Coordinates), 'a'+file)
+			fmt.Fprintf(bw, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="middle" dominant-baseline="central" fill="%s">%c</text>`+"\n",
+				num(margin/2), num(offset), num(margin*0.6), attr(colors.Coordinates), '1'+rank)
+		}
+	}
+
+	// Pieces
+	for square := engine.A1; square <= engine.H8; square++ {
+		piece := chess.PieceAt(square)
+		drawing, ok := pieces[piece]
+		if !ok {
+			continue
+		}
+		x, y := corner(square)
+		fmt.Fprintf(bw, `<g transform="translate(%s %s) scale(%s)">%s</g>`+"\n", num(x), num(y), num(size/pieceBox), drawing)
+	}
+
+	// Marks over the pieces
+	for _, circle := range s.Circles {
+		x, y := center(circle.Square)
+		fmt.Fprintf(bw, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s" stroke-width="%s" opacity="0.8"/>`+"\n",
+			num(x), num(y), num(size*0.44), attr(or(circle.Color, colors.Mark)), num(size*0.07))
+	}
+	for _, arrow := range s.Arrows {
+		x1, y1 := center(arrow.From)
+		x2, y2 := center(arrow.To)
+		fmt.Fprintln(bw, arrowPolygon(x1, y1, x2, y2, size, or(arrow.Color, colors.Mark)))
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// arrowPolygon returns the polygon of an arrow between two centers of
+// squares, its head ends on the second one
+func arrowPolygon(x1, y1, x2, y2, size float64, color string) string {
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return ""
+	}
+	// The unit vector of the arrow and its normal
+	ux, uy := (x2-x1)/length, (y2-y1)/length
+	nx, ny := -uy, ux
+
+	head := size * 0.45
+	shaft, width := size*0.075, size*0.25
+	if head > length {
+		head = length
+	}
+	bx, by := x2-ux*head, y2-uy*head
+
+	points := [][2]float64{
+		{x1 + nx*shaft, y1 + ny*shaft},
+		{bx + nx*shaft, by + ny*shaft},
+		{bx + nx*width, by + ny*width},
+		{x2, y2},
+		{bx - nx*width, by - ny*width},
+		{bx - nx*shaft, by - ny*shaft},
+		{x1 - nx*shaft, y1 - ny*shaft},
+	}
+	var list string
+	for i, point := range points {
+		if i > 0 {
+			list += " "
+		}
+		list += num(point[0]) + "," + num(point[1])
+	}
+	return fmt.Sprintf(`<polygon points="%s" fill="%s" opacity="0.8"/>`, list, attr(color))
+}
+
+// num writes a coordinate with at most two decimals
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// attr escapes a value for an attribute
+func attr(s string) string {
+	return html.EscapeString(s)
+}
+
+// or returns s, or fallback when s is empty
+func or(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
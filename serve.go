@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"chess-go/server"
+	"chess-go/storage"
+)
+
+// runServe serves games over HTTP and returns the exit code
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address the server listens on")
+	abandon := flags.Duration("abandon", time.Minute, "how long a player may stay disconnected before losing")
+	data := flags.String("data", "", "directory the games are stored in to survive restarts, in memory when empty")
+	flags.Parse(args)
+
+	s := server.New()
+	if *data != "" {
+		store, err := storage.NewFile(*data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		s = server.NewWithStore(store)
+	}
+	s.AbandonTimeout = *abandon
+
+	restored, err := s.Restore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if restored > 0 {
+		fmt.Printf("Restored %d games in progress\n", restored)
+	}
+
+	fmt.Printf("Serving games on http://%s/games\n", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
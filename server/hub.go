@@ -0,0 +1,455 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/storage"
+	"chess-go/websocket"
+)
+
+// defaultAbandonTimeout is how long a player may stay disconnected when the
+// server sets no timeout
+const defaultAbandonTimeout = time.Minute
+
+// defaultPingInterval is how often the WebSockets are pinged when the server
+// sets no interval
+const defaultPingInterval = 30 * time.Second
+
+// sendBuffer is how many messages may wait for a slow client before it is
+// dropped
+const sendBuffer = 64
+
+var (
+	// ErrSeatTaken is returned when joining a seat connected to another player
+	ErrSeatTaken = errors.New("seat is taken")
+	// ErrNotPlayer is returned when a spectator tries to play
+	ErrNotPlayer = errors.New("spectators can not play")
+	// ErrNoDrawOffer is returned when answering a draw offer nobody made
+	ErrNoDrawOffer = errors.New("no draw offer to answer")
+)
+
+// Request is a message sent by a client over the WebSocket of a game. Its
+// type is one of "move" with the move in SAN or UCI, "offerDraw",
+// "acceptDraw", "declineDraw", "claimDraw" by the fifty move rule or the
+// threefold repetition, "resign" and "sync" to get the state again.
+type Request struct {
+	Type string `json:"type"`
+	Move string `json:"move,omitempty"`
+}
+
+// Message is a message sent to the clients of a game. "welcome" is sent
+// first with the color of the client and, to players, the token that takes
+// their seat back after a disconnection. "update" is sent to everyone with
+// the state of the game after each event, "error" to a client whose request
+// failed.
+type Message struct {
+	Type  string `json:"type"`
+	Event string `json:"event,omitempty"`
+	Color string `json:"color,omitempty"`
+	Token string `json:"token,omitempty"`
+	Error string `json:"error,omitempty"`
+	Game  *State `json:"game,omitempty"`
+}
+
+// client is a WebSocket connected to a game
+type client struct {
+	conn  *websocket.Conn
+	color engine.Color
+	send  chan []byte
+}
+
+// seat is the seat of a player of a game
+type seat struct {
+	token  string
+	client *client
+	// abandon ends the game when the player stays away too long
+	abandon *time.Timer
+}
+
+// room is the players and spectators of a game, guarded by the lock of the
+// game
+type room struct {
+	seats          map[engine.Color]*seat
+	spectators     map[*client]bool
+	drawOffer      engine.Color
+	abandonTimeout time.Duration
+	// flag ends the game when the time of the side to move runs out
+	flag *time.Timer
+	// closed is set once the game is deleted, its timers and clients do
+	// nothing more
+	closed bool
+}
+
+// play upgrades a request to a WebSocket that plays or watches a game. The
+// query chooses the seat: color=white or color=black to play, with the token
+// of a previous connection to take the seat back and the name of the player
+// when they take it first, or nothing to watch.
+func (s *Server) play(w http.ResponseWriter, r *http.Request, game *Game) {
+	query := r.URL.Query()
+	color := engine.NoColor
+	switch query.Get("color") {
+	case "white":
+		color = engine.White
+	case "black":
+		color = engine.Black
+	case "", "spectator":
+	default:
+		writeError(w, http.StatusBadRequest, errors.New(`color must be "white", "black" or "spectator"`))
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	// A client that answers neither pings nor anything else for two
+	// intervals is gone
+	interval := s.PingInterval
+	if interval <= 0 {
+		interval = defaultPingInterval
+	}
+	conn.SetReadTimeout(2 * interval)
+
+	c := &client{conn: conn, color: color, send: make(chan []byte, sendBuffer)}
+	go c.write(interval)
+
+	if err := game.join(c, query.Get("token"), query.Get("name")); err != nil {
+		data, _ := json.Marshal(Message{Type: "error", Error: err.Error()})
+		c.send <- data
+		close(c.send)
+		return
+	}
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var request Request
+		if err := json.Unmarshal(data, &request); err != nil {
+			game.reply(c, err)
+			continue
+		}
+		game.reply(c, game.handle(c, request))
+	}
+
+	game.leave(c)
+}
+
+// write sends the messages of a client until its channel is closed, and
+// pings it in between
+func (c *client) write(pingInterval time.Duration) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case message, ok := <-c.send:
+			if !ok {
+				c.conn.Close()
+				return
+			}
+			err = c.conn.WriteMessage(message)
+		case <-ping.C:
+			err = c.conn.Ping()
+		}
+
+		if err != nil {
+			c.conn.Close()
+			for range c.send {
+			}
+			return
+		}
+	}
+}
+
+// join seats a client as a player or a spectator and welcomes it, the seat
+// of a new player is stored with their name
+func (g *Game) join(c *client, token, name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return ErrNotFound
+	}
+	if g.seats == nil {
+		g.seats = map[engine.Color]*seat{}
+		g.spectators = map[*client]bool{}
+	}
+
+	if c.color == engine.NoColor {
+		g.spectators[c] = true
+		g.sendTo(c, Message{Type: "welcome", Color: "spectator"})
+		g.changed("spectator")
+		return nil
+	}
+
+	event := "join"
+	s, ok := g.seats[c.color]
+	switch {
+	case !ok:
+		s = &seat{token: newID()}
+		if err := g.store.Seat(g.ID, c.color, storage.Player{Name: name, Token: s.token}); err != nil {
+			return err
+		}
+		g.seats[c.color] = s
+	case token != s.token:
+		return ErrSeatTaken
+	default:
+		event = "reconnect"
+		if s.abandon != nil {
+			s.abandon.Stop()
+			s.abandon = nil
+		}
+		// A new connection of the player replaces the one it lost
+		if s.client != nil {
+			close(s.client.send)
+		}
+	}
+	s.client = c
+
+	g.sendTo(c, Message{Type: "welcome", Color: c.color.Name(), Token: s.token})
+	g.changed(event)
+	return nil
+}
+
+// leave disconnects a client, a player loses the game if they do not come
+// back before the abandon timeout
+func (g *Game) leave(c *client) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.spectators[c] {
+		delete(g.spectators, c)
+		close(c.send)
+		return
+	}
+
+	s, ok := g.seats[c.color]
+	if !ok || s.client != c {
+		return
+	}
+	s.client = nil
+	close(c.send)
+
+	g.abandonLater(c.color, s)
+	g.changed("leave")
+}
+
+// close stops the timers of a deleted game and disconnects its clients
+func (g *Game) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	if g.flag != nil {
+		g.flag.Stop()
+		g.flag = nil
+	}
+	for _, s := range g.seats {
+		if s.abandon != nil {
+			s.abandon.Stop()
+			s.abandon = nil
+		}
+		if s.client != nil {
+			close(s.client.send)
+			s.client = nil
+		}
+	}
+	for c := range g.spectators {
+		delete(g.spectators, c)
+		close(c.send)
+	}
+}
+
+// abandonLater ends the game when the player of a seat does not come back
+// before the abandon timeout, the lock must be held
+func (g *Game) abandonLater(color engine.Color, s *seat) {
+	// Games that have not started with both players can not be abandoned
+	if len(g.seats) < 2 || g.chess.IsGameOver() {
+		return
+	}
+
+	timeout := g.abandonTimeout
+	if timeout <= 0 {
+		timeout = defaultAbandonTimeout
+	}
+	s.abandon = time.AfterFunc(timeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if !g.closed && s.client == nil && g.chess.Abandon(color) == nil {
+			g.changed("abandon")
+		}
+	})
+}
+
+// handle executes a request of a client
+func (g *Game) handle(c *client, request Request) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if request.Type == "sync" {
+		state := g.state()
+		g.sendTo(c, Message{Type: "update", Event: "sync", Game: &state})
+		return nil
+	}
+
+	if g.closed {
+		return ErrNotFound
+	}
+	if c.color == engine.NoColor {
+		return ErrNotPlayer
+	}
+	g.checkClock()
+
+	switch request.Type {
+	case "move":
+		if g.chess.Turn() != c.color && !g.chess.IsGameOver() {
+			return engine.ErrNotYourTurn
+		}
+		return g.play(request.Move)
+
+	case "offerDraw":
+		if g.chess.IsGameOver() {
+			return engine.ErrGameOver
+		}
+		if g.drawOffer == c.color.Other() {
+			return g.endGame(g.chess.AgreeDraw(), "draw")
+		}
+		g.drawOffer = c.color
+		g.changed("drawOffer")
+
+	case "acceptDraw":
+		if g.drawOffer != c.color.Other() {
+			return ErrNoDrawOffer
+		}
+		return g.endGame(g.chess.AgreeDraw(), "draw")
+
+	case "declineDraw":
+		if g.drawOffer != c.color.Other() {
+			return ErrNoDrawOffer
+		}
+		g.drawOffer = engine.NoColor
+		g.changed("drawDeclined")
+
+	case "claimDraw":
+		return g.endGame(g.chess.ClaimDraw(), "draw")
+
+	case "resign":
+		return g.endGame(g.chess.Resign(c.color), "resign")
+
+	default:
+		return errors.New("unknown request " + request.Type)
+	}
+
+	return nil
+}
+
+// endGame tells everyone the game ended, unless it could not be ended
+func (g *Game) endGame(err error, event string) error {
+	if err != nil {
+		return err
+	}
+	g.drawOffer = engine.NoColor
+	g.changed(event)
+	return nil
+}
+
+// reply tells a client why its request failed
+func (g *Game) reply(c *client, err error) {
+	if err == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sendTo(c, Message{Type: "error", Error: err.Error()})
+}
+
+// changed sends the state of the game to its clients after an event, stores
+// the outcome of a game that just ended and watches the clock of the side to
+// move. The lock must be held.
+func (g *Game) changed(event string) {
+	g.watchClock()
+
+	// The outcome is stored again with the next change when it fails
+	if g.chess.IsGameOver() && !g.finished {
+		g.finished = g.store.Finish(g.ID, g.chess.Outcome(), g.chess.Method()) == nil
+	}
+
+	if len(g.seats) == 0 && len(g.spectators) == 0 {
+		return
+	}
+
+	state := g.state()
+	message := Message{Type: "update", Event: event, Game: &state}
+	for _, s := range g.seats {
+		if s.client != nil {
+			g.sendTo(s.client, message)
+		}
+	}
+	for c := range g.spectators {
+		g.sendTo(c, message)
+	}
+}
+
+// watchClock sets a timer that ends the game when the flag of the side to
+// move falls, the lock must be held
+func (g *Game) watchClock() {
+	if g.flag != nil {
+		g.flag.Stop()
+		g.flag = nil
+	}
+	if g.clock == nil || !g.clock.Running() || g.chess.IsGameOver() {
+		return
+	}
+
+	turn := g.clock.Turn()
+	left := g.clock.Remaining(turn) + g.clock.Delay()
+	g.flag = time.AfterFunc(left+time.Millisecond, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		// The flag has not fallen yet when the time source is not the system
+		if g.closed {
+			return
+		}
+		g.flag = nil
+		g.checkClock()
+		g.watchClock()
+	})
+}
+
+// sendTo queues a message for a client, dropping the client when it does not
+// keep up. The lock must be held.
+func (g *Game) sendTo(c *client, message Message) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	select {
+	case c.send <- data:
+	default:
+		// The writer of the client may be stuck, closing must not wait for it
+		go c.conn.Close()
+	}
+}
+
+// seatState returns the state of the seat of a color, the lock must be held
+func (g *Game) seatState(color engine.Color) Seat {
+	s, ok := g.seats[color]
+	if !ok {
+		return Seat{}
+	}
+	return Seat{Taken: true, Connected: s.client != nil}
+}
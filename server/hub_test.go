@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"chess-go/clock"
+	"chess-go/engine"
+	"chess-go/storage"
+	"chess-go/websocket"
+)
+
+// connect opens a WebSocket to a game and returns it with its welcome
+func connect(t *testing.T, ts *httptest.Server, id, query string) (*websocket.Conn, Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/games/" + id + "/play?" + query
+	conn, err := websocket.Dial(ctx, url)
+	if err != nil {
+		t.Fatalf("FAILED: %s\n\t%s", query, err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var welcome Message
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&welcome); err != nil {
+		t.Fatalf("FAILED: %s\n\t%s", query, err.Error())
+	}
+	return conn, welcome
+}
+
+// await reads messages until an update of an event, or an error when event
+// is "error", whose JSON contains a text
+func await(t *testing.T, conn *websocket.Conn, event, text string) Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("FAILED: waiting for %s\n\tgot:     %s\n\texpected:%s", event, err.Error(), text)
+		}
+
+		var message Message
+		json.Unmarshal(data, &message)
+		if (message.Event == event || message.Type == event) && strings.Contains(string(data), text) {
+			return message
+		}
+	}
+}
+
+// send sends a request to the server
+func send(t *testing.T, conn *websocket.Conn, request Request) {
+	if err := conn.WriteJSON(request); err != nil {
+		t.Fatalf("FAILED: %+v\n\t%s", request, err.Error())
+	}
+}
+
+func TestHub_Play(t *testing.T) {
+	server := New()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	game, _ := server.Create("", nil)
+
+	white, welcome := connect(t, ts, game.ID, "color=white")
+	if welcome.Color != "white" || welcome.Token == "" {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%s", welcome, "a white seat")
+	}
+	black, _ := connect(t, ts, game.ID, "color=black")
+	spectator, _ := connect(t, ts, game.ID, "")
+	if _, taken := connect(t, ts, game.ID, "color=white"); taken.Error != ErrSeatTaken.Error() {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%v", taken, ErrSeatTaken)
+	}
+
+	inputs := []struct {
+		conn    *websocket.Conn
+		request Request
+		event   string
+	}{
+		{white, Request{Type: "move", Move: "e4"}, "move"},
+		{white, Request{Type: "move", Move: "d4"}, "error"},
+		{spectator, Request{Type: "move", Move: "e5"}, "error"},
+		{black, Request{Type: "move", Move: "e7e5"}, "move"},
+		{black, Request{Type: "acceptDraw"}, "error"},
+		{black, Request{Type: "claimDraw"}, "error"},
+		{white, Request{Type: "offerDraw"}, "drawOffer"},
+		{black, Request{Type: "declineDraw"}, "drawDeclined"},
+		{white, Request{Type: "offerDraw"}, "drawOffer"},
+		{black, Request{Type: "acceptDraw"}, "draw"},
+		{black, Request{Type: "resign"}, "error"},
+	}
+
+	expectedOutputs := []string{
+		`"turn":"black"`,
+		engine.ErrNotYourTurn.Error(),
+		ErrNotPlayer.Error(),
+		`"history":[{"uci":"e2e4","san":"e4"},{"uci":"e7e5","san":"e5"}]`,
+		ErrNoDrawOffer.Error(),
+		engine.ErrNoDrawClaim.Error(),
+		`"drawOffer":"white"`,
+		`"outcome":"*"`,
+		`"drawOffer":"white"`,
+		`"outcome":"1/2-1/2","method":"agreement"`,
+		engine.ErrGameOver.Error(),
+	}
+
+	for i, input := range inputs {
+		send(t, input.conn, input.request)
+		await(t, input.conn, input.event, expectedOutputs[i])
+	}
+
+	// Spectators see every move
+	await(t, spectator, "draw", `"method":"agreement"`)
+}
+func TestHub_Reconnect(t *testing.T) {
+	server := New()
+	server.AbandonTimeout = 200 * time.Millisecond
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	game, _ := server.Create("", nil)
+
+	white, welcome := connect(t, ts, game.ID, "color=white")
+	black, _ := connect(t, ts, game.ID, "color=black")
+	send(t, white, Request{Type: "move", Move: "e4"})
+	await(t, black, "move", `"san":"e4"`)
+
+	// The player comes back in time with the token of the seat
+	white.Close()
+	await(t, black, "leave", `"white":{"taken":true,"connected":false}`)
+	white, _ = connect(t, ts, game.ID, "color=white&token="+welcome.Token)
+	await(t, white, "reconnect", `"history":[{"uci":"e2e4","san":"e4"}]`)
+
+	time.Sleep(2 * server.AbandonTimeout)
+	if state := game.State(); state.Outcome != "*" {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%s", state.Outcome, "*")
+	}
+
+	// Then leaves for good
+	white.Close()
+	await(t, black, "abandon", `"outcome":"0-1","method":"abandonment"`)
+}
+func TestHub_Ping(t *testing.T) {
+	server := New()
+	server.PingInterval = 50 * time.Millisecond
+	server.AbandonTimeout = 200 * time.Millisecond
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	game, _ := server.Create("", nil)
+
+	// White stops reading and answers no ping, black answers them while it
+	// waits
+	connect(t, ts, game.ID, "color=white")
+	black, _ := connect(t, ts, game.ID, "color=black")
+	await(t, black, "leave", `"white":{"taken":true,"connected":false}`)
+	if state := game.State(); !state.Players.Black.Connected {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%s", state.Players.Black, "black connected")
+	}
+	await(t, black, "abandon", `"outcome":"0-1","method":"abandonment"`)
+}
+func TestHub_Clock(t *testing.T) {
+	source := clock.NewManual(time.Unix(0, 0))
+	server := New()
+	server.Clock = source
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	control, _ := clock.ParseControl("1+2")
+	game, _ := server.Create("4k3/8/8/8/8/8/8/4K2R w K - 0 1", control)
+
+	white, _ := connect(t, ts, game.ID, "color=white")
+	black, _ := connect(t, ts, game.ID, "color=black")
+
+	// The clock starts with the first move
+	send(t, white, Request{Type: "move", Move: "Rh7"})
+	await(t, white, "move", `"clock":{"white":62000,"black":60000,"running":true}`)
+
+	source.Advance(30 * time.Second)
+	send(t, black, Request{Type: "move", Move: "Kd8"})
+	await(t, black, "move", `"clock":{"white":62000,"black":32000,"running":true}`)
+
+	// White flags, and black only has a king left to mate with
+	source.Advance(63 * time.Second)
+	send(t, black, Request{Type: "sync"})
+	await(t, black, "timeout", `"outcome":"1/2-1/2","method":"timeout vs insufficient material"`)
+	await(t, white, "timeout", `"clock":{"white":0,"black":32000,"running":false}`)
+}
+func TestHub_Delete(t *testing.T) {
+	server := New()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Taking back a move takes back the draw offer made with it
+	game, _ := server.Create("", nil)
+	white, welcome := connect(t, ts, game.ID, "color=white")
+	connect(t, ts, game.ID, "color=black")
+	send(t, white, Request{Type: "move", Move: "e4"})
+	send(t, white, Request{Type: "offerDraw"})
+	await(t, white, "drawOffer", `"drawOffer":"white"`)
+	if state, err := game.Undo(welcome.Token); err != nil || state.DrawOffer != "" {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%s", state.DrawOffer, err, "no draw offer")
+	}
+
+	// The clients and the flag of a deleted game are let go
+	control, _ := clock.ParseControl("1+0")
+	game, _ = server.Create("", control)
+	white, _ = connect(t, ts, game.ID, "color=white")
+	black, _ := connect(t, ts, game.ID, "color=black")
+	spectator, _ := connect(t, ts, game.ID, "")
+	send(t, white, Request{Type: "move", Move: "e4"})
+	await(t, black, "move", `"san":"e4"`)
+	if err := server.Delete(game.ID); err != nil {
+		t.Fatalf("FAILED: delete\n\t%s", err.Error())
+	}
+
+	for _, conn := range []*websocket.Conn{white, black, spectator} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var err error
+		for err == nil {
+			_, err = conn.ReadMessage()
+		}
+		if !errors.Is(err, websocket.ErrClosed) {
+			t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", err, websocket.ErrClosed)
+		}
+	}
+	game.mu.Lock()
+	if game.flag != nil {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", game.flag, nil)
+	}
+	game.mu.Unlock()
+}
+func TestHub_Restart(t *testing.T) {
+	store, err := storage.NewFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := clock.NewManual(time.Unix(0, 0))
+	server := NewWithStore(store)
+	server.Clock = source
+	ts := httptest.NewServer(server)
+
+	control, _ := clock.ParseControl("1+2")
+	game, _ := server.Create("", control)
+	white, welcome := connect(t, ts, game.ID, "color=white&name=Alice")
+	black, _ := connect(t, ts, game.ID, "color=black&name=Bob")
+	send(t, white, Request{Type: "move", Move: "e4"})
+	await(t, black, "move", `"san":"e4"`)
+	source.Advance(10 * time.Second)
+	send(t, black, Request{Type: "move", Move: "e5"})
+	await(t, white, "move", `"history":[{"uci":"e2e4","san":"e4"},{"uci":"e7e5","san":"e5"}]`)
+	white.Close()
+	black.Close()
+	ts.Close()
+
+	// A new server goes on with the game where it was left
+	server = NewWithStore(store)
+	server.Clock = source
+	ts = httptest.NewServer(server)
+	defer ts.Close()
+
+	if n, err := server.Restore(); n != 1 || err != nil {
+		t.Fatalf("FAILED\n\tgot:     %d %v\n\texpected:%d", n, err, 1)
+	}
+	status, body := request(t, ts.URL+"/games?player=Alice&status=in-progress", http.MethodGet, "")
+	if expected := `{"games":["` + game.ID + `"]}`; status != http.StatusOK || strings.TrimSpace(body) != expected {
+		t.Errorf("FAILED\n\tgot:     %d %s\n\texpected:%s", status, body, expected)
+	}
+
+	white, _ = connect(t, ts, game.ID, "color=white&token="+welcome.Token)
+	await(t, white, "reconnect", `"clock":{"white":62000,"black":52000,"running":true}`)
+	if _, welcome := connect(t, ts, game.ID, "color=black"); welcome.Error != ErrSeatTaken.Error() {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%s", welcome, ErrSeatTaken)
+	}
+	send(t, white, Request{Type: "move", Move: "Nf3"})
+	await(t, white, "move", `"history":[{"uci":"e2e4","san":"e4"},{"uci":"e7e5","san":"e5"},{"uci":"g1f3","san":"Nf3"}]`)
+}
@@ -0,0 +1,704 @@
+// Package server serves games of engine.Chess over HTTP as JSON resources
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-go/clock"
+	"chess-go/engine"
+	"chess-go/pgn"
+	"chess-go/storage"
+)
+
+// ErrNotFound is returned for a game that does not exist
+var ErrNotFound = errors.New("game not found")
+
+// ErrUndoOnClock is returned when taking back a move of a game on the clock
+var ErrUndoOnClock = errors.New("can not undo a move of a game on the clock")
+
+// ErrSeatToken is returned when changing a game with seated players without
+// the token of a seat
+var ErrSeatToken = errors.New("the token of a seat is required")
+
+// Game is a game served by the server, its lock guards the chess game, its
+// clock and the players connected to it
+type Game struct {
+	ID string
+
+	mu    sync.Mutex
+	chess *engine.Chess
+	// clock is nil for games without a time control, it starts with the
+	// first move
+	clock *clock.Clock
+
+	// store keeps the moves of the game, finished is set once its outcome
+	// is stored
+	store    storage.Store
+	finished bool
+
+	// The players and spectators connected with WebSockets
+	room
+}
+
+// Move is a move in the state of a game
+type Move struct {
+	UCI string `json:"uci"`
+	SAN string `json:"san"`
+}
+
+// State is the JSON representation of a game
+type State struct {
+	ID         string `json:"id"`
+	FEN        string `json:"fen"`
+	StartFEN   string `json:"startFen"`
+	Turn       string `json:"turn"`
+	Check      bool   `json:"check"`
+	LegalMoves []Move `json:"legalMoves"`
+	// Outcome is the result in PGN notation, "*" while the game is played
+	Outcome string `json:"outcome"`
+	Method  string `json:"method,omitempty"`
+	History []Move `json:"history"`
+
+	Clock     *ClockState `json:"clock,omitempty"`
+	Players   Players     `json:"players"`
+	DrawOffer string      `json:"drawOffer,omitempty"`
+	// CanClaimDraw tells whether the players may claim a draw by the fifty
+	// move rule or the threefold repetition
+	CanClaimDraw bool `json:"canClaimDraw,omitempty"`
+}
+
+// ClockState is the time left to the players in milliseconds
+type ClockState struct {
+	White   int64 `json:"white"`
+	Black   int64 `json:"black"`
+	Running bool  `json:"running"`
+}
+
+// Players tells whether the seats of a game are taken and connected
+type Players struct {
+	White Seat `json:"white"`
+	Black Seat `json:"black"`
+}
+
+// Seat is the seat of a player
+type Seat struct {
+	Taken     bool `json:"taken"`
+	Connected bool `json:"connected"`
+}
+
+// Server keeps games in a store and serves them over HTTP:
+//
+//	POST   /games             create a game, from {"fen": "...", "clock": "5+3"}
+//	                          when given, see clock.ParseControl
+//	GET    /games             list the IDs of the games, of a ?player= and
+//	                          with a ?status= of "in-progress" or "finished"
+//	GET    /games/{id}        state of a game
+//	DELETE /games/{id}        delete a game
+//	POST   /games/{id}/moves  play {"move": "...", "token": "..."} in SAN or UCI
+//	POST   /games/{id}/undo   take back the last move, {"token": "..."}
+//	GET    /games/{id}/pgn    the game in PGN
+//	GET    /games/{id}/play   play or watch the game over a WebSocket, see Hub
+type Server struct {
+	// AbandonTimeout is how long a player may stay disconnected from a game
+	// before losing it, one minute when zero
+	AbandonTimeout time.Duration
+	// PingInterval is how often the WebSockets of the games are pinged, a
+	// client silent for two intervals is disconnected. 30 seconds when zero.
+	PingInterval time.Duration
+	// Clock is the time source of the clocks of the games, clock.System when
+	// nil
+	Clock clock.Source
+	// ErrorLog logs the games that can not be restored, the standard logger
+	// when nil
+	ErrorLog *log.Logger
+
+	store storage.Store
+	mu    sync.RWMutex
+	// games are the games loaded from the store
+	games map[string]*Game
+}
+
+// New returns a server without games that keeps them in memory
+func New() *Server {
+	return NewWithStore(storage.NewMemory())
+}
+
+// NewWithStore returns a server that keeps its games in a store, see Restore
+// to go on with the games in progress of a previous server
+func NewWithStore(store storage.Store) *Server {
+	return &Server{store: store, games: map[string]*Game{}}
+}
+
+// Restore loads the games in progress from the store, with their clocks and
+// seats, and returns how many were loaded. It is called once the server is
+// configured. The time of the side to move between the last stored move and
+// the restart is not charged to them. Games that can not be replayed are
+// logged and left in the store.
+func (s *Server) Restore() (int, error) {
+	records, err := s.store.List(storage.Filter{Status: storage.InProgress})
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded := 0
+	for _, record := range records {
+		if _, ok := s.games[record.ID]; ok {
+			continue
+		}
+		game, err := s.restore(record)
+		if err != nil {
+			s.logf("server: not restoring game %s: %v", record.ID, err)
+			continue
+		}
+		s.games[game.ID] = game
+		loaded++
+	}
+	return loaded, nil
+}
+
+// logf logs with the error log of the server, the standard logger when it
+// has none
+func (s *Server) logf(format string, args ...any) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// restore returns a game of the store as it was when its last move was
+// stored
+func (s *Server) restore(record storage.Record) (*Game, error) {
+	chess, err := record.Replay()
+	if err != nil {
+		return nil, err
+	}
+	game := s.newGame(record.ID, chess)
+	game.finished = record.Status == storage.Finished
+
+	if record.Clock != "" {
+		control, err := clock.ParseControl(record.Clock)
+		if err != nil {
+			return nil, fmt.Errorf("game %s: %w", record.ID, err)
+		}
+		game.clock = clock.New(control, s.Clock)
+
+		// The players alternate, the last to move made the odd move
+		if n := len(record.Moves); n > 0 {
+			last := record.Moves[n-1]
+			mover := chess.Turn().Other()
+			times := map[engine.Color]time.Duration{engine.White: last.White, engine.Black: last.Black}
+			game.clock.Restore(mover, times[mover], (n+1)/2)
+			game.clock.Restore(mover.Other(), times[mover.Other()], n/2)
+			if !chess.IsGameOver() {
+				game.clock.Start(chess.Turn())
+			}
+		}
+	}
+
+	game.seats = map[engine.Color]*seat{}
+	game.spectators = map[*client]bool{}
+	for color, player := range map[engine.Color]storage.Player{engine.White: record.White, engine.Black: record.Black} {
+		if player.Token != "" {
+			game.seats[color] = &seat{token: player.Token}
+		}
+	}
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+
+	// Nobody is connected after a restart, the players have to come back
+	for color, seat := range game.seats {
+		game.abandonLater(color, seat)
+	}
+	game.watchClock()
+	return game, nil
+}
+
+// newGame returns a game of the server
+func (s *Server) newGame(id string, chess *engine.Chess) *Game {
+	game := &Game{ID: id, chess: chess, store: s.store}
+	game.abandonTimeout = s.AbandonTimeout
+	return game
+}
+
+// Create adds a game starting from a FEN, the standard position when empty,
+// with a clock when the control has stages
+func (s *Server) Create(fen string, control clock.Control) (*Game, error) {
+	chess := engine.NewGameChess()
+	if fen != "" {
+		var err error
+		if chess, err = engine.NewChessGameWithFen(fen, engine.Strict); err != nil {
+			return nil, err
+		}
+	}
+
+	game := s.newGame(newID(), chess)
+	if len(control) > 0 {
+		game.clock = clock.New(control, s.Clock)
+	}
+
+	record := storage.Record{ID: game.ID, StartFEN: fen}
+	if len(control) > 0 {
+		record.Clock = control.String()
+	}
+	if err := s.store.Create(record); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.games[game.ID] = game
+	s.mu.Unlock()
+
+	return game, nil
+}
+
+// Game returns a game by ID, loading it from the store when it is not
+// loaded yet
+func (s *Server) Game(id string) (*Game, error) {
+	s.mu.RLock()
+	game, ok := s.games[id]
+	s.mu.RUnlock()
+	if ok {
+		return game, nil
+	}
+
+	record, err := s.store.Load(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have loaded it meanwhile
+	if game, ok := s.games[id]; ok {
+		return game, nil
+	}
+	if game, err = s.restore(record); err != nil {
+		return nil, err
+	}
+	s.games[id] = game
+	return game, nil
+}
+
+// Delete removes a game and disconnects its clients
+func (s *Server) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.store.Delete(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if game, ok := s.games[id]; ok {
+		game.close()
+		delete(s.games, id)
+	}
+	return nil
+}
+
+// IDs returns the IDs of the stored games selected by a filter, oldest first
+func (s *Server) IDs(filter storage.Filter) ([]string, error) {
+	records, err := s.store.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids, nil
+}
+
+// State returns the state of the game
+func (g *Game) State() State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.state()
+}
+
+// Play plays a move in SAN or UCI notation. Once the game has seated
+// players the token must be the one of the seat of the side to move.
+func (g *Game) Play(move, token string) (State, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.authorize(g.chess.Turn(), token); err != nil {
+		return g.state(), err
+	}
+	err := g.play(move)
+	return g.state(), err
+}
+
+// authorize checks that a token is the one of the seat of a color when the
+// game has seated players, the lock must be held
+func (g *Game) authorize(color engine.Color, token string) error {
+	if len(g.seats) == 0 {
+		return nil
+	}
+	if s, ok := g.seats[color]; ok && token == s.token {
+		return nil
+	}
+	if s, ok := g.seats[color.Other()]; ok && token == s.token {
+		return engine.ErrNotYourTurn
+	}
+	return ErrSeatToken
+}
+
+// play plays a move, presses the clock and stores the move, the lock must be
+// held. A move made after the flag fell or that can not be stored is taken
+// back.
+func (g *Game) play(move string) error {
+	// The flag may have fallen since the last look at the clock
+	g.checkClock()
+
+	turn := g.chess.Turn()
+	var played *engine.MoveResult
+	var err error
+	if _, parseErr := engine.ParseMove(move); parseErr == nil {
+		played, err = g.chess.MoveUCI(move)
+	} else {
+		played, err = g.chess.MovePGN(move)
+	}
+	if err != nil {
+		return err
+	}
+
+	stored := storage.Move{UCI: played.UCI}
+	var left time.Duration
+	var moves int
+	started := false
+	if g.clock != nil {
+		if g.clock.Moves(engine.White)+g.clock.Moves(engine.Black) == 0 && !g.clock.Running() {
+			g.clock.Start(turn)
+			started = true
+		}
+		left, moves = g.clock.Remaining(turn), g.clock.Moves(turn)
+		if err := g.clock.Press(); err != nil {
+			g.chess.Undo()
+			if started {
+				g.clock.Pause()
+			}
+			g.checkClock()
+			return err
+		}
+		stored.White = g.clock.Remaining(engine.White)
+		stored.Black = g.clock.Remaining(engine.Black)
+	}
+
+	if err := g.store.AppendMove(g.ID, stored); err != nil {
+		g.chess.Undo()
+		if g.clock != nil {
+			g.clock.Restore(turn, left, moves)
+			g.clock.Start(turn)
+			if started {
+				g.clock.Pause()
+			}
+		}
+		return err
+	}
+	if g.drawOffer == turn.Other() {
+		g.drawOffer = engine.NoColor
+	}
+
+	g.changed("move")
+	return nil
+}
+
+// Undo takes back the last move. Once the game has seated players the token
+// must be the one of the seat of the player who made it.
+func (g *Game) Undo(token string) (State, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.authorize(g.chess.Turn().Other(), token); err != nil {
+		return g.state(), err
+	}
+	if g.clock != nil {
+		return g.state(), ErrUndoOnClock
+	}
+	if len(g.chess.History()) == 0 {
+		return g.state(), engine.ErrNoHistory
+	}
+	if g.finished {
+		return g.state(), engine.ErrGameOver
+	}
+
+	if err := g.store.Undo(g.ID); err != nil {
+		return g.state(), err
+	}
+	_, err := g.chess.Undo()
+	if err == nil {
+		g.drawOffer = engine.NoColor
+		g.changed("undo")
+	}
+	return g.state(), err
+}
+
+// checkClock ends the game when the flag of the side to move fell, and stops
+// the clock once the game is over. The lock must be held.
+func (g *Game) checkClock() {
+	if g.clock == nil {
+		return
+	}
+	if g.clock.Check(g.chess) {
+		g.changed("timeout")
+	}
+	if g.chess.IsGameOver() && g.clock.Running() {
+		g.clock.Pause()
+	}
+}
+
+// PGN returns the game in PGN
+func (g *Game) PGN() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	game := pgn.NewGame(g.chess)
+	game.SetTag("Site", "chess-go")
+	return game.String()
+}
+
+// state returns the state of the game, the lock must be held
+func (g *Game) state() State {
+	g.checkClock()
+
+	chess := g.chess
+	state := State{
+		ID:         g.ID,
+		FEN:        chess.GetFEN(),
+		StartFEN:   chess.StartingFEN(),
+		Turn:       chess.Turn().Name(),
+		Check:      chess.InCheck(),
+		LegalMoves: []Move{},
+		Outcome:    chess.Outcome().String(),
+		History:    []Move{},
+	}
+	if chess.IsGameOver() {
+		state.Method = chess.Method().String()
+	}
+
+	for _, move := range chess.LegalMoves() {
+		san, err := chess.SAN(move)
+		if err != nil {
+			continue
+		}
+		state.LegalMoves = append(state.LegalMoves, Move{UCI: move.String(), SAN: san})
+	}
+
+	sans := chess.HistorySAN()
+	for i, move := range chess.History() {
+		state.History = append(state.History, Move{UCI: move.String(), SAN: sans[i]})
+	}
+
+	if g.clock != nil {
+		state.Clock = &ClockState{
+			White:   g.clock.Remaining(engine.White).Milliseconds(),
+			Black:   g.clock.Remaining(engine.Black).Milliseconds(),
+			Running: g.clock.Running(),
+		}
+	}
+	state.Players.White = g.seatState(engine.White)
+	state.Players.Black = g.seatState(engine.Black)
+	if g.drawOffer == engine.White || g.drawOffer == engine.Black {
+		state.DrawOffer = g.drawOffer.Name()
+	}
+	state.CanClaimDraw = chess.CanClaimDraw()
+
+	return state
+}
+
+// ServeHTTP routes the requests of the API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "games" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			ids, err := s.IDs(storage.Filter{Player: query.Get("player"), Status: storage.Status(query.Get("status"))})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string][]string{"games": ids})
+		case http.MethodPost:
+			s.create(w, r)
+		default:
+			methodNotAllowed(w, "GET, POST")
+		}
+		return
+	}
+
+	game, err := s.Game(parts[1])
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	action := ""
+	if len(parts) == 3 {
+		action = parts[2]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, game.State())
+
+	case action == "" && r.Method == http.MethodDelete:
+		if err := s.Delete(game.ID); errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case action == "":
+		methodNotAllowed(w, "GET, DELETE")
+
+	case action == "moves" && r.Method == http.MethodPost:
+		var body struct {
+			Move  string `json:"move"`
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Move == "" {
+			writeError(w, http.StatusBadRequest, errors.New(`expected {"move": "..."}`))
+			return
+		}
+		state, err := game.Play(body.Move, body.Token)
+		writeResult(w, state, err)
+
+	case action == "undo" && r.Method == http.MethodPost:
+		var body struct {
+			Token string `json:"token"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, errors.New(`expected {"token": "..."}`))
+				return
+			}
+		}
+		state, err := game.Undo(body.Token)
+		writeResult(w, state, err)
+
+	case action == "play":
+		s.play(w, r, game)
+
+	case action == "pgn" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/x-chess-pgn")
+		w.Write([]byte(game.PGN()))
+
+	case action == "moves" || action == "undo":
+		methodNotAllowed(w, "POST")
+
+	case action == "pgn":
+		methodNotAllowed(w, "GET")
+
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// create handles the creation of a game
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		FEN   string `json:"fen"`
+		Clock string `json:"clock"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New(`expected {"fen": "...", "clock": "..."}`))
+			return
+		}
+	}
+
+	var control clock.Control
+	if body.Clock != "" {
+		var err error
+		if control, err = clock.ParseControl(body.Clock); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	game, err := s.Create(body.FEN, control)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Location", "/games/"+game.ID)
+	writeJSON(w, http.StatusCreated, game.State())
+}
+
+// writeResult writes the state of a game after a change, or why it failed
+func writeResult(w http.ResponseWriter, state State, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, state)
+	case errors.Is(err, ErrSeatToken):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, engine.ErrGameOver) || errors.Is(err, engine.ErrNoHistory) || errors.Is(err, ErrUndoOnClock) ||
+		errors.Is(err, engine.ErrNotYourTurn) || errors.Is(err, clock.ErrFlagFell):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	}
+}
+
+// writeJSON writes a value as the JSON body of a response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// methodNotAllowed answers a request with a method the resource does not have
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+// newID returns a random ID for a game
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/storage"
+)
+
+// request sends a request to the server and returns the status and body
+func request(t *testing.T, url, method, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("FAILED: %s %s\n\t%s", method, url, err.Error())
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("FAILED: %s %s\n\t%s", method, url, err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestServer_Game(t *testing.T) {
+	ts := httptest.NewServer(New())
+	defer ts.Close()
+
+	status, body := request(t, ts.URL+"/games", http.MethodPost, "")
+	var state State
+	if err := json.Unmarshal([]byte(body), &state); err != nil || status != http.StatusCreated {
+		t.Fatalf("FAILED\n\tgot:     %d %s\n\texpected:%d", status, body, http.StatusCreated)
+	}
+	if len(state.LegalMoves) != 20 || state.Turn != "white" || state.Outcome != "*" {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%s", state, "the starting position")
+	}
+	game := ts.URL + "/games/" + state.ID
+
+	inputs := []struct{ method, path, body string }{
+		{http.MethodPost, "/moves", `{"move": "f3"}`},
+		{http.MethodPost, "/moves", `{"move": "e7e5"}`},
+		{http.MethodPost, "/moves", `{"move": "e4e5"}`},
+		{http.MethodPost, "/moves", `{"mov": "g4"}`},
+		{http.MethodPost, "/moves", `{"move": "g4"}`},
+		{http.MethodPost, "/undo", ""},
+		{http.MethodPost, "/moves", `{"move": "g4"}`},
+		{http.MethodPost, "/moves", `{"move": "Qh4"}`},
+		{http.MethodPost, "/moves", `{"move": "a3"}`},
+		{http.MethodGet, "/moves", ""},
+		{http.MethodGet, "/pgn", ""},
+		{http.MethodGet, "", ""},
+		{http.MethodDelete, "", ""},
+		{http.MethodGet, "", ""},
+	}
+
+	expectedOutputs := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, `"fen":"rnbqkbnr/pppppppp/8/8/8/5P2/PPPPP1PP/RNBQKBNR b KQkq - 0 1"`},
+		{http.StatusOK, `"history":[{"uci":"f2f3","san":"f3"},{"uci":"e7e5","san":"e5"}]`},
+		{http.StatusUnprocessableEntity, `"error":"Invalid Move e4e5`},
+		{http.StatusBadRequest, `"error":"expected {\"move\": \"...\"}"`},
+		{http.StatusOK, `"turn":"black"`},
+		{http.StatusOK, `"turn":"white"`},
+		{http.StatusOK, `"turn":"black"`},
+		{http.StatusOK, `"legalMoves":[],"outcome":"0-1","method":"checkmate"`},
+		{http.StatusConflict, `"error":"Invalid Move a3: the game is over"`},
+		{http.StatusMethodNotAllowed, `"error":"method not allowed"`},
+		{http.StatusOK, "1. f3 e5 2. g4 Qh4# 0-1"},
+		{http.StatusOK, `"check":true`},
+		{http.StatusNoContent, ""},
+		{http.StatusNotFound, `"error":"game not found"`},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		status, body := request(t, game+input.path, input.method, input.body)
+		if status != expected.status || !strings.Contains(body, expected.body) {
+			t.Errorf("FAILED: %+v\n\tgot:     %d %s\n\texpected:%d %s", input, status, body, expected.status, expected.body)
+		}
+	}
+}
+func TestServer_Seated(t *testing.T) {
+	server := New()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	game, _ := server.Create("", nil)
+
+	_, white := connect(t, ts, game.ID, "color=white")
+	black, welcome := connect(t, ts, game.ID, "color=black")
+	url := ts.URL + "/games/" + game.ID
+
+	inputs := []struct{ path, body string }{
+		{"/moves", `{"move": "e4"}`},
+		{"/moves", `{"move": "e4", "token": "` + welcome.Token + `"}`},
+		{"/moves", `{"move": "e4", "token": "` + white.Token + `"}`},
+		{"/undo", ""},
+		{"/undo", `{"token": "` + welcome.Token + `"}`},
+		{"/undo", `{"token": "` + white.Token + `"}`},
+	}
+
+	expectedOutputs := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusForbidden, ErrSeatToken.Error()},
+		{http.StatusConflict, engine.ErrNotYourTurn.Error()},
+		{http.StatusOK, `"turn":"black"`},
+		{http.StatusForbidden, ErrSeatToken.Error()},
+		{http.StatusConflict, engine.ErrNotYourTurn.Error()},
+		{http.StatusOK, `"turn":"white"`},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		status, body := request(t, url+input.path, http.MethodPost, input.body)
+		if status != expected.status || !strings.Contains(body, expected.body) {
+			t.Errorf("FAILED: %+v\n\tgot:     %d %s\n\texpected:%d %s", input, status, body, expected.status, expected.body)
+		}
+	}
+
+	// The players see the moves made with their tokens
+	await(t, black, "move", `"san":"e4"`)
+	await(t, black, "undo", `"history":[]`)
+}
+func TestServer_Create(t *testing.T) {
+	ts := httptest.NewServer(New())
+	defer ts.Close()
+
+	inputs := []string{
+		`{"fen": "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"}`,
+		`{"fen": "4k3/8/8/8/8/8/8/r3K3 b - - 0 1"}`,
+		`{"fen": "4k3/8/8/8/8/8/4P3/4KK2 w - - 0 1"}`,
+		`fen`,
+		`{"clock": "5+3"}`,
+		`{"clock": "5+"}`,
+	}
+
+	expectedOutputs := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusCreated, `"startFen":"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"`},
+		{http.StatusBadRequest, `"error":"Invalid position: turn`},
+		{http.StatusBadRequest, `"error":`},
+		{http.StatusBadRequest, `"error":"expected {\"fen\": \"...\", \"clock\": \"...\"}"`},
+		{http.StatusCreated, `"clock":{"white":300000,"black":300000,"running":false}`},
+		{http.StatusBadRequest, `"error":"clock: invalid time control \"5+\""`},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		status, body := request(t, ts.URL+"/games", http.MethodPost, input)
+		if status != expected.status || !strings.Contains(body, expected.body) {
+			t.Errorf("FAILED: %s\n\tgot:     %d %s\n\texpected:%d %s", input, status, body, expected.status, expected.body)
+		}
+	}
+
+	status, body := request(t, ts.URL+"/games", http.MethodGet, "")
+	var list struct{ Games []string }
+	if json.Unmarshal([]byte(body), &list); status != http.StatusOK || len(list.Games) != 2 {
+		t.Errorf("FAILED\n\tgot:     %d %s\n\texpected:%s", status, body, "two games")
+	}
+}
+func TestServer_Restore(t *testing.T) {
+	store := storage.NewMemory()
+	store.Create(storage.Record{ID: "good", Moves: []storage.Move{{UCI: "e2e4"}}})
+	store.Create(storage.Record{ID: "bad", Moves: []storage.Move{{UCI: "e2e5"}}})
+
+	// A game that can not be replayed does not keep the others from loading
+	var logs bytes.Buffer
+	server := NewWithStore(store)
+	server.ErrorLog = log.New(&logs, "", 0)
+	if n, err := server.Restore(); n != 1 || err != nil {
+		t.Errorf("FAILED\n\tgot:     %d %v\n\texpected:%d", n, err, 1)
+	}
+	if _, ok := server.games["good"]; !ok {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%s", server.games, "game good loaded")
+	}
+	if !strings.Contains(logs.String(), "game bad") {
+		t.Errorf("FAILED\n\tgot:     %q\n\texpected:%s", logs.String(), "game bad")
+	}
+}
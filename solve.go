@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/problem"
+)
+
+// runSolve solves a mate in N moves, writes its solution tree with its
+// cooks and duals, and returns the exit code: 1 when it has no solution
+func runSolve(args []string) int {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	moves := flags.Int("moves", 2, "number of moves of the mate")
+	timeout := flags.Duration("timeout", 0, "time after which the search gives up, none when 0")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go solve [flags] FEN")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	chess, err := engine.NewChessGameWithFen(strings.Join(flags.Args(), " "), engine.Strict)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "solve:", err)
+		return 2
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	solution, err := problem.Solve(ctx, chess, *moves)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "solve:", err)
+		return 1
+	}
+
+	fmt.Print(solution)
+	if solution.Cooked() {
+		fmt.Printf("\nCooked: %d keys\n", len(solution.Keys))
+	}
+	for _, dual := range solution.Duals() {
+		fmt.Printf("Dual after %s: %s\n", strings.Join(dual.Line, " "), strings.Join(dual.Moves, ", "))
+	}
+	fmt.Printf("\nSolved in %s\n", time.Since(start).Round(time.Millisecond))
+
+	if !solution.Solved() {
+		return 1
+	}
+	return 0
+}
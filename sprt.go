@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+
+	"chess-go/match"
+	"chess-go/sprt"
+)
+
+// runSPRT tests a candidate engine against a base engine and returns the exit
+// code: 0 when the candidate is accepted as stronger, 1 otherwise
+func runSPRT(args []string) int {
+	flags := flag.NewFlagSet("sprt", flag.ExitOnError)
+	candidate := flags.String("candidate", "", `candidate engine settings "name=N cmd=PATH arg=A depth=D option.NAME=VALUE"`)
+	base := flags.String("base", "", "base engine settings, like -candidate")
+	var test sprt.Test
+	flags.Float64Var(&test.Elo0, "elo0", 0, "Elo difference of the null hypothesis")
+	flags.Float64Var(&test.Elo1, "elo1", 5, "Elo difference of the alternative hypothesis")
+	flags.Float64Var(&test.Alpha, "alpha", 0.05, "probability of accepting H1 when H0 is true")
+	flags.Float64Var(&test.Beta, "beta", 0.05, "probability of accepting H0 when H1 is true")
+	maxGames := flags.Int("max-games", 0, "most games played before giving up, 0 for no limit")
+	gameFlags := addMatchFlags(flags, runtime.NumCPU(), "SPRT")
+	flags.Parse(args)
+
+	var engines [2]match.EngineConfig
+	for i, settings := range []string{*candidate, *base} {
+		config, err := match.ParseEngineConfig(settings)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		engines[i] = config
+	}
+	if engines[0].Name == "" {
+		engines[0].Name = "candidate"
+	}
+	if engines[1].Name == "" {
+		engines[1].Name = "base"
+	}
+
+	config, out, err := gameFlags.matchConfig(engines, *maxGames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer out.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sprtConfig := sprt.Config{
+		Test:  test,
+		Match: config,
+		Game: func(result match.GameResult) {
+			reportGame(result, out)
+		},
+	}
+
+	status, err := sprt.Run(ctx, sprtConfig, func(status sprt.Status) {
+		fmt.Println(status)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	fmt.Println(status)
+	if status.Decision != sprt.AcceptH1 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,240 @@
+// Package sprt runs a sequential probability ratio test between a candidate
+// and a base engine, playing game pairs until one of two hypotheses on their
+// Elo difference is accepted
+package sprt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"chess-go/match"
+)
+
+// regularization replaces the empty cells of a pentanomial, as fishtest does,
+// so that the variance is never zero. It is small enough not to bias the LLR
+// of the first pairs.
+const regularization = 1e-3
+
+// Decision is the state of a test
+type Decision uint8
+
+const (
+	Continue Decision = iota
+	// AcceptH0 means the candidate is not stronger than Elo0
+	AcceptH0
+	// AcceptH1 means the candidate is at least Elo1 stronger
+	AcceptH1
+)
+
+// String returns the decision as written in reports
+func (d Decision) String() string {
+	switch d {
+	case AcceptH0:
+		return "H0 accepted"
+	case AcceptH1:
+		return "H1 accepted"
+	}
+	return "continue"
+}
+
+// Test is the setup of a test: the logistic Elo differences of the two
+// hypotheses and the probabilities of wrongly accepting each of them
+type Test struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64
+	Beta  float64
+}
+
+// Bounds returns the log-likelihood ratios at which H0 and H1 are accepted
+func (t Test) Bounds() (lower, upper float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// Decide returns the decision for a log-likelihood ratio
+func (t Test) Decide(llr float64) Decision {
+	lower, upper := t.Bounds()
+	switch {
+	case llr >= upper:
+		return AcceptH1
+	case llr <= lower:
+		return AcceptH0
+	}
+	return Continue
+}
+
+// validate checks that the test can be decided
+func (t Test) validate() error {
+	if t.Elo1 <= t.Elo0 {
+		return errors.New("sprt: elo1 must be greater than elo0")
+	}
+	if t.Alpha <= 0 || t.Alpha >= 1 || t.Beta <= 0 || t.Beta >= 1 {
+		return errors.New("sprt: alpha and beta must be between 0 and 1")
+	}
+	return nil
+}
+
+// Pentanomial counts game pairs by the points the candidate scored in them:
+// 0, 0.5, 1, 1.5 and 2
+type Pentanomial [5]int
+
+// Add counts a pair with the points of the candidate, from 0 to 2
+func (p *Pentanomial) Add(points float64) {
+	p[int(math.Round(points*2))]++
+}
+
+// Pairs returns the number of pairs counted
+func (p Pentanomial) Pairs() int {
+	return p[0] + p[1] + p[2] + p[3] + p[4]
+}
+
+// stats returns the mean and variance of the score of the candidate per game
+// in a pair, from 0 to 1, over n pairs with empty cells regularized
+func (p Pentanomial) stats() (mean, variance, n float64) {
+	var counts [5]float64
+	for i, count := range p {
+		counts[i] = float64(count)
+		if count == 0 {
+			counts[i] = regularization
+		}
+		n += counts[i]
+	}
+
+	for i, count := range counts {
+		mean += count / n * float64(i) / 4
+	}
+	for i, count := range counts {
+		variance += count / n * math.Pow(float64(i)/4-mean, 2)
+	}
+	return mean, variance, n
+}
+
+// LLR returns the log-likelihood ratio of the hypotheses elo0 and elo1 with
+// the generalized SPRT approximation, 0 before any pair
+func (p Pentanomial) LLR(elo0, elo1 float64) float64 {
+	if p.Pairs() == 0 {
+		return 0
+	}
+
+	mean, variance, n := p.stats()
+	s0, s1 := expectedScore(elo0), expectedScore(elo1)
+	return n * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Elo returns the Elo difference of the candidate and the margin of its 95%
+// confidence interval
+func (p Pentanomial) Elo() (diff, margin float64) {
+	if p.Pairs() == 0 {
+		return 0, math.NaN()
+	}
+
+	mean, variance, n := p.stats()
+	deviation := math.Sqrt(variance / n)
+
+	low := match.EloFromScore(mean - 1.959964*deviation)
+	high := match.EloFromScore(mean + 1.959964*deviation)
+	return match.EloFromScore(mean), (high - low) / 2
+}
+
+// expectedScore returns the score expected for a logistic Elo difference
+func expectedScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Config is the setup of a test run. The first engine of the match is the
+// candidate and the second the base, the number of games of the match is the
+// most games played before giving up, 0 for no limit.
+type Config struct {
+	Test
+	Match match.Config
+	// Game is called after each game when it is set
+	Game func(match.GameResult)
+}
+
+// Status is the state of a test after a pair of games
+type Status struct {
+	Pentanomial Pentanomial
+	// Score counts the games of the candidate
+	Score    match.Score
+	LLR      float64
+	Lower    float64
+	Upper    float64
+	Decision Decision
+}
+
+// String returns the status as a report line
+func (s Status) String() string {
+	diff, margin := s.Pentanomial.Elo()
+	return fmt.Sprintf("Games: %d, Elo: %.1f +/- %.1f, Ptnml(0-2): %v, LLR: %.2f (%.2f, %.2f) %s",
+		s.Score.Games(), diff, margin, s.Pentanomial, s.LLR, s.Lower, s.Upper, s.Decision)
+}
+
+// Run plays game pairs between the candidate and the base engine, on as many
+// games at the same time as there are CPUs unless the match sets it, until a
+// hypothesis is accepted. report is called after each pair. When the match
+// ends before a decision the status is returned with Continue.
+func Run(ctx context.Context, config Config, report func(Status)) (Status, error) {
+	status := Status{}
+	status.Lower, status.Upper = config.Bounds()
+	if err := config.validate(); err != nil {
+		return status, err
+	}
+
+	matchConfig := config.Match
+	if matchConfig.Games <= 0 {
+		matchConfig.Games = math.MaxInt32 - 1
+	}
+	if matchConfig.Concurrency <= 0 {
+		matchConfig.Concurrency = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Pairs are counted once both of their games are finished
+	var mu sync.Mutex
+	pending := map[int]float64{}
+
+	_, err := match.Run(ctx, matchConfig, func(result match.GameResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if status.Decision != Continue {
+			return
+		}
+
+		if config.Game != nil {
+			config.Game(result)
+		}
+
+		points := result.Points()
+		status.Score.Add(points)
+
+		first, ok := pending[result.Pair]
+		if !ok {
+			pending[result.Pair] = points
+			return
+		}
+		delete(pending, result.Pair)
+
+		status.Pentanomial.Add(first + points)
+		status.LLR = status.Pentanomial.LLR(config.Elo0, config.Elo1)
+		status.Decision = config.Decide(status.LLR)
+		if report != nil {
+			report(status)
+		}
+
+		if status.Decision != Continue {
+			cancel()
+		}
+	})
+
+	if status.Decision != Continue {
+		return status, nil
+	}
+	return status, err
+}
@@ -0,0 +1,109 @@
+package sprt
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/match"
+	"chess-go/uci"
+)
+
+// confidentPlayer plays the first legal move and scores every position the
+// same, so that resign adjudication ends the games
+type confidentPlayer struct {
+	score int
+}
+
+func (c *confidentPlayer) Name() string                  { return "confident" }
+func (c *confidentPlayer) NewGame(context.Context) error { return nil }
+func (c *confidentPlayer) Close() error                  { return nil }
+
+func (c *confidentPlayer) Play(_ context.Context, chess *engine.Chess, _ uci.GoParams) (match.Reply, error) {
+	return match.Reply{Move: chess.LegalMoves()[0], Score: c.score, HasScore: true, Depth: 1}, nil
+}
+
+// confident returns the config of an engine made of a confident player
+func confident(score int) match.EngineConfig {
+	return match.EngineConfig{New: func(context.Context) (match.Player, error) {
+		return &confidentPlayer{score: score}, nil
+	}}
+}
+
+func TestSPRT_Bounds(t *testing.T) {
+	lower, upper := Test{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("FAILED\n\tgot:     %.3f %.3f\n\texpected:%.3f %.3f", lower, upper, -2.944, 2.944)
+	}
+}
+func TestSPRT_LLR(t *testing.T) {
+	inputs := []Pentanomial{
+		{},
+		{10, 20, 40, 20, 10},
+		{100, 2000, 4000, 2100, 110},
+		{50, 1500, 3000, 2000, 100},
+		{300, 2200, 4000, 1800, 200},
+	}
+
+	expectedOutputs := []float64{0, -0.03, 0.02, 23.86, -29.66}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := input.LLR(0, 5)
+
+		if math.Abs(output-expected) > 0.01 {
+			t.Errorf("FAILED: %v\n\tgot:     %.2f\n\texpected:%.2f", input, output, expected)
+		}
+	}
+}
+func TestSPRT_LLREmptyCells(t *testing.T) {
+	inputs := []Pentanomial{
+		{0, 3, 6, 4, 1},
+		{0, 2, 5, 8, 0},
+		{0, 20, 40, 25, 0},
+		{1, 30, 40, 20, 0},
+	}
+
+	expectedOutputs := []float64{0.109, 0.328, 0.207, -0.665}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := input.LLR(0, 5)
+
+		if math.Abs(output-expected) > 0.001 {
+			t.Errorf("FAILED: %v\n\tgot:     %.3f\n\texpected:%.3f", input, output, expected)
+		}
+	}
+}
+func TestSPRT_Run(t *testing.T) {
+	inputs := []Config{
+		{Match: match.Config{Engines: [2]match.EngineConfig{confident(600), confident(-600)}}},
+		{Match: match.Config{Engines: [2]match.EngineConfig{confident(-600), confident(600)}}},
+		{Match: match.Config{Engines: [2]match.EngineConfig{confident(0), confident(0)}, Games: 6}},
+	}
+
+	expectedOutputs := []Decision{AcceptH1, AcceptH0, Continue}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		input.Test = Test{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+		input.Match.Adjudicator = match.Adjudicator{ResignMoves: 1, ResignScore: 500, MaxMoves: 5}
+
+		pairs := 0
+		output, err := Run(context.Background(), input, func(Status) {
+			pairs++
+		})
+		if err != nil {
+			t.Fatalf("FAILED: %d\n\t%s", i, err.Error())
+		}
+
+		if output.Decision != expected || output.Pentanomial.Pairs() != pairs || pairs == 0 {
+			t.Errorf("FAILED: %d\n\tgot:     %s\n\texpected:%s", i, output, expected)
+		}
+	}
+
+	if _, err := Run(context.Background(), Config{Test: Test{Elo0: 5, Elo1: 0, Alpha: 0.05, Beta: 0.05}}, nil); err == nil {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, "an error")
+	}
+}
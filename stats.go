@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"chess-go/analysis"
+	"chess-go/pgn"
+)
+
+// playerStats are the statistics of a player written in JSON
+type playerStats struct {
+	Name         string  `json:"name"`
+	Games        int     `json:"games"`
+	Moves        int     `json:"moves"`
+	Accuracy     float64 `json:"accuracy"`
+	ACPL         float64 `json:"acpl"`
+	Best         int     `json:"best"`
+	Good         int     `json:"good"`
+	Inaccuracies int     `json:"inaccuracies"`
+	Mistakes     int     `json:"mistakes"`
+	Blunders     int     `json:"blunders"`
+}
+
+// runStats analyzes the games of PGN databases, writes the accuracy and the
+// average centipawn loss of their players and returns the exit code
+func runStats(args []string) int {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	analysisOptions := analysisFlags(flags)
+	asJSON := flags.Bool("json", false, "write the statistics in JSON instead of a table")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: chess-go stats [flags] GAMES.pgn...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	options, err := analysisOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	report := analysis.NewReport()
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		games, err := pgn.ReadAll(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			return 1
+		}
+
+		for i, game := range games {
+			chess, err := game.Replay()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+				continue
+			}
+			moves, err := analysis.Analyze(context.Background(), chess, options, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: game %d: %s\n", path, i+1, err)
+				continue
+			}
+			report.RecordGame(game, moves)
+		}
+	}
+
+	if !*asJSON {
+		if err := report.WriteTable(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	players := []playerStats{}
+	for _, stats := range report.Players() {
+		players = append(players, playerStats{
+			Name:         stats.Name,
+			Games:        stats.Games,
+			Moves:        stats.Moves,
+			Accuracy:     stats.Accuracy(),
+			ACPL:         stats.ACPL(),
+			Best:         stats.Grades[analysis.Best],
+			Good:         stats.Grades[analysis.Good],
+			Inaccuracies: stats.Grades[analysis.Inaccuracy],
+			Mistakes:     stats.Grades[analysis.Mistake],
+			Blunders:     stats.Grades[analysis.Blunder],
+		})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(players); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
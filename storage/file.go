@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+// logExt is the extension of the files of the games
+const logExt = ".jsonl"
+
+// File is a store that keeps each game in a file of a directory. The file is
+// a log with the record of the game on its first line and a change per line
+// after it, so that a move is a single append. The games are read once and
+// kept in memory, each with its own lock so that games are written at the
+// same time.
+type File struct {
+	dir string
+	// mu guards games, the lock of a game is taken without it
+	mu    sync.Mutex
+	games map[string]*fileGame
+}
+
+// fileGame is a game of a File store, its lock guards its fields and its file
+type fileGame struct {
+	mu sync.Mutex
+	// record is the game as stored once loaded is set, size is the length of
+	// its file without a line cut short
+	record Record
+	size   int64
+	loaded bool
+	// deleted is set once the game is removed, the next user of its ID gets
+	// a new fileGame
+	deleted bool
+}
+
+// NewFile returns a store in a directory, which is created if needed
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, games: map[string]*fileGame{}}, nil
+}
+
+func (f *File) Create(record Record) error {
+	if !validID(record.ID) {
+		return fmt.Errorf("storage: invalid game ID %q", record.ID)
+	}
+
+	g := f.lock(record.ID)
+	defer g.mu.Unlock()
+
+	file, err := os.OpenFile(f.path(record.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return ErrExists
+	}
+	if err != nil {
+		return err
+	}
+
+	record = prepare(record)
+	size, err := writeLine(file, record)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		g.record, g.size, g.loaded = record, size, true
+	}
+	return err
+}
+
+func (f *File) Load(id string) (Record, error) {
+	if !validID(id) {
+		return Record{}, ErrNotFound
+	}
+
+	g, err := f.open(id)
+	if err != nil {
+		return Record{}, err
+	}
+	defer g.mu.Unlock()
+
+	return clone(g.record), nil
+}
+
+func (f *File) List(filter Filter) ([]Record, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), logExt)
+		if !ok || entry.IsDir() || !validID(id) {
+			continue
+		}
+		g, err := f.open(id)
+		// A game deleted since the directory was read is left out
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filter.match(g.record) {
+			records = append(records, clone(g.record))
+		}
+		g.mu.Unlock()
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (f *File) AppendMove(id string, move Move) error {
+	return f.apply(id, change{Move: &move})
+}
+
+func (f *File) Undo(id string) error {
+	return f.apply(id, change{Undo: true})
+}
+
+func (f *File) Seat(id string, color engine.Color, player Player) error {
+	return f.apply(id, change{Color: color, Player: &player})
+}
+
+func (f *File) Finish(id string, outcome engine.Outcome, method engine.Method) error {
+	return f.apply(id, change{Finish: &outcome, Method: method})
+}
+
+func (f *File) Delete(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	g := f.lock(id)
+	defer g.mu.Unlock()
+
+	g.deleted = true
+	f.forget(id, g)
+
+	err := os.Remove(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// apply checks a change against the game and appends it to its file
+func (f *File) apply(id string, c change) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	g, err := f.open(id)
+	if err != nil {
+		return err
+	}
+	defer g.mu.Unlock()
+
+	record := g.record
+	c.Time = time.Now()
+	if err := c.apply(&record); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path(id), os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	// The change replaces a line cut short by a crash or a failed write
+	var size int64
+	if err = file.Truncate(g.size); err == nil {
+		if _, err = file.Seek(g.size, 0); err == nil {
+			size, err = writeLine(file, c)
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		g.record = record
+		g.size += size
+	}
+	return err
+}
+
+// lock returns the locked game of an ID, which is not loaded when the ID was
+// not used yet
+func (f *File) lock(id string) *fileGame {
+	for {
+		f.mu.Lock()
+		g, ok := f.games[id]
+		if !ok {
+			g = &fileGame{}
+			f.games[id] = g
+		}
+		f.mu.Unlock()
+
+		g.mu.Lock()
+		if !g.deleted {
+			return g
+		}
+		// The game was deleted while waiting for it
+		g.mu.Unlock()
+	}
+}
+
+// open returns the locked game of an ID, read from its file the first time
+func (f *File) open(id string) (*fileGame, error) {
+	g := f.lock(id)
+	if g.loaded {
+		return g, nil
+	}
+
+	record, size, err := f.read(id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			f.forget(id, g)
+		}
+		g.mu.Unlock()
+		return nil, err
+	}
+	g.record, g.size, g.loaded = record, size, true
+	return g, nil
+}
+
+// forget drops a game that is not stored from the games in memory, the lock
+// of the game must be held
+func (f *File) forget(id string, g *fileGame) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.games[id] == g {
+		delete(f.games, id)
+	}
+}
+
+// read reads the record of a game and applies its changes, size is the
+// length of the file without a line cut short
+func (f *File) read(id string) (record Record, size int64, err error) {
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, 0, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, 0, err
+	}
+
+	// A write cut short by a crash leaves a last line without its end, which
+	// is left out
+	lines := bytes.Split(data, []byte("\n"))
+	lines, torn := lines[:len(lines)-1], lines[len(lines)-1]
+	size = int64(len(data) - len(torn))
+	if len(lines) == 0 {
+		return Record{}, 0, fmt.Errorf("storage: game %s has no record", id)
+	}
+
+	for i, line := range lines {
+		if i == 0 {
+			err = json.Unmarshal(line, &record)
+		} else {
+			var c change
+			if err = json.Unmarshal(line, &c); err == nil {
+				err = c.apply(&record)
+			}
+		}
+		if err != nil {
+			return Record{}, 0, fmt.Errorf("storage: game %s line %d: %w", id, i+1, err)
+		}
+	}
+	return record, size, nil
+}
+
+// path returns the file of a game
+func (f *File) path(id string) string {
+	return filepath.Join(f.dir, id+logExt)
+}
+
+// writeLine writes a value as a line of JSON, flushes it to the disk and
+// returns its length
+func writeLine(file *os.File, value any) (int64, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	data = append(data, '\n')
+	if _, err := file.Write(data); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), file.Sync()
+}
+
+// validID reports whether an ID can name a file
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+// Memory is a store that keeps games in memory, for tests and servers that
+// do not need to outlive their process
+type Memory struct {
+	mu    sync.Mutex
+	games map[string]*Record
+}
+
+// NewMemory returns an empty store in memory
+func NewMemory() *Memory {
+	return &Memory{games: map[string]*Record{}}
+}
+
+func (m *Memory) Create(record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[record.ID]; ok {
+		return ErrExists
+	}
+	record = prepare(record)
+	m.games[record.ID] = &record
+	return nil
+}
+
+func (m *Memory) Load(id string) (Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.games[id]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return clone(*record), nil
+}
+
+func (m *Memory) List(filter Filter) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := []Record{}
+	for _, record := range m.games {
+		if filter.match(*record) {
+			records = append(records, clone(*record))
+		}
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (m *Memory) AppendMove(id string, move Move) error {
+	return m.apply(id, change{Move: &move})
+}
+
+func (m *Memory) Undo(id string) error {
+	return m.apply(id, change{Undo: true})
+}
+
+func (m *Memory) Seat(id string, color engine.Color, player Player) error {
+	return m.apply(id, change{Color: color, Player: &player})
+}
+
+func (m *Memory) Finish(id string, outcome engine.Outcome, method engine.Method) error {
+	return m.apply(id, change{Finish: &outcome, Method: method})
+}
+
+func (m *Memory) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.games, id)
+	return nil
+}
+
+// apply applies a change to a stored game
+func (m *Memory) apply(id string, c change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.games[id]
+	if !ok {
+		return ErrNotFound
+	}
+	c.Time = time.Now()
+	return c.apply(record)
+}
+
+// prepare returns a new record as it is stored
+func prepare(record Record) Record {
+	record = clone(record)
+	record.Status = InProgress
+	if record.Created.IsZero() {
+		record.Created = time.Now()
+	}
+	record.Updated = record.Created
+	return record
+}
+
+// clone returns a copy of a record that does not share its moves
+func clone(record Record) Record {
+	record.Moves = append([]Move{}, record.Moves...)
+	return record
+}
@@ -0,0 +1,200 @@
+// Package storage keeps games so that they outlive the process playing them:
+// a game is its starting position and the moves played from it, replayed
+// into engine.Chess when loaded
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"chess-go/engine"
+)
+
+var (
+	// ErrNotFound is returned for a game that is not stored
+	ErrNotFound = errors.New("storage: game not found")
+	// ErrExists is returned when creating a game with the ID of another
+	ErrExists = errors.New("storage: game already exists")
+	// ErrFinished is returned when changing a finished game
+	ErrFinished = errors.New("storage: game is finished")
+)
+
+// Status tells whether a game is played
+type Status string
+
+const (
+	InProgress Status = "in-progress"
+	Finished   Status = "finished"
+)
+
+// Move is a stored move
+type Move struct {
+	UCI string `json:"uci"`
+	// White and Black are the times left on the clock after the move, in
+	// games on the clock
+	White time.Duration `json:"white,omitempty"`
+	Black time.Duration `json:"black,omitempty"`
+}
+
+// Player is a player seated in a game
+type Player struct {
+	Name string `json:"name,omitempty"`
+	// Token takes the seat back after a disconnection
+	Token string `json:"token,omitempty"`
+}
+
+// Record is a stored game
+type Record struct {
+	ID string `json:"id"`
+	// StartFEN is the starting position, the standard one when empty
+	StartFEN string `json:"startFen,omitempty"`
+	// Clock is the time control, see clock.ParseControl, empty without clock
+	Clock string `json:"clock,omitempty"`
+	White Player `json:"white"`
+	Black Player `json:"black"`
+	Moves []Move `json:"moves"`
+
+	Status  Status         `json:"status"`
+	Outcome engine.Outcome `json:"outcome"`
+	Method  engine.Method  `json:"method"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// Replay returns the game played by the moves of the record, ended like the
+// record when it is finished
+func (r Record) Replay() (*engine.Chess, error) {
+	chess := engine.NewGameChess()
+	if r.StartFEN != "" {
+		var err error
+		if chess, err = engine.NewChessGameWithFen(r.StartFEN); err != nil {
+			return nil, fmt.Errorf("storage: game %s: %w", r.ID, err)
+		}
+	}
+
+	for _, move := range r.Moves {
+		if _, err := chess.MoveUCI(move.UCI); err != nil {
+			return nil, fmt.Errorf("storage: game %s: %w", r.ID, err)
+		}
+	}
+
+	// Games ended by the players or the clock are ended again
+	if r.Status == Finished && !chess.IsGameOver() {
+		end(chess, r.Outcome, r.Method)
+	}
+
+	return chess, nil
+}
+
+// end ends a game like it was ended by the players or the clock
+func end(chess *engine.Chess, outcome engine.Outcome, method engine.Method) {
+	loser := engine.White
+	if outcome == engine.WhiteWon {
+		loser = engine.Black
+	}
+
+	switch method {
+	case engine.Timeout, engine.TimeoutVsInsufficientMaterial:
+		if outcome == engine.Draw {
+			loser = chess.Turn()
+		}
+		chess.Timeout(loser)
+	case engine.Resignation:
+		chess.Resign(loser)
+	case engine.Abandonment:
+		chess.Abandon(loser)
+	case engine.FiftyMoveRule, engine.ThreefoldRepetition:
+		chess.ClaimDraw()
+	default:
+		chess.AgreeDraw()
+	}
+}
+
+// Filter selects games when listing them, zero fields select every game
+type Filter struct {
+	// Player is the name of the white or black player
+	Player string
+	Status Status
+}
+
+// match reports whether a game is selected by the filter
+func (f Filter) match(record Record) bool {
+	if f.Player != "" && record.White.Name != f.Player && record.Black.Name != f.Player {
+		return false
+	}
+	return f.Status == "" || record.Status == f.Status
+}
+
+// Store stores games
+type Store interface {
+	// Create stores a new game, the status of the record is set to
+	// InProgress
+	Create(record Record) error
+	// Load returns a game
+	Load(id string) (Record, error)
+	// List returns the games selected by a filter, oldest first
+	List(filter Filter) ([]Record, error)
+	// AppendMove adds a move to a game in progress
+	AppendMove(id string, move Move) error
+	// Undo takes back the last move of a game in progress
+	Undo(id string) error
+	// Seat seats a player in a game in progress
+	Seat(id string, color engine.Color, player Player) error
+	// Finish ends a game with its outcome
+	Finish(id string, outcome engine.Outcome, method engine.Method) error
+	// Delete removes a game
+	Delete(id string) error
+}
+
+// change is a change of a stored game, with what is needed to apply it to
+// its record
+type change struct {
+	Move   *Move           `json:"move,omitempty"`
+	Undo   bool            `json:"undo,omitempty"`
+	Color  engine.Color    `json:"color,omitempty"`
+	Player *Player         `json:"player,omitempty"`
+	Finish *engine.Outcome `json:"finish,omitempty"`
+	Method engine.Method   `json:"method,omitempty"`
+	Time   time.Time       `json:"time"`
+}
+
+// apply applies a change to a record
+func (c change) apply(record *Record) error {
+	if record.Status == Finished {
+		return ErrFinished
+	}
+
+	switch {
+	case c.Move != nil:
+		record.Moves = append(record.Moves, *c.Move)
+	case c.Undo:
+		if len(record.Moves) == 0 {
+			return engine.ErrNoHistory
+		}
+		record.Moves = record.Moves[:len(record.Moves)-1]
+	case c.Player != nil && c.Color == engine.White:
+		record.White = *c.Player
+	case c.Player != nil:
+		record.Black = *c.Player
+	case c.Finish != nil:
+		record.Status = Finished
+		record.Outcome = *c.Finish
+		record.Method = c.Method
+	}
+
+	record.Updated = c.Time
+	return nil
+}
+
+// sortRecords sorts games by creation, oldest first
+func sortRecords(records []Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].Created.Equal(records[j].Created) {
+			return records[i].Created.Before(records[j].Created)
+		}
+		return records[i].ID < records[j].ID
+	})
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+)
+
+// stores returns a store of each kind
+func stores(t *testing.T) map[string]Store {
+	file, err := NewFile(t.TempDir())
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	return map[string]Store{"memory": NewMemory(), "file": file}
+}
+
+func TestStorage_Store(t *testing.T) {
+	for name, store := range stores(t) {
+		created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		records := []Record{
+			{ID: "a", White: Player{Name: "Ann"}, Created: created},
+			{ID: "b", StartFEN: "4k3/8/8/8/8/8/8/4K2R w K - 0 1", Clock: "5+3", Created: created.Add(time.Hour)},
+		}
+		for _, record := range records {
+			if err := store.Create(record); err != nil {
+				t.Fatalf("FAILED: %s\n\t%s", name, err.Error())
+			}
+		}
+		if err := store.Create(records[0]); !errors.Is(err, ErrExists) {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", name, err, ErrExists)
+		}
+
+		inputs := []func() error{
+			func() error { return store.AppendMove("a", Move{UCI: "f2f3"}) },
+			func() error { return store.AppendMove("a", Move{UCI: "e7e5"}) },
+			func() error { return store.AppendMove("a", Move{UCI: "a2a3"}) },
+			func() error { return store.Undo("a") },
+			func() error { return store.AppendMove("a", Move{UCI: "g2g4"}) },
+			func() error { return store.Seat("a", engine.Black, Player{Name: "Bob", Token: "t"}) },
+			func() error { return store.AppendMove("b", Move{UCI: "h1h7", White: time.Minute}) },
+			func() error { return store.Seat("b", engine.Black, Player{Name: "Ann"}) },
+			func() error { return store.Finish("b", engine.BlackWon, engine.Resignation) },
+			func() error { return store.AppendMove("b", Move{UCI: "e8d8"}) },
+			func() error { return store.Undo("c") },
+		}
+		expectedOutputs := []error{nil, nil, nil, nil, nil, nil, nil, nil, nil, ErrFinished, ErrNotFound}
+
+		for i, input := range inputs {
+			if err := input(); !errors.Is(err, expectedOutputs[i]) {
+				t.Errorf("FAILED: %s change %d\n\tgot:     %v\n\texpected:%v", name, i, err, expectedOutputs[i])
+			}
+		}
+
+		a, err := store.Load("a")
+		if err != nil || a.White.Name != "Ann" || a.Black.Token != "t" || a.Status != InProgress || len(a.Moves) != 3 {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %v\n\texpected:%s", name, a, err, "game a")
+		}
+		chess, err := a.Replay()
+		if err != nil || chess.GetFEN() != "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2" {
+			t.Errorf("FAILED: %s\n\tgot:     %v %v\n\texpected:%s", name, chess, err, "f3 e5 g4")
+		}
+
+		b, _ := store.Load("b")
+		chess, err = b.Replay()
+		if err != nil || chess.Outcome() != engine.BlackWon || chess.Method() != engine.Resignation || b.Moves[0].White != time.Minute {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %v\n\texpected:%s", name, b, err, "black wins by resignation")
+		}
+
+		filters := []Filter{{}, {Player: "Ann"}, {Player: "Bob"}, {Status: Finished}, {Player: "Bob", Status: Finished}}
+		expectedLists := [][]string{{"a", "b"}, {"a", "b"}, {"a"}, {"b"}, {}}
+		for i, filter := range filters {
+			list, err := store.List(filter)
+			var ids []string
+			for _, record := range list {
+				ids = append(ids, record.ID)
+			}
+			if err != nil || len(ids) != len(expectedLists[i]) || len(ids) > 0 && ids[0] != expectedLists[i][0] {
+				t.Errorf("FAILED: %s %+v\n\tgot:     %v %v\n\texpected:%v", name, filter, ids, err, expectedLists[i])
+			}
+		}
+
+		if err := store.Delete("a"); err != nil {
+			t.Errorf("FAILED: %s\n\t%s", name, err.Error())
+		}
+		if _, err := store.Load("a"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", name, err, ErrNotFound)
+		}
+	}
+}
+func TestStorage_FileTorn(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFile(dir)
+	store.Create(Record{ID: "game"})
+	store.AppendMove("game", Move{UCI: "e2e4"})
+
+	// A crash while appending the second move
+	file, _ := os.OpenFile(filepath.Join(dir, "game"+logExt), os.O_APPEND|os.O_WRONLY, 0o644)
+	file.WriteString(`{"move":{"uci":"e7`)
+	file.Close()
+
+	record, err := store.Load("game")
+	if err != nil || len(record.Moves) != 1 {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%s", record, err, "one move")
+	}
+
+	store.AppendMove("game", Move{UCI: "e7e5"})
+	record, err = store.Load("game")
+	if err != nil || len(record.Moves) != 2 {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%s", record, err, "two moves")
+	}
+
+	if err := store.Create(Record{ID: "../game"}); err == nil {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%s", err, "an invalid ID")
+	}
+}
+func TestStorage_FileConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFile(dir)
+	ids := []string{"a", "b"}
+	for _, id := range ids {
+		store.Create(Record{ID: id})
+	}
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				if err := store.AppendMove(id, Move{UCI: "e2e4"}); err != nil {
+					t.Errorf("FAILED: %s\n\t%s", id, err.Error())
+				}
+			}(id)
+		}
+	}
+	wg.Wait()
+
+	// The games kept in memory are those of the files
+	reopened, _ := NewFile(dir)
+	for _, id := range ids {
+		cached, _ := store.Load(id)
+		record, err := reopened.Load(id)
+		if err != nil || len(record.Moves) != 20 || len(cached.Moves) != 20 {
+			t.Errorf("FAILED: %s\n\tgot:     %d %d %v\n\texpected:%d", id, len(cached.Moves), len(record.Moves), err, 20)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"chess-go/match"
+	"chess-go/pgn"
+	"chess-go/tournament"
+)
+
+const tournamentUsage = `usage: chess-go tournament COMMAND [flags] [arguments]
+
+commands:
+  new         create a tournament
+  pair        pair the next round
+  result      record a result: ROUND BOARD 1-0|0-1|1/2-1/2|GAME.pgn
+  play        play the engine games of the current round
+  standings   print the standings
+  crosstable  print the crosstable
+  pgn         print the recorded games`
+
+// playerFlags collects the players given with -player
+type playerFlags []tournament.Player
+
+func (p *playerFlags) String() string {
+	return fmt.Sprint(len(*p), " players")
+}
+
+func (p *playerFlags) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	player := tournament.Player{Name: strings.TrimSpace(parts[0])}
+	if player.Name == "" {
+		return errors.New("missing player name")
+	}
+
+	if len(parts) > 1 && parts[1] != "" {
+		rating, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid rating of %s: %q", player.Name, parts[1])
+		}
+		player.Rating = rating
+	}
+	if len(parts) > 2 {
+		if _, err := match.ParseEngineConfig(parts[2]); err != nil {
+			return err
+		}
+		player.Engine = parts[2]
+	}
+
+	*p = append(*p, player)
+	return nil
+}
+
+// runTournament runs a tournament command on the tournament saved in a JSON
+// file and returns the exit code
+func runTournament(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tournamentUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("tournament "+command, flag.ExitOnError)
+	file := flags.String("file", "tournament.json", "file the tournament is saved in")
+
+	var t *tournament.Tournament
+	var err error
+
+	switch command {
+	case "new":
+		name := flags.String("name", "Tournament", "name of the tournament")
+		format := flags.String("format", string(tournament.RoundRobin), `pairing system, "round-robin" or "swiss"`)
+		rounds := flags.Int("rounds", 1, "rounds of a Swiss tournament, cycles of a round robin")
+		tiebreaks := flags.String("tiebreaks", "", `comma separated tiebreaks among "buchholz", "sonneborn-berger" and "direct-encounter", the format's defaults when empty`)
+		var players playerFlags
+		flags.Var(&players, "player", `player "NAME[:RATING[:ENGINE SETTINGS]]", given once per player, the settings are those of match -engine and mark an engine`)
+		flags.Parse(args[1:])
+
+		if _, err := os.Stat(*file); err == nil {
+			fmt.Fprintf(os.Stderr, "tournament: %s already exists\n", *file)
+			return 2
+		}
+		if t, err = newTournament(*name, *format, players, *rounds, *tiebreaks); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Printf("Created %s with %d players and %d rounds\n", t.Name, len(t.Players), t.Rounds)
+		return saveTournament(t, *file)
+
+	case "pair":
+		flags.Parse(args[1:])
+		if t, err = loadTournament(*file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if _, err := t.PairNextRound(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Round %d\n", len(t.Played))
+		t.WriteRound(os.Stdout, len(t.Played))
+		return saveTournament(t, *file)
+
+	case "result":
+		flags.Parse(args[1:])
+		if flags.NArg() != 3 {
+			fmt.Fprintln(os.Stderr, "tournament: result needs a round, a board and a result or PGN file")
+			return 2
+		}
+		round, err1 := strconv.Atoi(flags.Arg(0))
+		board, err2 := strconv.Atoi(flags.Arg(1))
+		if err1 != nil || err2 != nil {
+			fmt.Fprintln(os.Stderr, "tournament: invalid round or board")
+			return 2
+		}
+		if t, err = loadTournament(*file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := recordResult(t, round, board, flags.Arg(2)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return saveTournament(t, *file)
+
+	case "play":
+		gameFlags := addMatchFlags(flags, 1, "")
+		flags.Parse(args[1:])
+		if t, err = loadTournament(*file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(t.Played) == 0 {
+			fmt.Fprintln(os.Stderr, "tournament: no round is paired")
+			return 1
+		}
+
+		config, out, err := gameFlags.matchConfig([2]match.EngineConfig{}, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer out.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// Save after every game so that an interrupted round is not replayed
+		round := len(t.Played)
+		err = t.PlayRound(ctx, round, config, func(board int, result match.GameResult) {
+			fmt.Printf("Round %d board %d (%s vs %s): %s {%s}\n", round, board,
+				result.Game.Tag("White"), result.Game.Tag("Black"), result.Outcome, result.Reason)
+			result.Game.WriteTo(out)
+			saveTournament(t, *file)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return saveTournament(t, *file)
+
+	case "standings", "crosstable", "pgn":
+		flags.Parse(args[1:])
+		if t, err = loadTournament(*file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		switch command {
+		case "standings":
+			err = t.WriteStandings(os.Stdout)
+		case "crosstable":
+			err = t.WriteCrosstable(os.Stdout)
+		case "pgn":
+			err = t.WritePGN(os.Stdout)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, tournamentUsage)
+	return 2
+}
+
+// newTournament creates a tournament from the flags of the new command
+func newTournament(name, format string, players []tournament.Player, rounds int, tiebreaks string) (*tournament.Tournament, error) {
+	parsedFormat, err := tournament.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	t, err := tournament.New(name, parsedFormat, players, rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	if tiebreaks != "" {
+		t.Tiebreaks = nil
+		for _, name := range strings.Split(tiebreaks, ",") {
+			tiebreak, err := tournament.ParseTiebreak(strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			t.Tiebreaks = append(t.Tiebreaks, tiebreak)
+		}
+	}
+	return t, nil
+}
+
+// recordResult records a result given as PGN notation or as a PGN file
+func recordResult(t *tournament.Tournament, round, board int, result string) error {
+	if outcome, ok := pgn.ParseResult(result); ok {
+		return t.RecordResult(round, board, outcome)
+	}
+
+	file, err := os.Open(result)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	game, err := pgn.NewReader(file).Read()
+	if err != nil {
+		return err
+	}
+	return t.RecordGame(round, board, game)
+}
+
+// loadTournament reads a tournament from its file
+func loadTournament(path string) (*tournament.Tournament, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return tournament.Load(file)
+}
+
+// saveTournament writes a tournament to its file and returns the exit code
+func saveTournament(t *tournament.Tournament, path string) int {
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	err = t.Save(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,352 @@
+package tournament
+
+import (
+	"errors"
+	"sort"
+
+	"chess-go/engine"
+)
+
+// maxPairingSteps bounds the search for a Swiss pairing before its rules are
+// relaxed
+const maxPairingSteps = 100000
+
+// pairRoundRobin pairs a round of a round robin with the circle method, the
+// colors are given to balance those of each player in the first cycle
+func (t *Tournament) pairRoundRobin(round int) []Pairing {
+	n := len(t.Players)
+	seats := n + n%2
+
+	// Every other cycle repeats the previous one with the colors reversed
+	if round >= seats-1 && (round/(seats-1))%2 == 1 {
+		var pairings []Pairing
+		for _, pairing := range t.Played[round-(seats-1)].Pairings {
+			if !pairing.IsBye() {
+				pairing.White, pairing.Black = pairing.Black, pairing.White
+			}
+			pairing.Result, pairing.PGN = engine.NoOutcome, ""
+			pairings = append(pairings, pairing)
+		}
+		return pairings
+	}
+	turn := round % (seats - 1)
+
+	// The last seat stays put while the others turn around it, an odd number
+	// of players leaves it empty for the bye
+	seat := func(i int) int {
+		if i == seats-1 {
+			return seats - 1
+		}
+		return (i + turn) % (seats - 1)
+	}
+
+	var pairings, byes []Pairing
+	for i := 0; i < seats/2; i++ {
+		a, b := seat(i), seat(seats-1-i)
+		if b >= n {
+			byes = append(byes, Pairing{White: a, Black: Bye})
+			continue
+		}
+		if a >= n {
+			byes = append(byes, Pairing{White: b, Black: Bye})
+			continue
+		}
+
+		white, black := t.allocateColors(a, b, i)
+		pairings = append(pairings, Pairing{White: white, Black: black})
+	}
+
+	return append(pairings, byes...)
+}
+
+// pairSwiss pairs a round of a Swiss tournament with a simplified Dutch
+// system: players are ranked by points then rating, and in each score group
+// the top half meets the bottom half, without rematches and with compatible
+// colors. The rules are relaxed when no such pairing exists.
+func (t *Tournament) pairSwiss() ([]Pairing, error) {
+	points := t.points()
+	ranking := t.ranking(points)
+
+	// The lowest ranked player who has not had a bye sits out the round
+	var byes []Pairing
+	if len(ranking)%2 == 1 {
+		bye := len(ranking) - 1
+		for i := len(ranking) - 1; i >= 0; i-- {
+			if !t.hadBye(ranking[i]) {
+				bye = i
+				break
+			}
+		}
+		byes = append(byes, Pairing{White: ranking[bye], Black: Bye})
+		ranking = append(ranking[:bye:bye], ranking[bye+1:]...)
+	}
+
+	for _, rules := range []pairingRules{{}, {allowColors: true}, {allowColors: true, allowRematches: true}} {
+		steps := 0
+		pairs, ok := t.pairPlayers(ranking, points, rules, &steps)
+		if !ok {
+			continue
+		}
+
+		var pairings []Pairing
+		for board, pair := range pairs {
+			white, black := t.allocateColors(pair[0], pair[1], board)
+			pairings = append(pairings, Pairing{White: white, Black: black})
+		}
+		return append(pairings, byes...), nil
+	}
+
+	return nil, errors.New("tournament: no pairing found")
+}
+
+// pairingRules are the rules of a Swiss pairing that may be broken
+type pairingRules struct {
+	allowColors    bool
+	allowRematches bool
+}
+
+// pairPlayers pairs the players in ranking order, trying the opponents of the
+// highest ranked player by preference and backtracking when the others can
+// not be paired
+func (t *Tournament) pairPlayers(players []int, points map[int]float64, rules pairingRules, steps *int) ([][2]int, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+
+	*steps++
+	if *steps > maxPairingSteps {
+		return nil, false
+	}
+
+	player := players[0]
+	for _, opponent := range preferredOpponents(players, points) {
+		if !rules.allowRematches && t.met(player, opponent) {
+			continue
+		}
+		if !rules.allowColors && !t.colorsCompatible(player, opponent) {
+			continue
+		}
+
+		rest := make([]int, 0, len(players)-2)
+		for _, other := range players[1:] {
+			if other != opponent {
+				rest = append(rest, other)
+			}
+		}
+
+		if pairs, ok := t.pairPlayers(rest, points, rules, steps); ok {
+			return append([][2]int{{player, opponent}}, pairs...), true
+		}
+		if *steps > maxPairingSteps {
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+// preferredOpponents returns the opponents of the first player by
+// preference: in its score group the player half the group below it, then
+// the following and the preceding ones, then the players of lower groups
+func preferredOpponents(players []int, points map[int]float64) []int {
+	group := 1
+	for group < len(players) && points[players[group]] == points[players[0]] {
+		group++
+	}
+
+	var opponents []int
+	ideal := group / 2
+	if ideal == 0 {
+		ideal = 1
+	}
+	for i := ideal; i < group; i++ {
+		opponents = append(opponents, players[i])
+	}
+	for i := ideal - 1; i >= 1; i-- {
+		opponents = append(opponents, players[i])
+	}
+	return append(opponents, players[group:]...)
+}
+
+// points returns the points of each player in the rounds played
+func (t *Tournament) points() map[int]float64 {
+	points := map[int]float64{}
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			white, black := pairingPoints(pairing)
+			points[pairing.White] += white
+			if !pairing.IsBye() {
+				points[pairing.Black] += black
+			}
+		}
+	}
+	return points
+}
+
+// pairingPoints returns the points of white and black in a pairing
+func pairingPoints(pairing Pairing) (white, black float64) {
+	switch pairing.Result {
+	case engine.WhiteWon:
+		return 1, 0
+	case engine.BlackWon:
+		return 0, 1
+	case engine.Draw:
+		return 0.5, 0.5
+	}
+	return 0, 0
+}
+
+// ranking returns the players by points, then rating, then registration
+func (t *Tournament) ranking(points map[int]float64) []int {
+	ranking := make([]int, len(t.Players))
+	for i := range ranking {
+		ranking[i] = i
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		a, b := ranking[i], ranking[j]
+		if points[a] != points[b] {
+			return points[a] > points[b]
+		}
+		return t.Players[a].Rating > t.Players[b].Rating
+	})
+	return ranking
+}
+
+// hadBye reports whether a player already sat out a round
+func (t *Tournament) hadBye(player int) bool {
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			if pairing.IsBye() && pairing.White == player {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// met reports whether two players already played each other
+func (t *Tournament) met(a, b int) bool {
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			if pairing.White == a && pairing.Black == b || pairing.White == b && pairing.Black == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// colors returns the colors a player had in the games played so far
+func (t *Tournament) colors(player int) []engine.Color {
+	var colors []engine.Color
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			switch {
+			case pairing.IsBye():
+			case pairing.White == player:
+				colors = append(colors, engine.White)
+			case pairing.Black == player:
+				colors = append(colors, engine.Black)
+			}
+		}
+	}
+	return colors
+}
+
+// Strengths of a color preference
+const (
+	noPreference = iota
+	mildPreference
+	strongPreference
+	absolutePreference
+)
+
+// colorPreference returns the color a player should get next and how much,
+// from the balance of its colors and the last ones it had
+func (t *Tournament) colorPreference(player int) (engine.Color, int) {
+	colors := t.colors(player)
+	if len(colors) == 0 {
+		return engine.NoColor, noPreference
+	}
+
+	balance := 0
+	for _, color := range colors {
+		if color == engine.White {
+			balance++
+		} else {
+			balance--
+		}
+	}
+
+	last := colors[len(colors)-1]
+	twice := len(colors) >= 2 && colors[len(colors)-2] == last
+	switch {
+	case balance >= 2 || balance <= -2 || twice:
+		if balance >= 2 || balance > -2 && last == engine.White {
+			return engine.Black, absolutePreference
+		}
+		return engine.White, absolutePreference
+	case balance == 1:
+		return engine.Black, strongPreference
+	case balance == -1:
+		return engine.White, strongPreference
+	}
+	return last.Other(), mildPreference
+}
+
+// colorsCompatible reports whether two players can meet without one of them
+// getting a color it must not have
+func (t *Tournament) colorsCompatible(a, b int) bool {
+	colorA, strengthA := t.colorPreference(a)
+	colorB, strengthB := t.colorPreference(b)
+	return !(strengthA == absolutePreference && strengthB == absolutePreference && colorA == colorB)
+}
+
+// allocateColors returns the white and black players of a pairing on a
+// board: the stronger color preference is granted, and without preferences
+// the higher ranked player gets white on odd boards
+func (t *Tournament) allocateColors(a, b, board int) (white, black int) {
+	colorA, strengthA := t.colorPreference(a)
+	colorB, strengthB := t.colorPreference(b)
+
+	switch {
+	case colorA != colorB && colorA != engine.NoColor:
+		if colorA == engine.White {
+			return a, b
+		}
+		return b, a
+	case colorA != colorB:
+		if colorB == engine.White {
+			return b, a
+		}
+		return a, b
+	case strengthA > strengthB:
+		if colorA == engine.White {
+			return a, b
+		}
+		return b, a
+	case strengthB > strengthA:
+		if colorB == engine.White {
+			return b, a
+		}
+		return a, b
+	}
+
+	// Same preferences, the one who had the other color more recently gets
+	// its preference
+	colorsA, colorsB := t.colors(a), t.colors(b)
+	for i, j := len(colorsA)-1, len(colorsB)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if colorsA[i] != colorsB[j] {
+			if colorsA[i] == engine.Black {
+				return a, b
+			}
+			return b, a
+		}
+	}
+
+	if board%2 == 0 {
+		return a, b
+	}
+	return b, a
+}
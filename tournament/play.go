@@ -0,0 +1,69 @@
+package tournament
+
+import (
+	"context"
+	"fmt"
+
+	"chess-go/engine"
+	"chess-go/match"
+)
+
+// PlayRound plays the games of a round, numbered from 1, between players
+// that are both engines and records their results. The games are played one
+// after the other with the time control, openings and adjudication of
+// config, the first opening for every game when there are several; games of
+// human players are left to be recorded. report is called after each game.
+func (t *Tournament) PlayRound(ctx context.Context, round int, config match.Config, report func(board int, result match.GameResult)) error {
+	if round < 1 || round > len(t.Played) {
+		return fmt.Errorf("tournament: round %d: %w", round, ErrNoPairing)
+	}
+
+	opening := match.Opening{}
+	if len(config.Openings) > 0 {
+		opening = config.Openings[0]
+	}
+	config.Event = t.Name
+
+	for i, pairing := range t.Played[round-1].Pairings {
+		if pairing.IsBye() || pairing.Result != engine.NoOutcome {
+			continue
+		}
+		white, black := t.Players[pairing.White], t.Players[pairing.Black]
+		if white.Engine == "" || black.Engine == "" {
+			continue
+		}
+
+		result, err := t.playGame(ctx, config, white, black, opening)
+		if err != nil {
+			return err
+		}
+		if err := t.RecordGame(round, i+1, result.Game); err != nil {
+			return err
+		}
+		if report != nil {
+			report(i+1, result)
+		}
+	}
+
+	return nil
+}
+
+// playGame plays a game between two engine players
+func (t *Tournament) playGame(ctx context.Context, config match.Config, white, black Player, opening match.Opening) (match.GameResult, error) {
+	var players [2]match.Player
+	for i, player := range []Player{white, black} {
+		engineConfig, err := match.ParseEngineConfig(player.Engine)
+		if err != nil {
+			return match.GameResult{}, fmt.Errorf("tournament: %s: %w", player.Name, err)
+		}
+		engineConfig.Name = player.Name
+
+		players[i], err = engineConfig.Start(ctx)
+		if err != nil {
+			return match.GameResult{}, fmt.Errorf("tournament: %s: %w", player.Name, err)
+		}
+		defer players[i].Close()
+	}
+
+	return match.PlayGame(ctx, config, players[0], players[1], opening)
+}
@@ -0,0 +1,320 @@
+package tournament
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"chess-go/engine"
+)
+
+// Tiebreak is a way to rank players with the same points
+type Tiebreak string
+
+const (
+	// Buchholz is the sum of the points of the opponents
+	Buchholz Tiebreak = "buchholz"
+	// SonnebornBerger is the sum of the points of the opponents beaten and
+	// half of those of the opponents drawn
+	SonnebornBerger Tiebreak = "sonneborn-berger"
+	// DirectEncounter is the points scored in the games between the players
+	// with the same points
+	DirectEncounter Tiebreak = "direct-encounter"
+)
+
+// ParseTiebreak parses a tiebreak from its name
+func ParseTiebreak(s string) (Tiebreak, error) {
+	switch Tiebreak(s) {
+	case Buchholz, SonnebornBerger, DirectEncounter:
+		return Tiebreak(s), nil
+	}
+	return "", fmt.Errorf("tournament: unknown tiebreak %q", s)
+}
+
+// short returns the column title of the tiebreak in crosstables
+func (t Tiebreak) short() string {
+	switch t {
+	case Buchholz:
+		return "BH"
+	case SonnebornBerger:
+		return "SB"
+	case DirectEncounter:
+		return "DE"
+	}
+	return string(t)
+}
+
+// Standing is the place of a player in the tournament
+type Standing struct {
+	// Rank is shared by players with the same points and tiebreaks
+	Rank   int
+	Player int
+	Points float64
+	Games  int
+	Wins   int
+	// Tiebreaks are the values of the tiebreaks of the tournament, in order
+	Tiebreaks []float64
+}
+
+// game is a finished game from the point of view of a player
+type game struct {
+	opponent int
+	color    engine.Color
+	points   float64
+}
+
+// games returns the finished games of each player, byes included with Bye as
+// the opponent
+func (t *Tournament) games() map[int][]game {
+	games := map[int][]game{}
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			if pairing.Result == engine.NoOutcome {
+				continue
+			}
+
+			white, black := pairingPoints(pairing)
+			games[pairing.White] = append(games[pairing.White], game{opponent: pairing.Black, color: engine.White, points: white})
+			if !pairing.IsBye() {
+				games[pairing.Black] = append(games[pairing.Black], game{opponent: pairing.White, color: engine.Black, points: black})
+			}
+		}
+	}
+	return games
+}
+
+// Standings returns the players ranked by points then by the tiebreaks
+func (t *Tournament) Standings() []Standing {
+	games := t.games()
+	points := t.points()
+
+	standings := make([]Standing, len(t.Players))
+	for player := range t.Players {
+		standing := Standing{Player: player, Points: points[player]}
+		for _, g := range games[player] {
+			if g.opponent == Bye {
+				continue
+			}
+			standing.Games++
+			if g.points == 1 {
+				standing.Wins++
+			}
+		}
+
+		for _, tiebreak := range t.Tiebreaks {
+			standing.Tiebreaks = append(standing.Tiebreaks, t.tiebreak(tiebreak, player, games, points))
+		}
+		standings[player] = standing
+	}
+
+	// better reports whether a is ranked above b, or 0 when they are tied
+	better := func(a, b Standing) int {
+		if a.Points != b.Points {
+			return compare(a.Points, b.Points)
+		}
+		for i := range a.Tiebreaks {
+			if a.Tiebreaks[i] != b.Tiebreaks[i] {
+				return compare(a.Tiebreaks[i], b.Tiebreaks[i])
+			}
+		}
+		return 0
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return better(standings[i], standings[j]) > 0
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && better(standings[i-1], standings[i]) == 0 {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+// compare returns 1 when a is greater than b, and -1 otherwise
+func compare(a, b float64) int {
+	if a > b {
+		return 1
+	}
+	return -1
+}
+
+// tiebreak computes a tiebreak of a player
+func (t *Tournament) tiebreak(tiebreak Tiebreak, player int, games map[int][]game, points map[int]float64) float64 {
+	var value float64
+
+	for _, g := range games[player] {
+		if g.opponent == Bye {
+			continue
+		}
+
+		switch tiebreak {
+		case Buchholz:
+			value += points[g.opponent]
+		case SonnebornBerger:
+			value += g.points * points[g.opponent]
+		case DirectEncounter:
+			if points[g.opponent] == points[player] {
+				value += g.points
+			}
+		}
+	}
+
+	return value
+}
+
+// WriteStandings writes the standings as a table
+func (t *Tournament) WriteStandings(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := []string{"#", "Name", "Rating", "Points", "Games"}
+	for _, tiebreak := range t.Tiebreaks {
+		header = append(header, tiebreak.short())
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, standing := range t.Standings() {
+		player := t.Players[standing.Player]
+		row := []string{
+			strconv.Itoa(standing.Rank),
+			player.Name,
+			strconv.Itoa(player.Rating),
+			formatPoints(standing.Points),
+			strconv.Itoa(standing.Games),
+		}
+		for _, value := range standing.Tiebreaks {
+			row = append(row, formatPoints(value))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// WriteCrosstable writes the crosstable of the tournament. A round robin has
+// a column per player with the results against them, a Swiss tournament a
+// column per round with the rank of the opponent, the color and the result
+// ("3w1", "5b=", "bye").
+func (t *Tournament) WriteCrosstable(w io.Writer) error {
+	standings := t.Standings()
+	place := map[int]int{}
+	for i, standing := range standings {
+		place[standing.Player] = i + 1
+	}
+
+	header := []string{"#", "Name", "Rating"}
+	if t.Format == RoundRobin {
+		for i := range standings {
+			header = append(header, strconv.Itoa(i+1))
+		}
+	} else {
+		for round := range t.Played {
+			header = append(header, "R"+strconv.Itoa(round+1))
+		}
+	}
+	header = append(header, "Points")
+	for _, tiebreak := range t.Tiebreaks {
+		header = append(header, tiebreak.short())
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for i, standing := range standings {
+		player := standing.Player
+		row := []string{strconv.Itoa(i + 1), t.Players[player].Name, strconv.Itoa(t.Players[player].Rating)}
+
+		if t.Format == RoundRobin {
+			for _, opponent := range standings {
+				row = append(row, t.results(player, opponent.Player))
+			}
+		} else {
+			for _, round := range t.Played {
+				row = append(row, roundCell(round, player, place))
+			}
+		}
+
+		row = append(row, formatPoints(standing.Points))
+		for _, value := range standing.Tiebreaks {
+			row = append(row, formatPoints(value))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// results returns the results of a player against an opponent, "*" against
+// itself and "." before they meet
+func (t *Tournament) results(player, opponent int) string {
+	if player == opponent {
+		return "*"
+	}
+
+	var cell string
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			if pairing.Result == engine.NoOutcome {
+				continue
+			}
+			white, black := pairingPoints(pairing)
+			switch {
+			case pairing.White == player && pairing.Black == opponent:
+				cell += resultSymbol(white)
+			case pairing.Black == player && pairing.White == opponent:
+				cell += resultSymbol(black)
+			}
+		}
+	}
+
+	if cell == "" {
+		return "."
+	}
+	return cell
+}
+
+// roundCell returns the cell of a player in a round of a Swiss crosstable
+func roundCell(round Round, player int, place map[int]int) string {
+	for _, pairing := range round.Pairings {
+		white, black := pairingPoints(pairing)
+		switch {
+		case pairing.IsBye() && pairing.White == player:
+			return "bye"
+		case pairing.White == player:
+			return strconv.Itoa(place[pairing.Black]) + "w" + cellResult(pairing, white)
+		case pairing.Black == player:
+			return strconv.Itoa(place[pairing.White]) + "b" + cellResult(pairing, black)
+		}
+	}
+	return "-"
+}
+
+// cellResult returns the result of a pairing in a crosstable cell, "*" while
+// it is played
+func cellResult(pairing Pairing, points float64) string {
+	if pairing.Result == engine.NoOutcome {
+		return "*"
+	}
+	return resultSymbol(points)
+}
+
+// resultSymbol returns "1", "=" or "0" for the points of a game
+func resultSymbol(points float64) string {
+	switch points {
+	case 1:
+		return "1"
+	case 0.5:
+		return "="
+	}
+	return "0"
+}
+
+// formatPoints writes points with a half when they have one
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
@@ -0,0 +1,285 @@
+// Package tournament runs round-robin and Swiss tournaments: it pairs the
+// rounds, records the results, ranks the players with tiebreaks and exports
+// crosstables
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+)
+
+// Bye is the opponent of a player who sits out a round
+const Bye = -1
+
+var (
+	// ErrRoundInProgress is returned when pairing a round before the results
+	// of the previous one are all recorded
+	ErrRoundInProgress = errors.New("the current round is not finished")
+	// ErrTournamentOver is returned when pairing a round after the last one
+	ErrTournamentOver = errors.New("the tournament is over")
+	// ErrNoPairing is returned when a pairing can not be recorded
+	ErrNoPairing = errors.New("no such pairing")
+)
+
+// Format is the pairing system of a tournament
+type Format string
+
+const (
+	RoundRobin Format = "round-robin"
+	Swiss      Format = "swiss"
+)
+
+// ParseFormat parses a format from its name
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case RoundRobin, Swiss:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("tournament: unknown format %q", s)
+}
+
+// Player is a participant of a tournament
+type Player struct {
+	Name   string
+	Rating int
+	// Engine is the setting of the engine playing for the player, in the
+	// format of match.ParseEngineConfig, empty for humans
+	Engine string `json:",omitempty"`
+}
+
+// Pairing is a game of a round, Black is Bye when White sits out the round
+type Pairing struct {
+	White  int
+	Black  int
+	Result engine.Outcome
+	// PGN is the game when it was recorded with its moves
+	PGN string `json:",omitempty"`
+}
+
+// IsBye reports whether the pairing is a bye
+func (p Pairing) IsBye() bool {
+	return p.Black == Bye
+}
+
+// Round is the pairings of a round of a tournament, by board
+type Round struct {
+	Pairings []Pairing
+}
+
+// IsFinished reports whether all the results of the round are recorded
+func (r Round) IsFinished() bool {
+	for _, pairing := range r.Pairings {
+		if pairing.Result == engine.NoOutcome {
+			return false
+		}
+	}
+	return true
+}
+
+// Tournament is a tournament between players, it is saved and loaded as JSON
+type Tournament struct {
+	Name    string
+	Format  Format
+	Players []Player
+	// Rounds is the number of rounds, fixed by the number of players and
+	// cycles in a round robin
+	Rounds int
+	// Cycles is the number of times the players meet each other in a round
+	// robin, 2 for a double round robin
+	Cycles int `json:",omitempty"`
+	// Tiebreaks break the ties in points, the first one that differs ranks
+	// the players
+	Tiebreaks []Tiebreak
+	// Played are the rounds paired so far
+	Played []Round
+}
+
+// New returns a tournament with the default tiebreaks of its format. rounds
+// is the number of rounds of a Swiss tournament, and the number of cycles of
+// a round robin.
+func New(name string, format Format, players []Player, rounds int) (*Tournament, error) {
+	if len(players) < 2 {
+		return nil, errors.New("tournament: at least two players are needed")
+	}
+	if rounds < 1 {
+		return nil, errors.New("tournament: at least one round is needed")
+	}
+
+	t := &Tournament{Name: name, Format: format, Players: players}
+	switch format {
+	case RoundRobin:
+		t.Cycles = rounds
+		t.Rounds = rounds * (len(players) - 1 + len(players)%2)
+		t.Tiebreaks = []Tiebreak{DirectEncounter, SonnebornBerger}
+	case Swiss:
+		if rounds >= len(players)+len(players)%2 {
+			return nil, fmt.Errorf("tournament: %d players can not play %d Swiss rounds", len(players), rounds)
+		}
+		t.Rounds = rounds
+		t.Tiebreaks = []Tiebreak{Buchholz, SonnebornBerger, DirectEncounter}
+	default:
+		return nil, fmt.Errorf("tournament: unknown format %q", format)
+	}
+
+	return t, nil
+}
+
+// Load reads a tournament saved as JSON
+func Load(r io.Reader) (*Tournament, error) {
+	var t Tournament
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, fmt.Errorf("tournament: %w", err)
+	}
+	return &t, nil
+}
+
+// Save writes the tournament as JSON
+func (t *Tournament) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// IsOver reports whether all the rounds are played
+func (t *Tournament) IsOver() bool {
+	return len(t.Played) == t.Rounds && (t.Rounds == 0 || t.Played[t.Rounds-1].IsFinished())
+}
+
+// PairNextRound pairs the next round once the results of the current one are
+// all recorded, byes are scored at once
+func (t *Tournament) PairNextRound() (*Round, error) {
+	if len(t.Played) > 0 && !t.Played[len(t.Played)-1].IsFinished() {
+		return nil, ErrRoundInProgress
+	}
+	if len(t.Played) >= t.Rounds {
+		return nil, ErrTournamentOver
+	}
+
+	var pairings []Pairing
+	var err error
+	if t.Format == RoundRobin {
+		pairings = t.pairRoundRobin(len(t.Played))
+	} else {
+		pairings, err = t.pairSwiss()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range pairings {
+		if pairings[i].IsBye() {
+			pairings[i].Result = engine.WhiteWon
+		}
+	}
+
+	t.Played = append(t.Played, Round{Pairings: pairings})
+	return &t.Played[len(t.Played)-1], nil
+}
+
+// RecordResult records the result of the game on a board of a round, both
+// numbered from 1
+func (t *Tournament) RecordResult(round, board int, result engine.Outcome) error {
+	pairing, err := t.pairing(round, board)
+	if err != nil {
+		return err
+	}
+	if result == engine.NoOutcome {
+		return fmt.Errorf("tournament: round %d board %d: the game has no result", round, board)
+	}
+
+	pairing.Result = result
+	return nil
+}
+
+// RecordGame records a game played on a board of a round, its result is the
+// outcome of the game or the result of its PGN when it was decided otherwise
+// (by resignation, on time, ...)
+func (t *Tournament) RecordGame(round, board int, game *pgn.Game) error {
+	pairing, err := t.pairing(round, board)
+	if err != nil {
+		return err
+	}
+
+	result := game.Result
+	if result == engine.NoOutcome {
+		chess, err := game.Replay()
+		if err != nil {
+			return err
+		}
+		result = chess.Outcome()
+	}
+	if err := t.RecordResult(round, board, result); err != nil {
+		return err
+	}
+
+	game.SetResult(result)
+	game.SetTag("Event", t.Name)
+	game.SetTag("Round", fmt.Sprintf("%d.%d", round, board))
+	game.SetTag("White", t.Players[pairing.White].Name)
+	game.SetTag("Black", t.Players[pairing.Black].Name)
+	pairing.PGN = game.String()
+	return nil
+}
+
+// RecordChess records a game played with engine.Chess on a board of a round,
+// the game must be over
+func (t *Tournament) RecordChess(round, board int, chess *engine.Chess) error {
+	if !chess.IsGameOver() {
+		return fmt.Errorf("tournament: round %d board %d: the game is not over", round, board)
+	}
+	return t.RecordGame(round, board, pgn.NewGame(chess))
+}
+
+// WritePGN writes the recorded games with their moves in PGN
+func (t *Tournament) WritePGN(w io.Writer) error {
+	for _, round := range t.Played {
+		for _, pairing := range round.Pairings {
+			if pairing.PGN == "" {
+				continue
+			}
+			if _, err := io.WriteString(w, pairing.PGN); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pairing returns the pairing on a board of a round, both numbered from 1
+func (t *Tournament) pairing(round, board int) (*Pairing, error) {
+	if round < 1 || round > len(t.Played) || board < 1 || board > len(t.Played[round-1].Pairings) {
+		return nil, fmt.Errorf("tournament: round %d board %d: %w", round, board, ErrNoPairing)
+	}
+
+	pairing := &t.Played[round-1].Pairings[board-1]
+	if pairing.IsBye() {
+		return nil, fmt.Errorf("tournament: round %d board %d is a bye: %w", round, board, ErrNoPairing)
+	}
+	return pairing, nil
+}
+
+// WriteRound writes the pairings of a round numbered from 1, a board per
+// line with its result
+func (t *Tournament) WriteRound(w io.Writer, round int) error {
+	if round < 1 || round > len(t.Played) {
+		return fmt.Errorf("tournament: round %d: %w", round, ErrNoPairing)
+	}
+
+	for i, pairing := range t.Played[round-1].Pairings {
+		var err error
+		if pairing.IsBye() {
+			_, err = fmt.Fprintf(w, "%d. %s bye\n", i+1, t.Players[pairing.White].Name)
+		} else {
+			_, err = fmt.Fprintf(w, "%d. %s - %s %s\n", i+1, t.Players[pairing.White].Name, t.Players[pairing.Black].Name, pairing.Result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
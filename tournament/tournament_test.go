@@ -0,0 +1,249 @@
+package tournament
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+	"chess-go/match"
+)
+
+// players returns n players rated from 2000 down by 100
+func players(n int) []Player {
+	var players []Player
+	for i := 0; i < n; i++ {
+		players = append(players, Player{Name: fmt.Sprintf("P%d", i+1), Rating: 2000 - 100*i})
+	}
+	return players
+}
+
+// playHigherRated pairs and plays every round, the higher rated player wins
+func playHigherRated(t *testing.T, tournament *Tournament) {
+	for !tournament.IsOver() {
+		round, err := tournament.PairNextRound()
+		if err != nil {
+			t.Fatalf("FAILED: round %d\n\t%s", len(tournament.Played), err.Error())
+		}
+
+		for board, pairing := range round.Pairings {
+			if pairing.IsBye() {
+				continue
+			}
+			result := engine.WhiteWon
+			if pairing.Black < pairing.White {
+				result = engine.BlackWon
+			}
+			if err := tournament.RecordResult(len(tournament.Played), board+1, result); err != nil {
+				t.Fatalf("FAILED\n\t%s", err.Error())
+			}
+		}
+	}
+}
+
+func TestTournament_RoundRobin(t *testing.T) {
+	inputs := [][2]int{{4, 1}, {5, 1}, {4, 2}, {7, 1}}
+	expectedRounds := []int{3, 5, 6, 7}
+
+	for i, input := range inputs {
+		tournament, err := New("Club", RoundRobin, players(input[0]), input[1])
+		if err != nil {
+			t.Fatalf("FAILED: %v\n\t%s", input, err.Error())
+		}
+		playHigherRated(t, tournament)
+
+		if len(tournament.Played) != expectedRounds[i] {
+			t.Errorf("FAILED: %v\n\tgot:     %+v\n\texpected:%+v", input, len(tournament.Played), expectedRounds[i])
+		}
+
+		// Every player meets every other one once per cycle with balanced
+		// colors, and has at most one bye per cycle
+		for a := 0; a < input[0]; a++ {
+			whites, blacks, byes := 0, 0, 0
+			for _, round := range tournament.Played {
+				for _, pairing := range round.Pairings {
+					switch {
+					case pairing.IsBye() && pairing.White == a:
+						byes++
+					case pairing.White == a:
+						whites++
+					case pairing.Black == a:
+						blacks++
+					}
+				}
+			}
+			if whites+blacks != (input[0]-1)*input[1] || whites-blacks > 1 || blacks-whites > 1 || byes != input[0]%2*input[1] {
+				t.Errorf("FAILED: %v player %d\n\tgot:     %d whites %d blacks %d byes\n\texpected:balanced colors", input, a, whites, blacks, byes)
+			}
+		}
+
+		// Byes score a point
+		for rank, standing := range tournament.Standings() {
+			points := float64((input[0] - 1 - rank + input[0]%2) * input[1])
+			if standing.Player != rank || standing.Points != points {
+				t.Errorf("FAILED: %v\n\tgot:     %+v\n\texpected:%+v points", input, standing, points)
+			}
+		}
+	}
+}
+func TestTournament_Swiss(t *testing.T) {
+	tournament, err := New("Open", Swiss, players(8), 3)
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	round, _ := tournament.PairNextRound()
+	expected := []Pairing{{White: 0, Black: 4}, {White: 5, Black: 1}, {White: 2, Black: 6}, {White: 7, Black: 3}}
+	if !reflect.DeepEqual(round.Pairings, expected) {
+		t.Errorf("FAILED: round 1\n\tgot:     %+v\n\texpected:%+v", round.Pairings, expected)
+	}
+
+	if _, err := tournament.PairNextRound(); !errors.Is(err, ErrRoundInProgress) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrRoundInProgress)
+	}
+	tournament.Played = nil
+	playHigherRated(t, tournament)
+
+	// Round 2 pairs the winners together and the losers together, round 3
+	// the leaders P1 and P2
+	expectedRounds := [][]Pairing{
+		{{White: 0, Black: 2, Result: engine.WhiteWon}, {White: 3, Black: 1, Result: engine.BlackWon}, {White: 4, Black: 6, Result: engine.WhiteWon}, {White: 7, Black: 5, Result: engine.BlackWon}},
+		{{White: 1, Black: 0, Result: engine.BlackWon}, {White: 2, Black: 4, Result: engine.WhiteWon}, {White: 5, Black: 3, Result: engine.BlackWon}, {White: 6, Black: 7, Result: engine.WhiteWon}},
+	}
+	for i, expected := range expectedRounds {
+		if output := tournament.Played[i+1].Pairings; !reflect.DeepEqual(output, expected) {
+			t.Errorf("FAILED: round %d\n\tgot:     %+v\n\texpected:%+v", i+2, output, expected)
+		}
+	}
+
+	for a := range tournament.Players {
+		for b := range tournament.Players {
+			count := 0
+			for _, round := range tournament.Played {
+				for _, pairing := range round.Pairings {
+					if pairing.White == a && pairing.Black == b {
+						count++
+					}
+				}
+			}
+			if count > 1 {
+				t.Errorf("FAILED: %d and %d\n\tgot:     %d games\n\texpected:at most 1", a, b, count)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	tournament.WriteCrosstable(&out)
+	expectedCrosstable := `# Name Rating R1  R2  R3  Points BH SB DE
+1 P1   2000   5w1 3w1 2b1 3      5  5  0
+2 P2   1900   6b1 4b1 1w0 2      6  3  1
+3 P3   1800   7w1 1b0 5w1 2      5  2  0
+`
+	if !strings.HasPrefix(out.String(), expectedCrosstable) {
+		t.Errorf("FAILED\n\tgot:     \n%s\n\texpected:\n%s", out.String(), expectedCrosstable)
+	}
+}
+func TestTournament_Bye(t *testing.T) {
+	tournament, _ := New("Open", Swiss, players(5), 3)
+	playHigherRated(t, tournament)
+
+	byes := map[int]bool{}
+	for _, round := range tournament.Played {
+		bye := round.Pairings[len(round.Pairings)-1]
+		if !bye.IsBye() || byes[bye.White] || bye.Result != engine.WhiteWon {
+			t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", bye, "a new player with a bye")
+		}
+		byes[bye.White] = true
+	}
+
+	if err := tournament.RecordResult(1, 3, engine.Draw); !errors.Is(err, ErrNoPairing) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrNoPairing)
+	}
+	if _, err := tournament.PairNextRound(); !errors.Is(err, ErrTournamentOver) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrTournamentOver)
+	}
+}
+func TestTournament_Crosstable(t *testing.T) {
+	tournament, _ := New("Club", RoundRobin, players(3), 1)
+	for _, result := range []engine.Outcome{engine.Draw, engine.WhiteWon, engine.BlackWon} {
+		round, _ := tournament.PairNextRound()
+		for board, pairing := range round.Pairings {
+			if !pairing.IsBye() {
+				tournament.RecordResult(len(tournament.Played), board+1, result)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	tournament.WriteCrosstable(&out)
+	expected := `# Name Rating 1 2 3 Points DE  SB
+1 P1   2000   * 1 1 3      0   3
+2 P2   1900   0 * = 1.5    0.5 0.75
+3 P3   1800   0 = * 1.5    0.5 0.75
+`
+	if out.String() != expected {
+		t.Errorf("FAILED\n\tgot:     \n%s\n\texpected:\n%s", out.String(), expected)
+	}
+}
+func TestTournament_SaveLoad(t *testing.T) {
+	tournament, _ := New("Club", Swiss, append(players(3), Player{Name: "Bot", Engine: "depth=1"}), 2)
+	tournament.PairNextRound()
+
+	chess := engine.NewGameChess()
+	for _, move := range []string{"f3", "e5", "g4", "Qh4#"} {
+		chess.MovePGN(move)
+	}
+	if err := tournament.RecordChess(1, 1, chess); err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+
+	var saved bytes.Buffer
+	if err := tournament.Save(&saved); err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	loaded, err := Load(&saved)
+	if err != nil || !reflect.DeepEqual(loaded, tournament) {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", loaded, err, tournament)
+	}
+
+	var out bytes.Buffer
+	tournament.WritePGN(&out)
+	if !strings.Contains(out.String(), `[Round "1.1"]`) || !strings.Contains(out.String(), "2. g4 Qh4# 0-1") {
+		t.Errorf("FAILED\n\tgot:     %s\n\texpected:%s", out.String(), "the recorded game")
+	}
+}
+func TestTournament_PlayRound(t *testing.T) {
+	tournament, _ := New("Bots", RoundRobin, []Player{
+		{Name: "Deep", Engine: "depth=3"},
+		{Name: "Shallow", Engine: "depth=3"},
+		{Name: "Human"},
+	}, 1)
+
+	for round := 1; round <= tournament.Rounds; round++ {
+		if _, err := tournament.PairNextRound(); err != nil {
+			t.Fatalf("FAILED\n\t%s", err.Error())
+		}
+
+		config := match.Config{Openings: []match.Opening{{FEN: "7k/8/5K2/8/8/8/8/6Q1 w - - 0 1"}}}
+		if err := tournament.PlayRound(context.Background(), round, config, nil); err != nil {
+			t.Fatalf("FAILED\n\t%s", err.Error())
+		}
+
+		for board, pairing := range tournament.Played[round-1].Pairings {
+			human := pairing.IsBye() || pairing.White == 2 || pairing.Black == 2
+			if human != (pairing.Result == engine.NoOutcome) && !pairing.IsBye() {
+				t.Errorf("FAILED: round %d board %d\n\tgot:     %+v\n\texpected:%v", round, board+1, pairing, "engine games played")
+			}
+			if !human && (pairing.Result != engine.WhiteWon || !strings.Contains(pairing.PGN, `[Event "Bots"]`)) {
+				t.Errorf("FAILED: round %d board %d\n\tgot:     %+v\n\texpected:%v", round, board+1, pairing, "white mates")
+			}
+			if human && !pairing.IsBye() {
+				tournament.RecordResult(round, board+1, engine.Draw)
+			}
+		}
+	}
+}
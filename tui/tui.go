@@ -0,0 +1,404 @@
+// Package tui plays games of engine.Chess in a terminal: the board is drawn
+// with the moves played beside it, and a line of input is either a move or
+// a command
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"chess-go/engine"
+	"chess-go/pgn"
+	"chess-go/render"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\x1b[H\x1b[2J"
+
+// movesShown is how many move numbers the move list shows, as many as the
+// board has lines
+const movesShown = 9
+
+const help = `Enter a move in SAN (Nf3) or UCI (g1f3), or a command:
+  new          start a new game
+  fen FEN      start a game from a position
+  undo         take back the last move
+  flip         turn the board around
+  hint         suggest a move
+  save FILE    save the game in PGN
+  load FILE    load the first game of a PGN file
+  draw         claim a draw by repetition or the fifty move rule
+  resign       resign the game
+  help         show this help
+  quit         leave`
+
+// UI is a game played in a terminal
+type UI struct {
+	// Color paints the board with ANSI colors
+	Color bool
+	// Clear clears the screen before drawing the board
+	Clear bool
+	// HintLimits bounds the search of the hint command
+	HintLimits engine.SearchLimits
+	// Computer is the color played by the computer, NoColor when two people
+	// play on one keyboard
+	Computer engine.Color
+	// Strength is how well the computer plays
+	Strength engine.Strength
+	// Thinking shows the score and the best line of the computer as it
+	// searches
+	Thinking bool
+
+	rnd     *rand.Rand
+	out     io.Writer
+	chess   *engine.Chess
+	flipped bool
+	// message is shown under the board until the next command
+	message string
+}
+
+// New returns a UI with a new game that draws to out
+func New(out io.Writer) *UI {
+	return &UI{
+		HintLimits: engine.SearchLimits{Depth: 4, MoveTime: 2 * time.Second},
+		Computer:   engine.NoColor,
+		Strength:   engine.Levels[4],
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		out:        out,
+		chess:      engine.NewGameChess(),
+	}
+}
+
+// Run draws the game and reads moves and commands from in until quit or the
+// end of in, the computer plays its moves in between
+func (u *UI) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if err := u.Draw(); err != nil {
+			return err
+		}
+		if u.computerToMove() {
+			if err := u.ComputerMove(); err != nil {
+				return err
+			}
+			continue
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(u.out)
+			return scanner.Err()
+		}
+		if quit := u.Handle(scanner.Text()); quit {
+			return nil
+		}
+	}
+}
+
+// Handle plays a move or executes a command and reports whether it was quit
+func (u *UI) Handle(line string) bool {
+	fields := strings.Fields(line)
+	u.message = ""
+	if len(fields) == 0 {
+		return false
+	}
+
+	var err error
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "quit", "exit":
+		return true
+
+	case "help", "?":
+		u.message = help
+
+	case "new":
+		u.chess = engine.NewGameChess()
+
+	case "fen":
+		err = u.SetFEN(strings.Join(args, " "))
+
+	case "undo":
+		_, err = u.chess.Undo()
+		// The move of the computer is taken back with the one it answered
+		if err == nil && u.computerToMove() && len(u.chess.History()) > 0 {
+			_, err = u.chess.Undo()
+		}
+
+	case "flip":
+		u.flipped = !u.flipped
+
+	case "hint":
+		err = u.hint()
+
+	case "save":
+		err = u.save(args)
+
+	case "load":
+		err = u.load(args)
+
+	case "draw":
+		err = u.chess.ClaimDraw()
+
+	case "resign":
+		err = u.chess.Resign(u.chess.Turn())
+
+	default:
+		err = u.play(line)
+	}
+
+	if err != nil {
+		u.message = err.Error()
+	}
+	return false
+}
+
+// SetFEN starts a new game from a position
+func (u *UI) SetFEN(fen string) error {
+	chess, err := engine.NewChessGameWithFen(fen, engine.Strict)
+	if err != nil {
+		return err
+	}
+	u.chess = chess
+	return nil
+}
+
+// play plays a move in SAN or UCI
+func (u *UI) play(move string) error {
+	move = strings.TrimSpace(move)
+	if _, err := engine.ParseMove(move); err == nil {
+		_, err = u.chess.MoveUCI(move)
+		return err
+	}
+	_, err := u.chess.MovePGN(move)
+	return err
+}
+
+// computerToMove reports whether the computer plays the next move
+func (u *UI) computerToMove() bool {
+	return u.Computer == u.chess.Turn() && !u.chess.IsGameOver()
+}
+
+// ComputerMove lets the computer play a move, writing its thinking when it
+// is shown
+func (u *UI) ComputerMove() error {
+	var thinking string
+	report := func(info engine.SearchInfo) {
+		thinking = formatInfo(u.chess, info)
+		if u.Thinking {
+			fmt.Fprintln(u.out, thinking)
+		}
+	}
+
+	result := u.chess.SearchStrength(context.Background(), u.Strength, u.rnd, report)
+	played, err := u.chess.Move(result.BestMove)
+	if err != nil {
+		return err
+	}
+
+	u.message = "Computer plays " + played.SAN
+	if u.Thinking && thinking != "" {
+		u.message += "\n" + thinking
+	}
+	return nil
+}
+
+// hint searches the best move of the side to move
+func (u *UI) hint() error {
+	if u.chess.IsGameOver() {
+		return engine.ErrGameOver
+	}
+
+	result := u.chess.Search(context.Background(), u.HintLimits, nil)
+	san, err := u.chess.SAN(result.BestMove)
+	if err != nil {
+		return err
+	}
+	u.message = fmt.Sprintf("Hint: %s (%s)", san, formatScore(result.Info))
+	return nil
+}
+
+// save writes the game to a PGN file
+func (u *UI) save(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: save FILE")
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	_, err = pgn.NewGame(u.chess).WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		u.message = "Saved to " + args[0]
+	}
+	return err
+}
+
+// load replays the first game of a PGN file
+func (u *UI) load(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: load FILE")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	game, err := pgn.NewReader(file).Read()
+	if err != nil {
+		return err
+	}
+	chess, err := game.Replay()
+	if err != nil {
+		return err
+	}
+	if game.Result != engine.NoOutcome && !chess.IsGameOver() {
+		endWith(chess, game.Result)
+	}
+	u.chess = chess
+	u.message = "Loaded " + args[0]
+	return nil
+}
+
+// endWith ends a game that the moves did not end with the result of its PGN:
+// a draw is claimed when the position allows it and agreed otherwise, the
+// loser of a decisive game resigned
+func endWith(chess *engine.Chess, result engine.Outcome) {
+	switch {
+	case result == engine.Draw && chess.CanClaimDraw():
+		chess.ClaimDraw()
+	case result == engine.Draw:
+		chess.AgreeDraw()
+	default:
+		chess.Resign(result.Winner().Other())
+	}
+}
+
+// Draw draws the board with the move list beside it, the state of the game,
+// the message of the last command and the prompt
+func (u *UI) Draw() error {
+	// The board faces the player of black against the computer
+	flipped := u.flipped != (u.Computer == engine.White)
+
+	var board bytes.Buffer
+	if err := (render.Unicode{Flipped: flipped, Coordinates: true, Color: u.Color}).Render(&board, u.chess); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(board.String(), "\n"), "\n")
+	moves := u.moveList()
+
+	var screen strings.Builder
+	if u.Clear {
+		screen.WriteString(clearScreen)
+	}
+	for i, line := range lines {
+		screen.WriteString(line)
+		if i < len(moves) {
+			screen.WriteString("    " + moves[i])
+		}
+		screen.WriteString("\n")
+	}
+
+	screen.WriteString("\n" + u.status() + "\n")
+	if u.message != "" {
+		screen.WriteString(u.message + "\n")
+	}
+	if !u.computerToMove() {
+		screen.WriteString("> ")
+	}
+
+	_, err := io.WriteString(u.out, screen.String())
+	return err
+}
+
+// moveList returns the last moves of the game, a line per move number
+func (u *UI) moveList() []string {
+	sans := u.chess.HistorySAN()
+	if len(sans) == 0 {
+		return nil
+	}
+
+	// The number of the first move and whether black played it
+	number, blackFirst := 1, false
+	if start, err := engine.NewChessGameWithFen(u.chess.StartingFEN()); err == nil {
+		number = start.FullMoves()
+		blackFirst = start.Turn() == engine.Black
+	}
+
+	var lines []string
+	if blackFirst {
+		lines = append(lines, fmt.Sprintf("%3d. %-7s %s", number, "...", sans[0]))
+		sans = sans[1:]
+		number++
+	}
+	for i := 0; i < len(sans); i += 2 {
+		line := fmt.Sprintf("%3d. %-7s", number, sans[i])
+		if i+1 < len(sans) {
+			line += " " + sans[i+1]
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+		number++
+	}
+
+	if len(lines) > movesShown {
+		lines = lines[len(lines)-movesShown:]
+	}
+	return lines
+}
+
+// status describes the state of the game
+func (u *UI) status() string {
+	if u.chess.IsGameOver() {
+		return fmt.Sprintf("%s by %s", u.chess.Outcome(), u.chess.Method())
+	}
+
+	status := u.chess.Turn().Title() + " to move"
+	if u.chess.InCheck() {
+		status += ", check"
+	}
+	if u.chess.CanClaimDraw() {
+		status += ", draw can be claimed"
+	}
+	return status
+}
+
+// formatScore writes the score of a search in pawns or as a mate
+func formatScore(info engine.SearchInfo) string {
+	if info.Mate > 0 {
+		return fmt.Sprintf("mate in %d", info.Mate)
+	}
+	if info.Mate < 0 {
+		return fmt.Sprintf("mated in %d", -info.Mate)
+	}
+	return fmt.Sprintf("%+.2f", float64(info.Score)/100)
+}
+
+// formatInfo writes a completed depth of a search with its line in SAN
+func formatInfo(chess *engine.Chess, info engine.SearchInfo) string {
+	line := fmt.Sprintf("depth %d score %s nodes %d time %.2fs pv", info.Depth, formatScore(info), info.Nodes, info.Time.Seconds())
+
+	position, err := engine.NewChessGameWithFen(chess.GetFEN())
+	if err != nil {
+		return line
+	}
+	for _, move := range info.PV {
+		played, err := position.Move(move)
+		if err != nil {
+			break
+		}
+		line += " " + played.SAN
+	}
+	return line
+}
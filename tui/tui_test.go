@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+)
+
+func TestTUI_Handle(t *testing.T) {
+	var out strings.Builder
+	ui := New(&out)
+	save := filepath.Join(t.TempDir(), "game.pgn")
+
+	inputs := []string{
+		"e4",
+		"e7e5",
+		"Ke3",
+		"Qh5",
+		"undo",
+		"Nf3",
+		"save " + save,
+		"new",
+		"load " + save,
+		"fen 4k3/8/8/8/8/8/8/4K2R w K - 0 1",
+		"Rh8",
+		"hint",
+		"draw",
+		"resign",
+		"Kd7",
+		"jump",
+	}
+
+	expectedOutputs := []string{
+		"  1. e4",
+		"  1. e4      e5",
+		"Invalid Move Ke3",
+		"  2. Qh5",
+		"  1. e4      e5\n",
+		"  2. Nf3",
+		"Saved to " + save,
+		"White to move\n>",
+		"  2. Nf3",
+		"White to move",
+		"Black to move, check",
+		"Hint: Kf7 (-5.00)",
+		"no draw to claim",
+		"1-0 by resignation",
+		"the game is over",
+		"Invalid Move jump",
+	}
+
+	for i, input := range inputs {
+		if quit := ui.Handle(input); quit {
+			t.Fatalf("FAILED: %s\n\tgot:     %v\n\texpected:%v", input, quit, false)
+		}
+		out.Reset()
+		ui.Draw()
+		if output := out.String(); !strings.Contains(output, expectedOutputs[i]) {
+			t.Errorf("FAILED: %s\n\tgot:\n%s\n\texpected:%s", input, output, expectedOutputs[i])
+		}
+	}
+
+	if quit := ui.Handle("quit"); !quit {
+		t.Errorf("FAILED: %s\n\tgot:     %v\n\texpected:%v", "quit", quit, true)
+	}
+}
+func TestTUI_Load(t *testing.T) {
+	inputs := []string{
+		"1. e4 e5 1-0",
+		"1. e4 e5 1/2-1/2",
+		"1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 1/2-1/2",
+		"1. e4 e5 *",
+	}
+
+	// The result of a game its moves did not end is kept
+	expectedOutputs := []string{
+		"1-0 by resignation",
+		"1/2-1/2 by agreement",
+		"1/2-1/2 by threefold repetition",
+		"White to move",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		path := filepath.Join(t.TempDir(), "game.pgn")
+		if err := os.WriteFile(path, []byte(input+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out strings.Builder
+		ui := New(&out)
+		ui.Handle("load " + path)
+		ui.Draw()
+		if output := out.String(); !strings.Contains(output, expected) {
+			t.Errorf("FAILED: %s\n\tgot:\n%s\n\texpected:%s", input, output, expected)
+		}
+	}
+}
+func TestTUI_Run(t *testing.T) {
+	var out strings.Builder
+	ui := New(&out)
+
+	// The end of the input leaves like quit
+	if err := ui.Run(strings.NewReader("f3\ne5\ng4\nQh4\n")); err != nil {
+		t.Fatalf("FAILED\n\tgot:     %s\n\texpected:%v", err, nil)
+	}
+	if expected := "0-1 by checkmate\n> \n"; !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("FAILED\n\tgot:\n%s\n\texpected:%s", out.String(), expected)
+	}
+}
+func TestTUI_Computer(t *testing.T) {
+	var out strings.Builder
+	ui := New(&out)
+	ui.Computer = engine.White
+	ui.Strength = engine.Strength{Limits: engine.SearchLimits{Depth: 2}}
+	ui.Thinking = true
+
+	// The computer mates in one with white, on a board seen from black
+	ui.SetFEN("6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1")
+	if err := ui.Run(strings.NewReader("")); err != nil {
+		t.Fatalf("FAILED\n\tgot:     %s\n\texpected:%v", err, nil)
+	}
+
+	inputs := []string{
+		"depth 1 score mate in 1",
+		"1 · ♔ · · · · · ·      1. Rd8#",
+		"Computer plays Rd8#\ndepth 1 score mate in 1 nodes",
+		"1-0 by checkmate\n",
+	}
+	for _, input := range inputs {
+		if !strings.Contains(out.String(), input) {
+			t.Errorf("FAILED: %s\n\tgot:\n%s", input, out.String())
+		}
+	}
+
+	// Taking back a move of the computer takes back the answer
+	ui.Computer = engine.Black
+	ui.SetFEN(engine.DefaultFen)
+	ui.Handle("e4")
+	ui.ComputerMove()
+	ui.Handle("undo")
+	out.Reset()
+	ui.Draw()
+	if expected := "White to move\n> "; !strings.HasSuffix(out.String(), expected) || strings.Contains(out.String(), "1.") {
+		t.Errorf("FAILED: undo\n\tgot:\n%s\n\texpected:%s", out.String(), expected)
+	}
+}
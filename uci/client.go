@@ -0,0 +1,440 @@
+// Package uci speaks the Universal Chess Interface (UCI): it drives external
+// engines as subprocesses and serves engine.Chess to interfaces
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+// stopGrace is how long an engine has to answer stop before it is given up on
+const stopGrace = 2 * time.Second
+
+var (
+	// ErrEngineExited is returned when the engine process ends while it is
+	// expected to answer
+	ErrEngineExited = errors.New("engine exited")
+	// ErrIllegalBestMove is returned when the engine plays an illegal move
+	ErrIllegalBestMove = errors.New("engine played an illegal move")
+)
+
+// ProtocolError is an unexpected line sent by the engine
+type ProtocolError struct {
+	Line string
+
+	err string
+}
+
+func (p *ProtocolError) Error() string {
+	return "UCI protocol error: " + p.err + ": " + p.Line
+}
+
+// Option is an option declared by the engine during the handshake
+type Option struct {
+	Name    string
+	Type    string
+	Default string
+	Min     string
+	Max     string
+	Vars    []string
+}
+
+// GoParams are the limits of a search, zero values are left out
+type GoParams struct {
+	Depth     int
+	Nodes     int64
+	Mate      int
+	MoveTime  time.Duration
+	WTime     time.Duration
+	BTime     time.Duration
+	WInc      time.Duration
+	BInc      time.Duration
+	MovesToGo int
+	Infinite  bool
+}
+
+// command returns the go command for the params
+func (p GoParams) command() string {
+	cmd := []string{"go"}
+
+	addInt := func(name string, value int64) {
+		if value > 0 {
+			cmd = append(cmd, name, strconv.FormatInt(value, 10))
+		}
+	}
+	addDuration := func(name string, value time.Duration) {
+		if value > 0 {
+			cmd = append(cmd, name, strconv.FormatInt(value.Milliseconds(), 10))
+		}
+	}
+
+	addInt("depth", int64(p.Depth))
+	addInt("nodes", p.Nodes)
+	addInt("mate", int64(p.Mate))
+	addDuration("movetime", p.MoveTime)
+	addDuration("wtime", p.WTime)
+	addDuration("btime", p.BTime)
+	addDuration("winc", p.WInc)
+	addDuration("binc", p.BInc)
+	addInt("movestogo", int64(p.MovesToGo))
+	if p.Infinite {
+		cmd = append(cmd, "infinite")
+	}
+
+	return strings.Join(cmd, " ")
+}
+
+// BestMove is the answer of the engine to go
+type BestMove struct {
+	Move   string
+	Ponder string
+	// Info is the last info line with a principal variation
+	Info Info
+}
+
+// Engine is a running UCI engine process
+type Engine struct {
+	Name    string
+	Author  string
+	Options map[string]Option
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Start launches the engine at path and performs the UCI handshake
+func Start(ctx context.Context, path string, args ...string) (*Engine, error) {
+	return StartCmd(ctx, exec.Command(path, args...))
+}
+
+// StartCmd launches the engine from a prepared command and performs the UCI
+// handshake, the command's stdin and stdout must not be set
+func StartCmd(ctx context.Context, cmd *exec.Cmd) (*Engine, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		Options: map[string]Option{},
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 64),
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+		close(e.lines)
+	}()
+
+	if err := e.handshake(ctx); err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// handshake sends uci and reads the engine's id and options until uciok
+func (e *Engine) handshake(ctx context.Context) error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "uciok":
+			return nil
+		case strings.HasPrefix(line, "id name "):
+			e.Name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "id author "):
+			e.Author = strings.TrimPrefix(line, "id author ")
+		case strings.HasPrefix(line, "option "):
+			option := parseOption(line)
+			e.Options[option.Name] = option
+		}
+	}
+}
+
+// SetOption sets an option of the engine
+func (e *Engine) SetOption(name, value string) error {
+	if _, ok := e.Options[name]; !ok {
+		return fmt.Errorf("uci: engine %q has no option %q", e.Name, name)
+	}
+
+	if value == "" {
+		return e.send("setoption name " + name)
+	}
+	return e.send("setoption name " + name + " value " + value)
+}
+
+// IsReady waits until the engine is ready for new commands
+func (e *Engine) IsReady(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// NewGame tells the engine the next position is from a new game
+func (e *Engine) NewGame(ctx context.Context) error {
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	return e.IsReady(ctx)
+}
+
+// SetPosition sends the starting position and moves of a game
+func (e *Engine) SetPosition(chess *engine.Chess) error {
+	var moves []string
+	for _, move := range chess.History() {
+		moves = append(moves, move.String())
+	}
+
+	return e.Position(chess.StartingFEN(), moves)
+}
+
+// Position sends a position as a FEN and the UCI moves played from it, an
+// empty FEN is the starting position
+func (e *Engine) Position(fen string, moves []string) error {
+	cmd := "position startpos"
+	if fen != "" && fen != engine.DefaultFen {
+		cmd = "position fen " + fen
+	}
+	if len(moves) > 0 {
+		cmd += " moves " + strings.Join(moves, " ")
+	}
+
+	return e.send(cmd)
+}
+
+// Go starts a search of the current position and waits for the best move,
+// calling info for each info line. When ctx is done the engine is told to
+// stop and its best move so far is returned.
+func (e *Engine) Go(ctx context.Context, params GoParams, info func(Info)) (BestMove, error) {
+	if err := e.send(params.command()); err != nil {
+		return BestMove{}, err
+	}
+
+	var best BestMove
+	stopped := false
+	readCtx := ctx
+
+	for {
+		line, err := e.readLine(readCtx)
+		if err != nil {
+			// Ask for the move once the time is up, and give up if it does not come
+			if !stopped && ctx.Err() != nil && !errors.Is(err, ErrEngineExited) {
+				stopped = true
+				if err := e.send("stop"); err != nil {
+					return best, err
+				}
+
+				var cancel context.CancelFunc
+				readCtx, cancel = context.WithTimeout(context.Background(), stopGrace)
+				defer cancel()
+				continue
+			}
+			return best, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "info":
+			parsed, err := ParseInfo(line)
+			if err != nil {
+				continue
+			}
+			if len(parsed.PV) > 0 {
+				best.Info = parsed
+			}
+			if info != nil {
+				info(parsed)
+			}
+
+		case "bestmove":
+			if len(fields) < 2 {
+				return best, &ProtocolError{Line: line, err: "missing best move"}
+			}
+			best.Move = fields[1]
+			if len(fields) >= 4 && fields[2] == "ponder" {
+				best.Ponder = fields[3]
+			}
+			return best, nil
+		}
+	}
+}
+
+// Stop tells the engine to stop searching, Go returns its best move
+func (e *Engine) Stop() error {
+	return e.send("stop")
+}
+
+// BestMove sets the position of a game, searches it and checks that the
+// engine's best move is legal
+func (e *Engine) BestMove(ctx context.Context, chess *engine.Chess, params GoParams, info func(Info)) (engine.Move, BestMove, error) {
+	if err := e.SetPosition(chess); err != nil {
+		return engine.Move{}, BestMove{}, err
+	}
+
+	best, err := e.Go(ctx, params, info)
+	if err != nil {
+		return engine.Move{}, best, err
+	}
+
+	move, err := engine.ParseMove(best.Move)
+	if err != nil {
+		return engine.Move{}, best, fmt.Errorf("%w: %s", ErrIllegalBestMove, best.Move)
+	}
+
+	for _, legal := range chess.LegalMoves() {
+		if legal.From == move.From && legal.To == move.To &&
+			(!legal.IsPromotion() || legal.Promotion == move.Promotion) {
+			return legal, best, nil
+		}
+	}
+
+	return engine.Move{}, best, fmt.Errorf("%w: %s", ErrIllegalBestMove, best.Move)
+}
+
+// Close quits the engine and waits for the process to end, killing it if it
+// does not quit in time
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	fmt.Fprintln(e.stdin, "quit")
+	e.stdin.Close()
+
+	// Nobody reads the engine anymore, keep its output from blocking it
+	go func() {
+		for range e.lines {
+		}
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- e.cmd.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(stopGrace):
+		e.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+// send writes a command to the engine
+func (e *Engine) send(cmd string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrEngineExited
+	}
+	_, err := fmt.Fprintln(e.stdin, cmd)
+	return err
+}
+
+// readLine waits for the next line sent by the engine
+func (e *Engine) readLine(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", ErrEngineExited
+		}
+		return strings.TrimSpace(line), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// parseOption parses an option line of the handshake
+func parseOption(line string) Option {
+	var option Option
+
+	keywords := map[string]bool{"name": true, "type": true, "default": true, "min": true, "max": true, "var": true}
+	fields := strings.Fields(line)
+
+	for i := 1; i < len(fields); i++ {
+		keyword := fields[i]
+		if !keywords[keyword] {
+			continue
+		}
+
+		// The value runs until the next keyword, names may have spaces
+		var value []string
+		for i+1 < len(fields) && !keywords[fields[i+1]] {
+			value = append(value, fields[i+1])
+			i++
+		}
+		joined := strings.Join(value, " ")
+
+		switch keyword {
+		case "name":
+			option.Name = joined
+		case "type":
+			option.Type = joined
+		case "default":
+			option.Default = joined
+		case "min":
+			option.Min = joined
+		case "max":
+			option.Max = joined
+		case "var":
+			option.Vars = append(option.Vars, joined)
+		}
+	}
+
+	return option
+}
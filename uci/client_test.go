@@ -0,0 +1,228 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+)
+
+// TestMain runs the test binary as a scripted fake engine, or as the UCI
+// server, when asked to
+func TestMain(m *testing.M) {
+	switch script := os.Getenv("UCI_FAKE_ENGINE"); script {
+	case "":
+	case "server":
+		Serve(os.Stdin, os.Stdout)
+		os.Exit(0)
+	default:
+		fakeEngine(script)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeEngine answers UCI commands from a script: "good" plays e2e4 or e7e5,
+// "illegal" plays e2e5 and "slow" only moves when told to stop
+func fakeEngine(script string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	position := ""
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "uci":
+			fmt.Println("id name Fake Engine 1.0")
+			fmt.Println("id author Test")
+			fmt.Println("option name Hash type spin default 16 min 1 max 1024")
+			fmt.Println("option name Skill Level type combo default Hard var Easy var Hard")
+			fmt.Println("uciok")
+		case line == "isready":
+			fmt.Println("readyok")
+		case strings.HasPrefix(line, "position"):
+			position = line
+		case strings.HasPrefix(line, "go"):
+			move := "e2e4"
+			if strings.HasSuffix(position, "e2e4") {
+				move = "e7e5"
+			}
+			if script == "illegal" {
+				move = "e2e5"
+			}
+			if script == "slow" {
+				// Wait for stop before answering
+				for scanner.Scan() && scanner.Text() != "stop" {
+				}
+			}
+			fmt.Println("info depth 1 score cp 20 nodes 20 nps 2000 time 10 pv " + move)
+			fmt.Println("info depth 2 seldepth 3 score mate 3 lowerbound nodes 400 nps 4000 time 100 pv " + move + " a7a6")
+			fmt.Println("bestmove " + move + " ponder a7a6")
+		case line == "quit":
+			return
+		}
+	}
+}
+
+// startFake starts the fake engine with a script
+func startFake(t *testing.T, script string) *Engine {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "UCI_FAKE_ENGINE="+script)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e, err := StartCmd(ctx, cmd)
+	if err != nil {
+		t.Fatalf("FAILED: start %s\n\t%s", script, err.Error())
+	}
+	return e
+}
+
+func TestUCI_Handshake(t *testing.T) {
+	e := startFake(t, "good")
+	defer e.Close()
+
+	if e.Name != "Fake Engine 1.0" || e.Author != "Test" {
+		t.Errorf("FAILED\n\tgot:     %q %q\n\texpected:%q %q", e.Name, e.Author, "Fake Engine 1.0", "Test")
+	}
+
+	expected := map[string]Option{
+		"Hash":        {Name: "Hash", Type: "spin", Default: "16", Min: "1", Max: "1024"},
+		"Skill Level": {Name: "Skill Level", Type: "combo", Default: "Hard", Vars: []string{"Easy", "Hard"}},
+	}
+	if !reflect.DeepEqual(e.Options, expected) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", e.Options, expected)
+	}
+
+	if err := e.SetOption("Hash", "64"); err != nil {
+		t.Errorf("FAILED\n\t%s", err.Error())
+	}
+	if err := e.SetOption("Threads", "2"); err == nil {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, "unknown option")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.NewGame(ctx); err != nil {
+		t.Errorf("FAILED\n\t%s", err.Error())
+	}
+}
+func TestUCI_BestMove(t *testing.T) {
+	e := startFake(t, "good")
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	chess := engine.NewGameChess()
+	var infos []Info
+	for _, expected := range []string{"e2e4", "e7e5"} {
+		move, best, err := e.BestMove(ctx, chess, GoParams{Depth: 2}, func(info Info) {
+			infos = append(infos, info)
+		})
+		if err != nil {
+			t.Fatalf("FAILED\n\t%s", err.Error())
+		}
+
+		if move.String() != expected || best.Ponder != "a7a6" || best.Info.Depth != 2 {
+			t.Errorf("FAILED\n\tgot:     %v %+v\n\texpected:%v", move, best, expected)
+		}
+		if _, err := chess.Move(move); err != nil {
+			t.Fatalf("FAILED\n\t%s", err.Error())
+		}
+	}
+
+	if len(infos) != 4 || !infos[1].Score.IsMate || infos[1].Score.Mate != 3 {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", infos, "4 infos with a mate score")
+	}
+}
+func TestUCI_IllegalBestMove(t *testing.T) {
+	e := startFake(t, "illegal")
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _, err := e.BestMove(ctx, engine.NewGameChess(), GoParams{Depth: 1}, nil)
+	if !errors.Is(err, ErrIllegalBestMove) {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", err, ErrIllegalBestMove)
+	}
+}
+func TestUCI_Timeout(t *testing.T) {
+	e := startFake(t, "slow")
+	defer e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := e.Position("", nil); err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	best, err := e.Go(ctx, GoParams{Infinite: true}, nil)
+	if err != nil || best.Move != "e2e4" {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%+v", best, err, "e2e4 after stop")
+	}
+}
+func TestUCI_ParseInfo(t *testing.T) {
+	inputs := []string{
+		"info depth 12 seldepth 18 multipv 1 score cp -35 upperbound nodes 123456 nps 1000000 hashfull 12 tbhits 0 time 123 pv e2e4 e7e5 g1f3",
+		"info depth 5 score mate -2 pv e1e2",
+		"info string hello engine",
+		"info depth x",
+	}
+
+	expectedOutputs := []Info{
+		{
+			Depth: 12, SelDepth: 18, MultiPV: 1,
+			Score: Score{CP: -35, Upperbound: true}, HasScore: true,
+			Nodes: 123456, NPS: 1000000, HashFull: 12, Time: 123 * time.Millisecond,
+			PV: []string{"e2e4", "e7e5", "g1f3"},
+		},
+		{Depth: 5, Score: Score{Mate: -2, IsMate: true}, HasScore: true, PV: []string{"e1e2"}},
+		{String: "hello engine"},
+		{},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output, _ := ParseInfo(input)
+
+		if !reflect.DeepEqual(output, expected) {
+			t.Errorf("FAILED: %s\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}
+func TestUCI_GoParams(t *testing.T) {
+	inputs := []GoParams{
+		{},
+		{Depth: 10},
+		{WTime: time.Minute, BTime: 30 * time.Second, WInc: time.Second, BInc: time.Second, MovesToGo: 20},
+		{MoveTime: 1500 * time.Millisecond},
+		{Infinite: true},
+	}
+
+	expectedOutputs := []string{
+		"go",
+		"go depth 10",
+		"go wtime 60000 btime 30000 winc 1000 binc 1000 movestogo 20",
+		"go movetime 1500",
+		"go infinite",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+		output := input.command()
+
+		if output != expected {
+			t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package uci
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Score is the evaluation reported by an engine, from the point of view of
+// the side to move
+type Score struct {
+	// CP is the score in centipawns when it is not a mate
+	CP int
+	// Mate is the number of moves to mate, negative when being mated
+	Mate int
+	// IsMate tells whether Mate is set instead of CP
+	IsMate bool
+
+	Lowerbound bool
+	Upperbound bool
+}
+
+// String returns the score as written by UCI ("cp 35" or "mate -3")
+func (s Score) String() string {
+	if s.IsMate {
+		return "mate " + strconv.Itoa(s.Mate)
+	}
+	return "cp " + strconv.Itoa(s.CP)
+}
+
+// Info is a parsed info line sent by an engine while searching
+type Info struct {
+	Depth    int
+	SelDepth int
+	MultiPV  int
+	Score    Score
+	HasScore bool
+
+	Nodes    int64
+	NPS      int64
+	TBHits   int64
+	HashFull int
+	Time     time.Duration
+
+	PV             []string
+	CurrMove       string
+	CurrMoveNumber int
+
+	// String is the free text sent with "info string"
+	String string
+}
+
+// ParseInfo parses an info line, unknown tokens are skipped
+func ParseInfo(line string) (Info, error) {
+	var info Info
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return info, &ProtocolError{Line: line, err: "not an info line"}
+	}
+
+	// next returns the field after i as an integer
+	next := func(i int) (int64, error) {
+		if i+1 >= len(fields) {
+			return 0, &ProtocolError{Line: line, err: "missing value of " + fields[i]}
+		}
+		n, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			return 0, &ProtocolError{Line: line, err: "invalid value of " + fields[i]}
+		}
+		return n, nil
+	}
+
+	for i := 1; i < len(fields); i++ {
+		var n int64
+		var err error
+
+		switch fields[i] {
+		case "depth", "seldepth", "multipv", "nodes", "nps", "tbhits", "hashfull", "time", "currmovenumber":
+			n, err = next(i)
+			if err != nil {
+				return info, err
+			}
+
+			switch fields[i] {
+			case "depth":
+				info.Depth = int(n)
+			case "seldepth":
+				info.SelDepth = int(n)
+			case "multipv":
+				info.MultiPV = int(n)
+			case "nodes":
+				info.Nodes = n
+			case "nps":
+				info.NPS = n
+			case "tbhits":
+				info.TBHits = n
+			case "hashfull":
+				info.HashFull = int(n)
+			case "time":
+				info.Time = time.Duration(n) * time.Millisecond
+			case "currmovenumber":
+				info.CurrMoveNumber = int(n)
+			}
+			i++
+
+		case "score":
+			info.HasScore = true
+		scoreFields:
+			for i+1 < len(fields) {
+				switch fields[i+1] {
+				case "cp", "mate":
+					n, err = next(i + 1)
+					if err != nil {
+						return info, err
+					}
+					if fields[i+1] == "mate" {
+						info.Score.IsMate = true
+						info.Score.Mate = int(n)
+					} else {
+						info.Score.CP = int(n)
+					}
+					i += 2
+				case "lowerbound":
+					info.Score.Lowerbound = true
+					i++
+				case "upperbound":
+					info.Score.Upperbound = true
+					i++
+				default:
+					break scoreFields
+				}
+			}
+
+		case "currmove":
+			if i+1 < len(fields) {
+				info.CurrMove = fields[i+1]
+				i++
+			}
+
+		case "pv":
+			// The principal variation runs to the end of the line
+			info.PV = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+
+		case "string":
+			info.String = strings.Join(fields[i+1:], " ")
+			i = len(fields)
+		}
+	}
+
+	return info, nil
+}
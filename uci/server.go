@@ -0,0 +1,292 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+// Server plays engine.Chess for an interface that speaks UCI
+type Server struct {
+	out   io.Writer
+	outMu sync.Mutex
+
+	chess *engine.Chess
+
+	// Search in progress
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewServer returns a server that writes its replies to out
+func NewServer(out io.Writer) *Server {
+	return &Server{out: out, chess: engine.NewGameChess()}
+}
+
+// Serve reads UCI commands from in and writes replies to out until quit or
+// the end of in
+func Serve(in io.Reader, out io.Writer) error {
+	return NewServer(out).Serve(in)
+}
+
+// Serve reads UCI commands from in until quit or the end of in
+func (s *Server) Serve(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if quit := s.Handle(scanner.Text()); quit {
+			return nil
+		}
+	}
+
+	// Let a search in progress finish its move before leaving
+	if s.done != nil {
+		<-s.done
+	}
+	return scanner.Err()
+}
+
+// Handle executes a single command and reports whether it was quit
+func (s *Server) Handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "uci":
+		s.writeln("id name chess-go")
+		s.writeln("id author chess-go authors")
+		s.writeln("uciok")
+
+	case "debug", "setoption", "register", "ponderhit":
+
+	case "isready":
+		s.writeln("readyok")
+
+	case "ucinewgame":
+		s.stop()
+		s.chess = engine.NewGameChess()
+
+	case "position":
+		s.stop()
+		chess, err := parsePosition(args)
+		if err != nil {
+			s.writeln("info string " + err.Error())
+			return false
+		}
+		s.chess = chess
+
+	case "go":
+		s.stop()
+		params, err := parseGo(args)
+		if err != nil {
+			s.writeln("info string " + err.Error())
+			return false
+		}
+		s.search(params)
+
+	case "stop":
+		s.stop()
+
+	case "quit":
+		s.stop()
+		return true
+
+	default:
+		s.writeln("info string unknown command " + command)
+	}
+
+	return false
+}
+
+// search starts searching the position in the background, the best move is
+// written when the search ends, or when it is stopped for infinite searches
+func (s *Server) search(params GoParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+
+	chess := s.chess
+	limits := searchLimits(chess, params)
+
+	go func() {
+		defer close(done)
+
+		result := chess.Search(ctx, limits, s.writeInfo)
+		if params.Infinite {
+			<-ctx.Done()
+		}
+
+		if len(chess.LegalMoves()) == 0 {
+			s.writeln("bestmove 0000")
+			return
+		}
+		s.writeln("bestmove " + result.BestMove.String())
+	}()
+}
+
+// stop ends the search in progress, if any, once it has written its move
+func (s *Server) stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	<-s.done
+	s.cancel, s.done = nil, nil
+}
+
+// writeInfo writes an info line for a completed iteration of a search
+func (s *Server) writeInfo(info engine.SearchInfo) {
+	score := "cp " + strconv.Itoa(info.Score)
+	if info.Mate != 0 {
+		score = "mate " + strconv.Itoa(info.Mate)
+	}
+
+	var pv []string
+	for _, move := range info.PV {
+		pv = append(pv, move.String())
+	}
+
+	s.writeln(fmt.Sprintf("info depth %d score %s nodes %d nps %d time %d pv %s",
+		info.Depth, score, info.Nodes, info.NPS(), info.Time.Milliseconds(), strings.Join(pv, " ")))
+}
+
+// writeln writes a line to the interface
+func (s *Server) writeln(line string) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fmt.Fprintln(s.out, line)
+}
+
+// searchLimits converts the parameters of go into limits of a search of the
+// position
+func searchLimits(chess *engine.Chess, params GoParams) engine.SearchLimits {
+	limits := engine.SearchLimits{
+		Depth:    params.Depth,
+		Nodes:    int(params.Nodes),
+		MoveTime: params.MoveTime,
+	}
+	if params.Mate > 0 && (limits.Depth == 0 || limits.Depth > params.Mate*2) {
+		limits.Depth = params.Mate * 2
+	}
+
+	remaining, increment := params.WTime, params.WInc
+	if chess.Turn() == engine.Black {
+		remaining, increment = params.BTime, params.BInc
+	}
+	if limits.MoveTime == 0 && remaining > 0 && !params.Infinite {
+		limits.MoveTime = engine.AllocateTime(remaining, increment, params.MovesToGo)
+	}
+
+	return limits
+}
+
+// parsePosition parses the arguments of position: startpos or fen followed
+// by the six fields of a FEN, then the moves played from it
+func parsePosition(args []string) (*engine.Chess, error) {
+	var chess *engine.Chess
+	var err error
+
+	switch {
+	case len(args) > 0 && args[0] == "startpos":
+		chess = engine.NewGameChess()
+		args = args[1:]
+	case len(args) >= 7 && args[0] == "fen":
+		chess, err = engine.NewChessGameWithFen(strings.Join(args[1:7], " "))
+		if err != nil {
+			return nil, err
+		}
+		args = args[7:]
+	default:
+		return nil, fmt.Errorf("invalid position %s", strings.Join(args, " "))
+	}
+
+	if len(args) > 0 {
+		if args[0] != "moves" {
+			return nil, fmt.Errorf("invalid position %s", strings.Join(args, " "))
+		}
+		args = args[1:]
+	}
+	for _, move := range args {
+		if _, err := chess.MoveUCI(move); err != nil {
+			return nil, err
+		}
+	}
+
+	return chess, nil
+}
+
+// parseGo parses the arguments of go, the parameters GoParams does not have
+// are skipped
+func parseGo(args []string) (GoParams, error) {
+	var params GoParams
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "infinite":
+			params.Infinite = true
+			continue
+		case "ponder":
+			continue
+		case "searchmoves":
+			// The moves to search run to the next parameter and are not supported
+			for i+1 < len(args) && !isGoParam(args[i+1]) {
+				i++
+			}
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return params, fmt.Errorf("missing value of %s", args[i])
+		}
+		n, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid value of %s", args[i])
+		}
+		i++
+
+		ms := time.Duration(n) * time.Millisecond
+		switch args[i-1] {
+		case "depth":
+			params.Depth = int(n)
+		case "nodes":
+			params.Nodes = n
+		case "mate":
+			params.Mate = int(n)
+		case "movetime":
+			params.MoveTime = ms
+		case "wtime":
+			params.WTime = ms
+		case "btime":
+			params.BTime = ms
+		case "winc":
+			params.WInc = ms
+		case "binc":
+			params.BInc = ms
+		case "movestogo":
+			params.MovesToGo = int(n)
+		}
+	}
+
+	return params, nil
+}
+
+// isGoParam reports whether a token is the name of a parameter of go
+func isGoParam(token string) bool {
+	switch token {
+	case "depth", "nodes", "mate", "movetime", "wtime", "btime", "winc", "binc",
+		"movestogo", "infinite", "ponder", "searchmoves":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package uci
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chess-go/engine"
+)
+
+func TestUCI_Serve(t *testing.T) {
+	inputs := []string{
+		"uci\n",
+		"isready\n",
+		"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1\ngo depth 3\n",
+		"position startpos moves f2f3 e7e5 g2g4\ngo movetime 100\n",
+		"position startpos moves e2e5\n",
+		"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1 moves d1d8\ngo depth 2\n",
+		"foo\n",
+	}
+
+	expectedOutputs := []string{
+		"id name chess-go\nid author chess-go authors\nuciok\n",
+		"readyok\n",
+		"score mate 1 nodes",
+		"bestmove d8h4\n",
+		"info string Invalid Move e2e5",
+		"bestmove 0000\n",
+		"info string unknown command foo\n",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		var out bytes.Buffer
+		if err := Serve(strings.NewReader(input), &out); err != nil {
+			t.Fatalf("FAILED: %q\n\t%s", input, err.Error())
+		}
+
+		output := out.String()
+		if !strings.Contains(output, expected) {
+			t.Errorf("FAILED: %q\n\tgot:     %q\n\texpected:%q", input, output, expected)
+		}
+	}
+}
+
+// syncBuffer is a buffer that can be read while a search writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestUCI_ServeStop(t *testing.T) {
+	var out syncBuffer
+	server := NewServer(&out)
+
+	server.Handle("position startpos")
+	server.Handle("go infinite")
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(out.String(), "bestmove") {
+		t.Errorf("FAILED\n\tgot:     %q\n\texpected:%q", out.String(), "no best move before stop")
+	}
+
+	server.Handle("stop")
+	if !strings.Contains(out.String(), "bestmove ") {
+		t.Errorf("FAILED\n\tgot:     %q\n\texpected:%q", out.String(), "a best move after stop")
+	}
+}
+func TestUCI_ServeLimits(t *testing.T) {
+	chess := engine.NewGameChess()
+	chess.MoveUCI("e2e4")
+
+	inputs := []string{
+		"depth 5 nodes 1000",
+		"wtime 1000 btime 60000 winc 0 binc 2000 movestogo 20",
+		"mate 3",
+		"infinite",
+	}
+
+	expectedOutputs := []engine.SearchLimits{
+		{Depth: 5, Nodes: 1000},
+		{MoveTime: 4500 * time.Millisecond},
+		{Depth: 6},
+		{},
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		params, err := parseGo(strings.Fields(input))
+		output := searchLimits(chess, params)
+		if err != nil || output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %+v %v\n\texpected:%+v", input, output, err, expected)
+		}
+	}
+}
+func TestUCI_ClientServer(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "UCI_FAKE_ENGINE=server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e, err := StartCmd(ctx, cmd)
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	defer e.Close()
+
+	chess, _ := engine.NewChessGameWithFen("6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1")
+	move, best, err := e.BestMove(ctx, chess, GoParams{Depth: 2}, nil)
+	if err != nil || move.String() != "d1d8" || best.Info.Score.Mate != 1 {
+		t.Errorf("FAILED\n\tgot:     %v %+v %v\n\texpected:%v", move, best, err, "d1d8")
+	}
+}
@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer returns a server that sends back each message it reads
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(message) == "close" {
+				return
+			}
+			conn.WriteMessage(message)
+		}
+	}))
+}
+
+func TestWebSocket_AcceptKey(t *testing.T) {
+	// The example of RFC 6455
+	expected := "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="
+	if output := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); output != expected {
+		t.Errorf("FAILED\n\tgot:     %s\n\texpected:%s", output, expected)
+	}
+}
+func TestWebSocket_Echo(t *testing.T) {
+	server := echoServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"))
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	defer conn.Close()
+
+	inputs := []string{"hello", "", strings.Repeat("a", 200), strings.Repeat("b", 70000)}
+	for _, input := range inputs {
+		if err := conn.WriteMessage([]byte(input)); err != nil {
+			t.Fatalf("FAILED: %d bytes\n\t%s", len(input), err.Error())
+		}
+		if err := conn.Ping(); err != nil {
+			t.Fatalf("FAILED\n\t%s", err.Error())
+		}
+
+		output, err := conn.ReadMessage()
+		if err != nil || string(output) != input {
+			t.Errorf("FAILED: %d bytes\n\tgot:     %d bytes %v\n\texpected:%d bytes", len(input), len(output), err, len(input))
+		}
+	}
+
+	var value struct{ Move string }
+	conn.WriteJSON(map[string]string{"move": "e4"})
+	if err := conn.ReadJSON(&value); err != nil || value.Move != "e4" {
+		t.Errorf("FAILED\n\tgot:     %+v %v\n\texpected:%s", value, err, "e4")
+	}
+
+	conn.WriteMessage([]byte("close"))
+	if _, err := conn.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%v", err, ErrClosed)
+	}
+}
+func TestWebSocket_BadHandshake(t *testing.T) {
+	server := echoServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("FAILED\n\t%s", err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("FAILED\n\tgot:     %d\n\texpected:%d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if _, err := Dial(context.Background(), server.URL); err == nil {
+		t.Errorf("FAILED\n\tgot:     %v\n\texpected:%s", err, "unsupported scheme")
+	}
+}
@@ -0,0 +1,466 @@
+// Package xboard speaks the Chess Engine Communication Protocol (CECP)
+// version 2 of XBoard and WinBoard on top of engine.Chess
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-go/engine"
+)
+
+// defaultDepth bounds the search when the GUI sets no time control
+const defaultDepth = 4
+
+// drawScore is the score in centipawns under which the engine takes its game
+// for a draw and accepts draw offers
+const drawScore = 25
+
+// searchDone is a finished search, id tells apart searches that were abandoned
+type searchDone struct {
+	id     int
+	result engine.SearchResult
+}
+
+// Adapter plays a game of engine.Chess through CECP commands
+type Adapter struct {
+	out   io.Writer
+	outMu sync.Mutex
+
+	chess       *engine.Chess
+	force       bool
+	engineColor engine.Color
+	post        bool
+
+	// Time controls
+	depth           int
+	moveTime        time.Duration
+	movesPerSession int
+	baseTime        time.Duration
+	increment       time.Duration
+	engineTime      time.Duration
+	opponentTime    time.Duration
+
+	// score is the score of the last move of the engine, scored is false
+	// before its first move
+	score  engine.SearchInfo
+	scored bool
+
+	// Search in progress
+	searchID int
+	cancel   context.CancelFunc
+	done     chan searchDone
+}
+
+// NewAdapter returns an adapter that writes its replies to out
+func NewAdapter(out io.Writer) *Adapter {
+	a := &Adapter{
+		out:  out,
+		done: make(chan searchDone, 1),
+	}
+	a.reset()
+	return a
+}
+
+// Run reads CECP commands from in and writes replies to out until quit or
+// the end of in
+func Run(in io.Reader, out io.Writer) error {
+	return NewAdapter(out).Run(in)
+}
+
+// Run reads CECP commands from in until quit or the end of in
+func (a *Adapter) Run(in io.Reader) error {
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		scanErr <- scanner.Err()
+		close(lines)
+	}()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				// Let a search in progress finish its move before leaving
+				if a.cancel != nil {
+					a.playSearchResult(<-a.done)
+				}
+				return <-scanErr
+			}
+
+			if quit := a.Handle(line); quit {
+				a.stopThinking()
+				return nil
+			}
+
+		case done := <-a.done:
+			a.playSearchResult(done)
+		}
+	}
+}
+
+// Handle executes a single command and reports whether it was quit
+func (a *Adapter) Handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer",
+		"name", "rating", "ics", "otim", "bk", "hint", ".":
+		if command == "otim" && len(args) > 0 {
+			a.opponentTime = centiseconds(args[0])
+		}
+
+	case "draw":
+		a.answerDraw()
+
+	case "protover":
+		a.writeln(`feature myname="chess-go" setboard=1 usermove=1 ping=1 playother=1 colors=0 sigint=0 sigterm=0 analyze=0 variants="normal" done=1`)
+
+	case "quit":
+		return true
+
+	case "new":
+		a.stopThinking()
+		a.reset()
+
+	case "force":
+		a.stopThinking()
+		a.force = true
+
+	case "go":
+		a.stopThinking()
+		a.force = false
+		a.engineColor = a.chess.Turn()
+		a.think()
+
+	case "playother":
+		a.stopThinking()
+		a.force = false
+		a.engineColor = a.chess.Turn().Other()
+
+	case "white", "black":
+		a.stopThinking()
+		a.force = false
+		if command == "white" {
+			a.engineColor = engine.Black
+		} else {
+			a.engineColor = engine.White
+		}
+
+	case "usermove":
+		if len(args) != 1 {
+			a.writeln("Error (missing move): usermove")
+			return false
+		}
+		a.userMove(args[0])
+
+	case "?":
+		if a.cancel != nil {
+			a.cancel()
+		}
+
+	case "undo", "remove":
+		a.stopThinking()
+		plies := 1
+		if command == "remove" {
+			plies = 2
+		}
+		for i := 0; i < plies; i++ {
+			if _, err := a.chess.Undo(); err != nil {
+				a.writeln("Error (no move to undo): " + command)
+				break
+			}
+		}
+
+	case "setboard":
+		a.stopThinking()
+		chess, err := engine.NewChessGameWithFen(strings.Join(args, " "), engine.Strict)
+		if err != nil {
+			a.writeln("tellusererror Illegal position")
+			return false
+		}
+		a.chess = chess
+		a.scored = false
+
+	case "level":
+		if len(args) != 3 {
+			a.writeln("Error (invalid level): " + line)
+			return false
+		}
+		a.movesPerSession, _ = strconv.Atoi(args[0])
+		a.baseTime = parseBaseTime(args[1])
+		a.increment = seconds(args[2])
+		a.moveTime = 0
+
+	case "st":
+		if len(args) != 1 {
+			a.writeln("Error (invalid st): " + line)
+			return false
+		}
+		a.moveTime = seconds(args[0])
+
+	case "sd":
+		if len(args) != 1 {
+			a.writeln("Error (invalid sd): " + line)
+			return false
+		}
+		a.depth, _ = strconv.Atoi(args[0])
+
+	case "time":
+		if len(args) == 1 {
+			a.engineTime = centiseconds(args[0])
+		}
+
+	case "post":
+		a.post = true
+
+	case "nopost":
+		a.post = false
+
+	case "result":
+		a.stopThinking()
+		a.force = true
+
+	case "ping":
+		a.writeln("pong " + strings.Join(args, " "))
+
+	default:
+		// Moves may be sent without usermove by interfaces that rejected it
+		if _, err := engine.ParseMove(command); err == nil && len(args) == 0 {
+			a.userMove(command)
+			return false
+		}
+		a.writeln("Error (unknown command): " + command)
+	}
+
+	return false
+}
+
+// reset starts a new game with the engine playing black
+func (a *Adapter) reset() {
+	a.chess = engine.NewGameChess()
+	a.force = false
+	a.engineColor = engine.Black
+	a.depth = 0
+	a.engineTime = 0
+	a.opponentTime = 0
+	a.scored = false
+}
+
+// answerDraw accepts a draw offer when the engine scored its last move about
+// even, and declines it otherwise
+func (a *Adapter) answerDraw() {
+	if a.chess.IsGameOver() {
+		return
+	}
+	if a.scored && a.score.Mate == 0 && a.score.Score > -drawScore && a.score.Score < drawScore {
+		a.writeln("offer draw")
+		return
+	}
+	a.writeln("tellopponent I decline the draw")
+}
+
+// userMove plays the move of the opponent and answers it if it is the turn
+// of the engine
+func (a *Adapter) userMove(move string) {
+	if a.cancel != nil {
+		a.writeln("Error (command not legal now): usermove " + move)
+		return
+	}
+
+	result, err := a.chess.MoveUCI(move)
+	if err != nil {
+		a.writeln("Illegal move: " + move)
+		return
+	}
+
+	// The engine claims the draws it may claim
+	if result.GameOver || a.chess.ClaimDraw() == nil {
+		a.writeResult()
+		return
+	}
+
+	if !a.force && a.chess.Turn() == a.engineColor {
+		a.think()
+	}
+}
+
+// think starts searching for a move of the engine in the background
+func (a *Adapter) think() {
+	if a.chess.IsGameOver() {
+		a.writeResult()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.searchID++
+	a.cancel = cancel
+
+	id := a.searchID
+	position := *a.chess
+	limits := a.limits()
+	post := a.post
+
+	go func() {
+		result := position.Search(ctx, limits, func(info engine.SearchInfo) {
+			if post {
+				a.writeThinking(info)
+			}
+		})
+		a.done <- searchDone{id: id, result: result}
+	}()
+}
+
+// stopThinking abandons the search in progress, if any
+func (a *Adapter) stopThinking() {
+	if a.cancel == nil {
+		return
+	}
+
+	a.cancel()
+	<-a.done
+	a.cancel = nil
+}
+
+// playSearchResult plays the move found by a search that was not abandoned
+func (a *Adapter) playSearchResult(done searchDone) {
+	if a.cancel == nil || done.id != a.searchID {
+		return
+	}
+	a.cancel()
+	a.cancel = nil
+
+	result, err := a.chess.Move(done.result.BestMove)
+	if err != nil {
+		a.writeln("Error (no legal move): go")
+		return
+	}
+
+	a.score = done.result.Info
+	a.scored = true
+
+	a.writeln("move " + result.UCI)
+	if result.GameOver || a.chess.ClaimDraw() == nil {
+		a.writeResult()
+	}
+}
+
+// limits converts the time controls into limits for the next search
+func (a *Adapter) limits() engine.SearchLimits {
+	limits := engine.SearchLimits{Depth: a.depth}
+
+	switch {
+	case a.moveTime > 0:
+		limits.MoveTime = a.moveTime
+
+	case a.engineTime > 0 || a.baseTime > 0:
+		remaining := a.engineTime
+		if remaining <= 0 {
+			remaining = a.baseTime
+		}
+
+		movesToGo := 0
+		if a.movesPerSession > 0 {
+			played := a.chess.FullMoves() - 1
+			movesToGo = a.movesPerSession - played%a.movesPerSession
+		}
+
+		limits.MoveTime = engine.AllocateTime(remaining, a.increment, movesToGo)
+
+	case a.depth == 0:
+		limits.Depth = defaultDepth
+	}
+
+	return limits
+}
+
+// writeThinking writes a line of thinking output: depth, score in centipawns,
+// time in centiseconds, nodes and the principal variation
+func (a *Adapter) writeThinking(info engine.SearchInfo) {
+	score := info.Score
+	if info.Mate > 0 {
+		score = 100000 + info.Mate
+	} else if info.Mate < 0 {
+		score = -100000 + info.Mate
+	}
+
+	var pv []string
+	for _, move := range info.PV {
+		pv = append(pv, move.String())
+	}
+
+	a.writeln(fmt.Sprintf("%d %d %d %d %s", info.Depth, score, info.Time.Milliseconds()/10, info.Nodes, strings.Join(pv, " ")))
+}
+
+// writeResult tells the interface how the game ended
+func (a *Adapter) writeResult() {
+	var comment string
+	switch a.chess.Method() {
+	case engine.Checkmate:
+		if a.chess.Outcome() == engine.WhiteWon {
+			comment = "White mates"
+		} else {
+			comment = "Black mates"
+		}
+	case engine.Stalemate:
+		comment = "Stalemate"
+	case engine.InsufficientMaterial:
+		comment = "Insufficient material"
+	case engine.FiftyMoveRule:
+		comment = "50 move rule"
+	case engine.SeventyFiveMoveRule:
+		comment = "75 move rule"
+	case engine.ThreefoldRepetition, engine.FivefoldRepetition:
+		comment = "Draw by repetition"
+	default:
+		return
+	}
+
+	a.writeln(fmt.Sprintf("%s {%s}", a.chess.Outcome(), comment))
+}
+
+// writeln writes a line to the interface
+func (a *Adapter) writeln(line string) {
+	a.outMu.Lock()
+	defer a.outMu.Unlock()
+
+	fmt.Fprintln(a.out, line)
+}
+
+// centiseconds parses a time in centiseconds
+func centiseconds(s string) time.Duration {
+	n, _ := strconv.Atoi(s)
+	return time.Duration(n) * 10 * time.Millisecond
+}
+
+// seconds parses a time in seconds, which may have a fraction
+func seconds(s string) time.Duration {
+	n, _ := strconv.ParseFloat(s, 64)
+	return time.Duration(n * float64(time.Second))
+}
+
+// parseBaseTime parses the base time of level, in minutes or minutes:seconds
+func parseBaseTime(s string) time.Duration {
+	minutes, secs, _ := strings.Cut(s, ":")
+	m, _ := strconv.Atoi(minutes)
+	base := time.Duration(m) * time.Minute
+	if secs != "" {
+		base += seconds(secs)
+	}
+	return base
+}
@@ -0,0 +1,131 @@
+package xboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"chess-go/engine"
+)
+
+func TestXBoard_Run(t *testing.T) {
+	inputs := []string{
+		"xboard\nprotover 2\n",
+		"new\nsd 2\nusermove e2e5\n",
+		"new\nping 7\n",
+		"new\nfoo\n",
+		"setboard 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1\nsd 3\ngo\n",
+		"setboard 8/8/8/8/8/8/8/8 w - - 0 1\n",
+		"new\nsd 2\nusermove e2e4\n",
+	}
+
+	expectedOutputs := []string{
+		"feature myname=\"chess-go\" setboard=1 usermove=1 ping=1 playother=1 colors=0 sigint=0 sigterm=0 analyze=0 variants=\"normal\" done=1\n",
+		"Illegal move: e2e5\n",
+		"pong 7\n",
+		"Error (unknown command): foo\n",
+		"move d1d8\n1-0 {White mates}\n",
+		"tellusererror Illegal position\n",
+		"move ",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		var out bytes.Buffer
+		if err := Run(strings.NewReader(input), &out); err != nil {
+			t.Fatalf("FAILED: %q\n\t%s", input, err.Error())
+		}
+
+		output := out.String()
+		if !strings.HasPrefix(output, expected) {
+			t.Errorf("FAILED: %q\n\tgot:     %q\n\texpected:%q", input, output, expected)
+		}
+	}
+}
+func TestXBoard_Force(t *testing.T) {
+	var out bytes.Buffer
+	adapter := NewAdapter(&out)
+
+	for _, line := range []string{"new", "force", "usermove e2e4", "usermove e7e5", "usermove g1f3", "remove"} {
+		adapter.Handle(line)
+	}
+
+	expected := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	output := adapter.chess.GetFEN()
+	if output != expected {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", output, expected)
+	}
+
+	adapter.Handle("undo")
+	if adapter.chess.GetFEN() != engine.DefaultFen {
+		t.Errorf("FAILED\n\tgot:     %+v\n\texpected:%+v", adapter.chess.GetFEN(), engine.DefaultFen)
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("FAILED\n\tgot:     %q\n\texpected:%q", out.String(), "")
+	}
+}
+func TestXBoard_Draw(t *testing.T) {
+	inputs := []string{
+		"",
+		"4k3/8/8/8/8/8/8/4K2R w K - 0 1",
+		"4k3/4p3/8/8/8/8/4P3/4K3 w - - 0 1",
+	}
+
+	// The engine declines before it has a score and when it is winning
+	expectedOutputs := []string{
+		"tellopponent I decline the draw\n",
+		"tellopponent I decline the draw\n",
+		"offer draw\n",
+	}
+
+	for i, input := range inputs {
+		expected := expectedOutputs[i]
+
+		var out bytes.Buffer
+		adapter := NewAdapter(&out)
+		if input != "" {
+			adapter.Handle("setboard " + input)
+			adapter.Handle("sd 2")
+			adapter.Handle("go")
+			adapter.playSearchResult(<-adapter.done)
+		}
+		out.Reset()
+
+		adapter.Handle("draw")
+		if output := out.String(); output != expected {
+			t.Errorf("FAILED: %s\n\tgot:     %q\n\texpected:%q", input, output, expected)
+		}
+	}
+}
+func TestXBoard_Limits(t *testing.T) {
+	inputs := [][]string{
+		{"new"},
+		{"new", "sd 6"},
+		{"new", "st 2.5"},
+		{"new", "level 40 5 0", "time 30000"},
+		{"new", "level 0 2:30 10", "time 15000"},
+	}
+
+	expectedOutputs := []engine.SearchLimits{
+		{Depth: defaultDepth},
+		{Depth: 6},
+		{MoveTime: 2500000000},
+		{MoveTime: 7500000000},
+		{MoveTime: 12500000000},
+	}
+
+	for i, input := range inputs {
+		adapter := NewAdapter(&bytes.Buffer{})
+		for _, line := range input {
+			adapter.Handle(line)
+		}
+
+		expected := expectedOutputs[i]
+		output := adapter.limits()
+		if output != expected {
+			t.Errorf("FAILED: %v\n\tgot:     %+v\n\texpected:%+v", input, output, expected)
+		}
+	}
+}